@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/spf13/cobra"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/rabbitmq"
+)
+
+var dlqLimit int
+
+var dlqCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "Inspect and replay dead-lettered notifications",
+}
+
+var dlqListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show dead-lettered notifications without removing them",
+	Run: func(cmd *cobra.Command, args []string) {
+		ch, closeFn := openDLQChannel()
+		defer closeFn()
+
+		// Messages stay unacknowledged and go back to the queue when the
+		// channel closes.
+		n := 0
+		for dlqLimit <= 0 || n < dlqLimit {
+			d, ok, err := ch.Get(rabbitmq.DeadLetterQueue, false)
+			if err != nil {
+				log.Fatalf("Failed to read dead-letter queue: %v", err)
+			}
+			if !ok {
+				break
+			}
+			n++
+			fmt.Printf("#%d retries=%d error=%q\n  %s\n", n, rabbitmq.RetryCount(d.Headers), lastError(d), d.Body)
+		}
+		fmt.Printf("%d dead-lettered message(s)\n", n)
+	},
+}
+
+var dlqReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Move dead-lettered notifications back onto the notifications queue",
+	Run: func(cmd *cobra.Command, args []string) {
+		ch, closeFn := openDLQChannel()
+		defer closeFn()
+
+		n := 0
+		for dlqLimit <= 0 || n < dlqLimit {
+			d, ok, err := ch.Get(rabbitmq.DeadLetterQueue, false)
+			if err != nil {
+				log.Fatalf("Failed to read dead-letter queue: %v", err)
+			}
+			if !ok {
+				break
+			}
+
+			// Start over with a fresh retry budget
+			headers := rabbitmq.CopyHeaders(d.Headers)
+			delete(headers, rabbitmq.RetryCountHeader)
+			delete(headers, rabbitmq.LastErrorHeader)
+			delete(headers, "x-death")
+
+			err = ch.PublishWithContext(context.Background(), "", rabbitmq.NotificationsQueue, false, false, amqp.Publishing{
+				Headers:      headers,
+				ContentType:  d.ContentType,
+				DeliveryMode: amqp.Persistent,
+				Body:         d.Body,
+			})
+			if err != nil {
+				_ = d.Nack(false, true)
+				log.Fatalf("Failed to replay message: %v", err)
+			}
+			if err := d.Ack(false); err != nil {
+				log.Fatalf("Failed to acknowledge replayed message: %v", err)
+			}
+			n++
+		}
+		fmt.Printf("Replayed %d message(s)\n", n)
+	},
+}
+
+func init() {
+	dlqCmd.PersistentFlags().IntVar(&dlqLimit, "limit", 0, "maximum number of messages to process (0 for all)")
+	dlqCmd.AddCommand(dlqListCmd, dlqReplayCmd)
+	RootCmd.AddCommand(dlqCmd)
+}
+
+func openDLQChannel() (*amqp.Channel, func()) {
+	cfg := config.LoadConfig()
+	conn, err := amqp.Dial(cfg.RabbitMQ.URL)
+	if err != nil {
+		log.Fatalf("Could not connect to RabbitMQ: %v", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		log.Fatalf("Could not open RabbitMQ channel: %v", err)
+	}
+	if _, err := rabbitmq.DeclareTopology(ch); err != nil {
+		log.Fatalf("Could not declare queues: %v", err)
+	}
+	return ch, func() {
+		_ = ch.Close()
+		_ = conn.Close()
+	}
+}
+
+// lastError prefers the reason recorded by the consumer and falls back to
+// the broker's x-death reason for messages rejected without one.
+func lastError(d amqp.Delivery) string {
+	if reason, ok := d.Headers[rabbitmq.LastErrorHeader].(string); ok {
+		return reason
+	}
+	if deaths, ok := d.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			if reason, ok := death["reason"].(string); ok {
+				return reason
+			}
+		}
+	}
+	return ""
+}
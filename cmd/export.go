@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/export"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+	exportQuery  domain.InventoryQuery
+	exportTags   []string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export customers and their resources as CSV, JSON Lines or XLSX",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, pair := range exportTags {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || key == "" {
+				log.Fatalf("Invalid --tag %q, expected key=value", pair)
+			}
+			if exportQuery.Tags == nil {
+				exportQuery.Tags = map[string]string{}
+			}
+			exportQuery.Tags[key] = value
+		}
+
+		var out io.Writer = os.Stdout
+		if exportOutput != "" {
+			f, err := os.Create(exportOutput)
+			if err != nil {
+				log.Fatalf("Could not create %s: %v", exportOutput, err)
+			}
+			defer f.Close()
+			out = f
+		}
+		buf := bufio.NewWriter(out)
+		w, err := export.NewWriter(exportFormat, buf)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		cfg := config.LoadConfig()
+		// Logs go to stderr, as the export may be written to stdout
+		logger, err := logging.New(cfg.Logging, os.Stderr)
+		if err != nil {
+			log.Fatalf("Could not set up logging: %v", err)
+		}
+		conn, err := db.NewPostgresDB(cfg.DB)
+		if err != nil {
+			log.Fatalf("Could not connect to Postgres: %v", err)
+		}
+		defer conn.Close()
+
+		inventoryUC := usecase.NewInventoryUsecase(repository.NewInventoryRepository(conn, db.NewTimeouts(cfg.DB)), logger)
+		rows := 0
+		err = inventoryUC.Export(context.Background(), exportQuery, func(row domain.InventoryRow) error {
+			rows++
+			return w.Write(row)
+		})
+		if err == nil {
+			err = w.Close()
+		}
+		if err == nil {
+			err = buf.Flush()
+		}
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		if exportOutput != "" {
+			fmt.Printf("Exported %d row(s) to %s\n", rows, exportOutput)
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", export.FormatCSV, "output format: "+strings.Join(export.Formats, ", "))
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "file to write; standard output if empty")
+	exportCmd.Flags().Int64Var(&exportQuery.CustomerID, "customer-id", 0, "only export this customer")
+	exportCmd.Flags().StringVar(&exportQuery.Provider, "provider", "", "only export resources of this provider")
+	exportCmd.Flags().StringVar(&exportQuery.AccountID, "account-id", "", "only export resources in this account")
+	exportCmd.Flags().StringVar(&exportQuery.Type, "type", "", "only export resources of this type")
+	exportCmd.Flags().StringVar(&exportQuery.Region, "region", "", "only export resources in this region")
+	exportCmd.Flags().StringArrayVar(&exportTags, "tag", nil, "only export resources with this key=value tag; repeatable")
+	RootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/importer"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+)
+
+var importFormat string
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>...",
+	Short: "Import resources from AWS Config, GCP Cloud Asset Inventory or Azure Resource Graph exports",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if importFormat == "" {
+			log.Fatalf("--format is required, expected one of %s", strings.Join(importer.Formats, ", "))
+		}
+
+		cfg := config.LoadConfig()
+		conn, err := db.NewPostgresDB(cfg.DB)
+		if err != nil {
+			log.Fatalf("Could not connect to Postgres: %v", err)
+		}
+		defer conn.Close()
+
+		resourceRepo := repository.NewResourceRepository(conn, db.NewTimeouts(cfg.DB))
+
+		// Each file is imported in its own batches of transactions
+		for _, path := range args {
+			f, err := os.Open(path)
+			if err != nil {
+				log.Fatalf("Could not open %s: %v", path, err)
+			}
+			resources, err := importer.Parse(importFormat, f)
+			f.Close()
+			if err != nil {
+				log.Fatalf("Could not read %s: %v", path, err)
+			}
+			if len(resources) == 0 {
+				fmt.Printf("%s: no resources found\n", path)
+				continue
+			}
+
+			summary, err := resourceRepo.Import(context.Background(), resources)
+			if err != nil {
+				log.Fatalf("Could not import %s after %d created, %d updated, %d unchanged, %d skipped: %v",
+					path, summary.Created, summary.Updated, summary.Unchanged, summary.Skipped, err)
+			}
+			fmt.Printf("%s: %d created, %d updated, %d unchanged, %d skipped\n",
+				path, summary.Created, summary.Updated, summary.Unchanged, summary.Skipped)
+		}
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFormat, "format", "", "export format: "+strings.Join(importer.Formats, ", "))
+	RootCmd.AddCommand(importCmd)
+}
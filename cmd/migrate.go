@@ -1,11 +1,17 @@
 package cmd
 
 import (
-	"database/sql"
+	"context"
 	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/pressly/goose/v3"
 	"github.com/spf13/cobra"
@@ -17,39 +23,268 @@ import (
 //go:embed migrations/*.sql
 var embedMigrations embed.FS
 
+// Where migrate create puts new files when no directory is given.
+const sourceMigrationsDir = "cmd/migrations"
+
+var migrateDir string
+
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Run database migrations",
+	Long: `Run database migrations. Without a subcommand all pending migrations are applied.
+
+The migrations built into the binary are used unless --dir or DB_MIGRATIONS_PATH
+names a directory on disk. Migrations hold a Postgres advisory lock while they
+run, so several replicas may migrate at once.`,
+	Args: cobra.NoArgs,
+	Run:  migrateUp,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	Args:  cobra.NoArgs,
+	Run:   migrateUp,
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back the most recent migration",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(cmd, func(ctx context.Context, p *goose.Provider) error {
+			result, err := p.Down(ctx)
+			if errors.Is(err, goose.ErrNoNextVersion) {
+				fmt.Println("No migrations to roll back")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Println(result)
+			return nil
+		})
+	},
+}
+
+var migrateRedoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Roll back the most recent migration and apply it again",
+	Long: `Roll back the most recent migration and apply it again.
+
+The two steps take the advisory lock one after the other, so another migrator
+may run in between. Do not redo while replicas could be migrating.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(cmd, func(ctx context.Context, p *goose.Provider) error {
+			down, err := p.Down(ctx)
+			if errors.Is(err, goose.ErrNoNextVersion) {
+				fmt.Println("No migrations to redo")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Println(down)
+			up, err := p.ApplyVersion(ctx, down.Source.Version, true)
+			if err != nil {
+				return err
+			}
+			fmt.Println(up)
+			return nil
+		})
+	},
+}
+
+var migrateToCmd = &cobra.Command{
+	Use:   "to <version>",
+	Short: "Migrate up or down to a specific version",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || version < 0 {
+			log.Fatalf("Invalid version %q", args[0])
+		}
+		withMigrator(cmd, func(ctx context.Context, p *goose.Provider) error {
+			current, err := p.GetDBVersion(ctx)
+			if err != nil {
+				return err
+			}
+			var results []*goose.MigrationResult
+			if version >= current {
+				results, err = p.UpTo(ctx, version)
+			} else {
+				results, err = p.DownTo(ctx, version)
+			}
+			printResults(results, err)
+			return err
+		})
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which migrations have been applied",
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg := config.LoadConfig()
-		conn, err := db.NewPostgresDB(cfg.DB)
+		withMigrator(cmd, func(ctx context.Context, p *goose.Provider) error {
+			statuses, err := p.Status(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%-21s %s\n", "Applied At", "Migration")
+			for _, s := range statuses {
+				applied := "Pending"
+				if s.State == goose.StateApplied {
+					applied = s.AppliedAt.Local().Format(time.DateTime)
+				}
+				fmt.Printf("%-21s %s\n", applied, filepath.Base(s.Source.Path))
+			}
+			return nil
+		})
+	},
+}
+
+var migrateVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the current schema version",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		withMigrator(cmd, func(ctx context.Context, p *goose.Provider) error {
+			current, latest, err := p.GetVersions(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Database version: %d (latest migration: %d)\n", current, latest)
+			return nil
+		})
+	},
+}
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a new SQL migration with the next version number",
+	Long: `Create a new SQL migration with the next version number, such as
+cmd/migrations/011_<name>.sql. The file is written to --dir or DB_MIGRATIONS_PATH,
+or to cmd/migrations when the built-in migrations are in use, and is embedded in
+the binary on the next build.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if !migrationName.MatchString(name) {
+			log.Fatalf("Invalid migration name %q, use lower case letters, digits and underscores", name)
+		}
+		dir := migrationsDir(cmd, config.LoadConfig())
+		if dir == "" {
+			dir = sourceMigrationsDir
+		}
+
+		entries, err := os.ReadDir(dir)
 		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
+			log.Fatalf("Failed to read %s: %v", dir, err)
+		}
+		var latest int64
+		for _, entry := range entries {
+			if version, err := goose.NumericComponent(entry.Name()); err == nil && version > latest {
+				latest = version
+			}
 		}
-		defer conn.Close()
 
-		runMigrations(conn, cfg.DB.MigrationsPath)
+		path := filepath.Join(dir, fmt.Sprintf("%03d_%s.sql", latest+1, name))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(migrationTemplate); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+		fmt.Printf("Created %s\n", path)
 	},
 }
 
+const migrationTemplate = `-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+`
+
 func init() {
+	migrateCmd.PersistentFlags().StringVar(&migrateDir, "dir", "", "directory of migrations on disk; overrides DB_MIGRATIONS_PATH, the built-in migrations are used if empty")
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateRedoCmd, migrateToCmd, migrateStatusCmd, migrateVersionCmd, migrateCreateCmd)
 	RootCmd.AddCommand(migrateCmd)
 }
 
-func runMigrations(db *sql.DB, migrationsPath string) {
-	goose.SetBaseFS(embedMigrations)
-	if migrationsPath == "" {
-		absPath, err := filepath.Abs("migrations")
-		if err != nil {
-			log.Fatalf("Failed to resolve migrations path: %v", err)
+func migrateUp(cmd *cobra.Command, args []string) {
+	withMigrator(cmd, func(ctx context.Context, p *goose.Provider) error {
+		results, err := p.Up(ctx)
+		printResults(results, err)
+		if err == nil && len(results) == 0 {
+			fmt.Println("No pending migrations")
 		}
-		migrationsPath = absPath
+		return err
+	})
+}
+
+// migrationsDir returns the directory chosen with --dir, falling back to
+// the configured one. An empty result selects the built-in migrations.
+func migrationsDir(cmd *cobra.Command, cfg *config.Config) string {
+	if cmd.Flags().Changed("dir") {
+		return migrateDir
 	}
+	return cfg.DB.MigrationsPath
+}
+
+// Migrations returns the migrations built into the binary, which are the
+// ones the servers expect to have been applied.
+func Migrations() fs.FS {
+	// The directory is embedded above, so this cannot fail
+	fsys, _ := fs.Sub(embedMigrations, "migrations")
+	return fsys
+}
 
-	err := goose.Up(db, migrationsPath)
+func migrationsFS(dir string) fs.FS {
+	if dir == "" {
+		return Migrations()
+	}
+	return os.DirFS(dir)
+}
+
+// withMigrator connects to the database and runs fn with a provider for the
+// chosen migrations, exiting if either fails.
+func withMigrator(cmd *cobra.Command, fn func(ctx context.Context, p *goose.Provider) error) {
+	cfg := config.LoadConfig()
+	conn, err := db.NewPostgresDB(cfg.DB)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	provider, err := db.NewMigrator(conn, migrationsFS(migrationsDir(cmd, cfg)))
+	if err != nil {
+		conn.Close()
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	err = fn(context.Background(), provider)
+	provider.Close()
 	if err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
+}
 
-	fmt.Println("Migrations applied successfully!")
+// printResults prints the migrations that ran, including those applied
+// before a later one failed.
+func printResults(results []*goose.MigrationResult, err error) {
+	var partial *goose.PartialError
+	if errors.As(err, &partial) {
+		results = partial.Applied
+	}
+	for _, result := range results {
+		fmt.Println(result)
+	}
 }
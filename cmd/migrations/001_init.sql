@@ -35,5 +35,6 @@ CREATE TABLE IF NOT EXISTS notifications (
 
 -- +goose Down
 DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS customer_resource;
 DROP TABLE IF EXISTS resources;
 DROP TABLE IF EXISTS customers;
\ No newline at end of file
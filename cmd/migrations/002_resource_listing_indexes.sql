@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS idx_resources_created_at_id ON resources (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_resources_type ON resources (type);
+CREATE INDEX IF NOT EXISTS idx_resources_region ON resources (region);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_resources_region;
+DROP INDEX IF EXISTS idx_resources_type;
+DROP INDEX IF EXISTS idx_resources_created_at_id;
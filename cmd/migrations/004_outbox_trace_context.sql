@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS trace_context JSONB;
+
+-- +goose Down
+ALTER TABLE outbox DROP COLUMN IF EXISTS trace_context;
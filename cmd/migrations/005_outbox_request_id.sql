@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS request_id VARCHAR(128);
+
+-- +goose Down
+ALTER TABLE outbox DROP COLUMN IF EXISTS request_id;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS role_bindings (
+    principal_kind VARCHAR(20) NOT NULL CHECK (principal_kind IN ('service', 'user')),
+    subject VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL CHECK (role IN ('admin', 'operator', 'customer-viewer')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (principal_kind, subject, role)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS role_bindings;
@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    actor_kind VARCHAR(20) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    customer_id BIGINT,
+    before JSONB,
+    after JSONB,
+    request_id VARCHAR(128)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events (entity_type, entity_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_customer ON audit_events (customer_id, id) WHERE customer_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at ON audit_events (occurred_at);
+
+-- The table is append-only: rows can be inserted but never changed
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
+DROP TABLE IF EXISTS audit_events;
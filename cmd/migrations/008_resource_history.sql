@@ -0,0 +1,88 @@
+-- +goose Up
+-- Each row is one version of a resource, valid over [valid_from, valid_to).
+-- The current version has no valid_to.
+CREATE TABLE IF NOT EXISTS resource_versions (
+    id BIGSERIAL PRIMARY KEY,
+    resource_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(100) NOT NULL,
+    region VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    valid_from TIMESTAMP NOT NULL,
+    valid_to TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_resource_versions_resource ON resource_versions (resource_id, valid_from);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_resource_versions_current ON resource_versions (resource_id) WHERE valid_to IS NULL;
+
+-- Each row is one period during which a customer had a resource
+CREATE TABLE IF NOT EXISTS customer_resource_versions (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id INT NOT NULL,
+    resource_id INT NOT NULL,
+    valid_from TIMESTAMP NOT NULL,
+    valid_to TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_resource_versions_customer ON customer_resource_versions (customer_id, valid_from);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_resource_versions_current
+    ON customer_resource_versions (customer_id, resource_id) WHERE valid_to IS NULL;
+
+-- Versions are kept by triggers so that every write path, including the CLI,
+-- records history
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION resources_track_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.name, NEW.type, NEW.region) IS NOT DISTINCT FROM (OLD.name, OLD.type, OLD.region) THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE resource_versions SET valid_to = NOW() WHERE resource_id = OLD.id AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO resource_versions (resource_id, name, type, region, created_at, valid_from)
+        VALUES (NEW.id, NEW.name, NEW.type, NEW.region, NEW.created_at, NOW());
+        RETURN NEW;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION customer_resource_track_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO customer_resource_versions (customer_id, resource_id, valid_from)
+        VALUES (NEW.customer_id, NEW.resource_id, NOW());
+        RETURN NEW;
+    END IF;
+    UPDATE customer_resource_versions SET valid_to = NOW()
+    WHERE customer_id = OLD.customer_id AND resource_id = OLD.resource_id AND valid_to IS NULL;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER resources_track_version
+    AFTER INSERT OR UPDATE OR DELETE ON resources
+    FOR EACH ROW EXECUTE FUNCTION resources_track_version();
+
+CREATE TRIGGER customer_resource_track_version
+    AFTER INSERT OR DELETE ON customer_resource
+    FOR EACH ROW EXECUTE FUNCTION customer_resource_track_version();
+
+-- Existing rows start their history when they were created
+INSERT INTO resource_versions (resource_id, name, type, region, created_at, valid_from)
+SELECT id, name, type, region, created_at, created_at FROM resources;
+
+INSERT INTO customer_resource_versions (customer_id, resource_id, valid_from)
+SELECT customer_id, resource_id, COALESCE(created_at, NOW()) FROM customer_resource;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS customer_resource_track_version ON customer_resource;
+DROP TRIGGER IF EXISTS resources_track_version ON resources;
+DROP FUNCTION IF EXISTS customer_resource_track_version();
+DROP FUNCTION IF EXISTS resources_track_version();
+DROP TABLE IF EXISTS customer_resource_versions;
+DROP TABLE IF EXISTS resource_versions;
@@ -0,0 +1,115 @@
+-- +goose Up
+-- Deleted rows are kept with deleted_at set until the purge command removes
+-- them. An assignment is soft deleted together with its resource or customer,
+-- at the same deleted_at, so that a restore can bring it back.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE resources ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE customer_resource ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_resources_deleted_at ON resources (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- A deleted customer's email can be used again
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email_live ON customers (email) WHERE deleted_at IS NULL;
+
+-- Soft deletion ends the current version and a restore starts a new one
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION resources_track_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.name, NEW.type, NEW.region, NEW.deleted_at) IS NOT DISTINCT FROM (OLD.name, OLD.type, OLD.region, OLD.deleted_at) THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE resource_versions SET valid_to = NOW() WHERE resource_id = OLD.id AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        IF NEW.deleted_at IS NULL THEN
+            INSERT INTO resource_versions (resource_id, name, type, region, created_at, valid_from)
+            VALUES (NEW.id, NEW.name, NEW.type, NEW.region, NEW.created_at, NOW());
+        END IF;
+        RETURN NEW;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION customer_resource_track_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.deleted_at IS NULL) THEN
+        IF TG_OP = 'INSERT' OR OLD.deleted_at IS NOT NULL THEN
+            INSERT INTO customer_resource_versions (customer_id, resource_id, valid_from)
+            VALUES (NEW.customer_id, NEW.resource_id, NOW());
+        END IF;
+        RETURN NEW;
+    END IF;
+    UPDATE customer_resource_versions SET valid_to = NOW()
+    WHERE customer_id = OLD.customer_id AND resource_id = OLD.resource_id AND valid_to IS NULL;
+    IF TG_OP = 'UPDATE' THEN
+        RETURN NEW;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS customer_resource_track_version ON customer_resource;
+CREATE TRIGGER customer_resource_track_version
+    AFTER INSERT OR UPDATE OF deleted_at OR DELETE ON customer_resource
+    FOR EACH ROW EXECUTE FUNCTION customer_resource_track_version();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS customer_resource_track_version ON customer_resource;
+CREATE TRIGGER customer_resource_track_version
+    AFTER INSERT OR DELETE ON customer_resource
+    FOR EACH ROW EXECUTE FUNCTION customer_resource_track_version();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION customer_resource_track_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO customer_resource_versions (customer_id, resource_id, valid_from)
+        VALUES (NEW.customer_id, NEW.resource_id, NOW());
+        RETURN NEW;
+    END IF;
+    UPDATE customer_resource_versions SET valid_to = NOW()
+    WHERE customer_id = OLD.customer_id AND resource_id = OLD.resource_id AND valid_to IS NULL;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION resources_track_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.name, NEW.type, NEW.region) IS NOT DISTINCT FROM (OLD.name, OLD.type, OLD.region) THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE resource_versions SET valid_to = NOW() WHERE resource_id = OLD.id AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO resource_versions (resource_id, name, type, region, created_at, valid_from)
+        VALUES (NEW.id, NEW.name, NEW.type, NEW.region, NEW.created_at, NOW());
+        RETURN NEW;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Soft deleted rows are removed for good, as a delete did before
+DELETE FROM customer_resource WHERE deleted_at IS NOT NULL;
+DELETE FROM resources WHERE deleted_at IS NOT NULL;
+DELETE FROM customers WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_customers_email_live;
+ALTER TABLE customers ADD CONSTRAINT customers_email_key UNIQUE (email);
+
+DROP INDEX IF EXISTS idx_resources_deleted_at;
+DROP INDEX IF EXISTS idx_customers_deleted_at;
+ALTER TABLE customer_resource DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE resources DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE customers DROP COLUMN IF EXISTS deleted_at;
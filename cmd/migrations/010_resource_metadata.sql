@@ -0,0 +1,103 @@
+-- +goose Up
+-- account_id holds the AWS account, Azure subscription or GCP project and
+-- native_id the ARN or provider resource ID
+ALTER TABLE resources
+    ADD COLUMN IF NOT EXISTS provider VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS account_id VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS native_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE resources ADD CONSTRAINT resources_provider_check CHECK (provider IN ('', 'aws', 'gcp', 'azure'));
+ALTER TABLE resources ADD CONSTRAINT resources_tags_check CHECK (jsonb_typeof(tags) = 'object');
+ALTER TABLE resources ADD CONSTRAINT resources_attributes_check CHECK (jsonb_typeof(attributes) = 'object');
+
+-- The provider was only implied by the name prefix so far. The history
+-- trigger ignores these columns until it is replaced below, so the backfill
+-- does not start new versions.
+UPDATE resources SET provider = CASE
+    WHEN name LIKE 'aws\_%' THEN 'aws'
+    WHEN name LIKE 'gcp\_%' THEN 'gcp'
+    WHEN name LIKE 'azure\_%' THEN 'azure'
+    ELSE ''
+END;
+
+CREATE INDEX IF NOT EXISTS idx_resources_provider_account ON resources (provider, account_id);
+CREATE INDEX IF NOT EXISTS idx_resources_tags ON resources USING GIN (tags jsonb_path_ops);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_resources_native_id ON resources (provider, native_id) WHERE native_id <> '';
+
+ALTER TABLE resource_versions
+    ADD COLUMN IF NOT EXISTS provider VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS account_id VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS native_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+UPDATE resource_versions SET provider = CASE
+    WHEN name LIKE 'aws\_%' THEN 'aws'
+    WHEN name LIKE 'gcp\_%' THEN 'gcp'
+    WHEN name LIKE 'azure\_%' THEN 'azure'
+    ELSE ''
+END;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION resources_track_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.name, NEW.type, NEW.region, NEW.provider, NEW.account_id, NEW.native_id, NEW.tags, NEW.attributes, NEW.deleted_at)
+        IS NOT DISTINCT FROM (OLD.name, OLD.type, OLD.region, OLD.provider, OLD.account_id, OLD.native_id, OLD.tags, OLD.attributes, OLD.deleted_at) THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE resource_versions SET valid_to = NOW() WHERE resource_id = OLD.id AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        IF NEW.deleted_at IS NULL THEN
+            INSERT INTO resource_versions (resource_id, name, type, region, provider, account_id, native_id, tags, attributes, created_at, valid_from)
+            VALUES (NEW.id, NEW.name, NEW.type, NEW.region, NEW.provider, NEW.account_id, NEW.native_id, NEW.tags, NEW.attributes, NEW.created_at, NOW());
+        END IF;
+        RETURN NEW;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION resources_track_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.name, NEW.type, NEW.region, NEW.deleted_at) IS NOT DISTINCT FROM (OLD.name, OLD.type, OLD.region, OLD.deleted_at) THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE resource_versions SET valid_to = NOW() WHERE resource_id = OLD.id AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        IF NEW.deleted_at IS NULL THEN
+            INSERT INTO resource_versions (resource_id, name, type, region, created_at, valid_from)
+            VALUES (NEW.id, NEW.name, NEW.type, NEW.region, NEW.created_at, NOW());
+        END IF;
+        RETURN NEW;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+ALTER TABLE resource_versions
+    DROP COLUMN IF EXISTS attributes,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS native_id,
+    DROP COLUMN IF EXISTS account_id,
+    DROP COLUMN IF EXISTS provider;
+
+DROP INDEX IF EXISTS idx_resources_native_id;
+DROP INDEX IF EXISTS idx_resources_tags;
+DROP INDEX IF EXISTS idx_resources_provider_account;
+
+ALTER TABLE resources
+    DROP COLUMN IF EXISTS attributes,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS native_id,
+    DROP COLUMN IF EXISTS account_id,
+    DROP COLUMN IF EXISTS provider;
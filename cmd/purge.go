@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+)
+
+var purgeRetention time.Duration
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently remove customers and resources deleted longer ago than the retention window",
+	Run: func(cmd *cobra.Command, args []string) {
+		if purgeRetention <= 0 {
+			log.Fatalf("Invalid --retention %s, expected a positive duration", purgeRetention)
+		}
+
+		cfg := config.LoadConfig()
+		conn, err := db.NewPostgresDB(cfg.DB)
+		if err != nil {
+			log.Fatalf("Could not connect to Postgres: %v", err)
+		}
+		defer conn.Close()
+
+		timeouts := db.NewTimeouts(cfg.DB)
+		resourceRepo := repository.NewResourceRepository(conn, timeouts)
+		customerRepo := repository.NewCustomerRepository(conn, timeouts)
+
+		// deleted_at is stored in UTC without a zone
+		cutoff := time.Now().Add(-purgeRetention).UTC()
+		resources, err := resourceRepo.Purge(context.Background(), cutoff)
+		if err != nil {
+			log.Fatalf("Could not purge resources: %v", err)
+		}
+		customers, err := customerRepo.Purge(context.Background(), cutoff)
+		if err != nil {
+			log.Fatalf("Could not purge customers: %v", err)
+		}
+		fmt.Printf("Purged %d resource(s) and %d customer(s) deleted before %s\n",
+			resources, customers, cutoff.Format(time.RFC3339))
+	},
+}
+
+func init() {
+	purgeCmd.Flags().DurationVar(&purgeRetention, "retention", 30*24*time.Hour, "how long deleted records are kept before they are purged")
+	RootCmd.AddCommand(purgeCmd)
+}
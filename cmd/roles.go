@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+)
+
+var (
+	roleKind    string
+	roleSubject string
+)
+
+var rolesCmd = &cobra.Command{
+	Use:   "roles",
+	Short: "Grant, revoke and list the roles of services and users",
+}
+
+var rolesGrantCmd = &cobra.Command{
+	Use:   "grant <role>",
+	Short: "Grant a role to a service or user",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		binding := roleBinding(args[0])
+		withRoleRepository(func(repo repository.RoleRepository) error {
+			if err := repo.Grant(context.Background(), &binding); err != nil {
+				return fmt.Errorf("could not grant role: %w", err)
+			}
+			return nil
+		})
+		fmt.Printf("Granted %s to %s %s\n", binding.Role, binding.PrincipalKind, binding.Subject)
+	},
+}
+
+var rolesRevokeCmd = &cobra.Command{
+	Use:   "revoke <role>",
+	Short: "Revoke a role from a service or user",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		binding := roleBinding(args[0])
+		withRoleRepository(func(repo repository.RoleRepository) error {
+			revoked, err := repo.Revoke(context.Background(), binding)
+			if err != nil {
+				return fmt.Errorf("could not revoke role: %w", err)
+			}
+			if !revoked {
+				return fmt.Errorf("%s %s does not have role %s", binding.PrincipalKind, binding.Subject, binding.Role)
+			}
+			return nil
+		})
+		fmt.Printf("Revoked %s from %s %s\n", binding.Role, binding.PrincipalKind, binding.Subject)
+	},
+}
+
+var rolesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all role bindings",
+	Run: func(cmd *cobra.Command, args []string) {
+		withRoleRepository(func(repo repository.RoleRepository) error {
+			bindings, err := repo.List(context.Background())
+			if err != nil {
+				return fmt.Errorf("could not list roles: %w", err)
+			}
+			for _, b := range bindings {
+				fmt.Printf("%-8s %-32s %s\n", b.PrincipalKind, b.Subject, b.Role)
+			}
+			fmt.Printf("%d role binding(s)\n", len(bindings))
+			return nil
+		})
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{rolesGrantCmd, rolesRevokeCmd} {
+		c.Flags().StringVar(&roleKind, "kind", string(auth.KindUser), "principal kind: user (JWT sub) or service (API key name)")
+		c.Flags().StringVar(&roleSubject, "subject", "", "JWT sub claim or API key service name")
+		_ = c.MarkFlagRequired("subject")
+	}
+	rolesCmd.AddCommand(rolesGrantCmd, rolesRevokeCmd, rolesListCmd)
+	RootCmd.AddCommand(rolesCmd)
+}
+
+func roleBinding(roleName string) domain.RoleBinding {
+	role, err := auth.ParseRole(roleName)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if kind := auth.Kind(roleKind); kind != auth.KindUser && kind != auth.KindService {
+		log.Fatalf("Invalid --kind %q, expected %s or %s", roleKind, auth.KindUser, auth.KindService)
+	}
+	return domain.RoleBinding{PrincipalKind: roleKind, Subject: roleSubject, Role: string(role)}
+}
+
+// withRoleRepository connects to the database and runs fn with a role
+// repository, closing the connection before exiting if fn fails.
+func withRoleRepository(fn func(repository.RoleRepository) error) {
+	cfg := config.LoadConfig()
+	conn, err := db.NewPostgresDB(cfg.DB)
+	if err != nil {
+		log.Fatalf("Could not connect to Postgres: %v", err)
+	}
+	err = fn(repository.NewRoleRepository(conn, db.NewTimeouts(cfg.DB)))
+	conn.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,18 +1,49 @@
 package cmd
 
 import (
-	"database/sql"
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
 	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/catalog"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
 	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
-	"github.com/spf13/cobra"
+)
+
+//go:embed fixtures/catalog.yaml
+var defaultCatalog []byte
+
+var (
+	seedFile   string
+	seedDryRun bool
+	seedPrune  bool
 )
 
 var seedCmd = &cobra.Command{
 	Use:   "seed",
-	Short: "Seed predefined cloud resources",
+	Short: "Seed cloud resources, customers and assignments from a catalog file",
+	Long: `Seed cloud resources, customers and assignments from a YAML, JSON or CSV
+catalog file, or the built-in catalog without --file. The file is applied in a
+single transaction: resources are matched on name and customers on email.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		var c *domain.Catalog
+		var err error
+		if seedFile == "" {
+			c, err = catalog.Parse(catalog.FormatYAML, bytes.NewReader(defaultCatalog))
+		} else {
+			c, err = catalog.Load(seedFile)
+		}
+		if err != nil {
+			log.Fatalf("Could not read catalog: %v", err)
+		}
+
 		cfg := config.LoadConfig()
 		conn, err := db.NewPostgresDB(cfg.DB)
 		if err != nil {
@@ -20,38 +51,29 @@ var seedCmd = &cobra.Command{
 		}
 		defer conn.Close()
 
-		if err := seedResources(conn); err != nil {
-			log.Fatalf("Seeding failed: %v", err)
+		catalogRepo := repository.NewCatalogRepository(conn, db.NewTimeouts(cfg.DB))
+		summary, err := catalogRepo.Seed(context.Background(), c, domain.SeedOptions{DryRun: seedDryRun, Prune: seedPrune})
+		if err != nil {
+			log.Fatalf("Seeding failed, nothing was changed: %v", err)
+		}
+
+		if seedDryRun {
+			fmt.Println("Dry run, nothing was changed:")
+		}
+		fmt.Printf("Resources: %d created, %d updated, %d unchanged, %d pruned\n",
+			summary.ResourcesCreated, summary.ResourcesUpdated, summary.ResourcesUnchanged, len(summary.ResourcesPruned))
+		if len(summary.ResourcesPruned) > 0 {
+			fmt.Printf("Pruned: %s\n", strings.Join(summary.ResourcesPruned, ", "))
 		}
-		log.Println("Seeding successful!")
+		fmt.Printf("Customers: %d created, %d updated, %d unchanged\n",
+			summary.CustomersCreated, summary.CustomersUpdated, summary.CustomersUnchanged)
+		fmt.Printf("Assignments: %d added\n", summary.Assigned)
 	},
 }
 
 func init() {
+	seedCmd.Flags().StringVar(&seedFile, "file", "", "catalog file (.yaml, .yml, .json or .csv); the built-in catalog if empty")
+	seedCmd.Flags().BoolVar(&seedDryRun, "dry-run", false, "report the changes without applying them")
+	seedCmd.Flags().BoolVar(&seedPrune, "prune", false, "delete the catalog resources that are not in the file")
 	RootCmd.AddCommand(seedCmd)
 }
-
-func seedResources(db *sql.DB) error {
-	resources := []struct {
-		Name   string
-		Type   string
-		Region string
-	}{
-		{"aws_vpc_main", "VPC", "us-east-1"},
-		{"gcp_vm_instance", "Compute", "us-central1"},
-		{"azure_sql_db", "Database", "eastus"},
-		// Add more as needed...
-	}
-
-	for _, r := range resources {
-		_, err := db.Exec(`
-            INSERT INTO resources (name, type, region, created_at, updated_at)
-            VALUES ($1, $2, $3, NOW(), NOW())
-            ON CONFLICT (name) DO NOTHING
-        `, r.Name, r.Type, r.Region)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
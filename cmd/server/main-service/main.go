@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -12,9 +18,18 @@ import (
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/service"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/transport/rest"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
 	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/health"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/metrics"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/rabbitmq"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
 )
 
+// migrateOnStart applies the built-in migrations at startup.
+var migrateOnStart bool
+
 var serverCmd = &cobra.Command{
 	Use:   "main-server",
 	Short: "Start the Aqua Security Cloud Resource Inventory Main Server",
@@ -22,47 +37,141 @@ var serverCmd = &cobra.Command{
 		// Load config
 		cfg := config.LoadConfig()
 
+		// Init logging
+		logger, err := logging.New(cfg.Logging, os.Stdout)
+		if err != nil {
+			log.Fatalf("Could not set up logging: %v", err)
+		}
+		// Route the standard logger, used by libraries, through slog too
+		slog.SetDefault(logger)
+
+		// Init tracing
+		shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing, "main-service")
+		if err != nil {
+			fatal(logger, "could not set up tracing", err)
+		}
+
+		// Init authentication
+		authenticator, err := auth.NewAuthenticator(cfg.Auth)
+		if err != nil {
+			fatal(logger, "could not set up authentication", err)
+		}
+
 		// Init DB
 		pgDB, err := db.NewPostgresDB(cfg.DB)
 		if err != nil {
-			log.Fatalf("Could not connect to Postgres: %v", err)
+			fatal(logger, "could not connect to Postgres", err)
 		}
 		defer pgDB.Close()
+		if err := metrics.RegisterDB(pgDB, cfg.DB.Name); err != nil {
+			logger.Warn("could not register database metrics", "error", err)
+		}
+		// Refuse to run against a schema this binary was not built for,
+		// applying pending migrations first if asked to
+		if err := db.PrepareSchema(context.Background(), pgDB, cmd.Migrations(), migrateOnStart, logger); err != nil {
+			fatal(logger, "database schema is not ready", err)
+		}
 
 		// Init Repositories
-		customerRepo := repository.NewCustomerRepository(pgDB)
-		resourceRepo := repository.NewResourceRepository(pgDB)
+		timeouts := db.NewTimeouts(cfg.DB)
+		customerRepo := repository.NewCustomerRepository(pgDB, timeouts)
+		resourceRepo := repository.NewResourceRepository(pgDB, timeouts)
+		outboxRepo := repository.NewOutboxRepository(pgDB, timeouts)
+		roleRepo := repository.NewRoleRepository(pgDB, timeouts)
+		auditRepo := repository.NewAuditRepository(pgDB, timeouts)
+		inventoryRepo := repository.NewInventoryRepository(pgDB, timeouts)
 
 		// Init Usecases
-		customerUC := usecase.NewCustomerUsecase(customerRepo)
-		resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+		customerUC := usecase.NewCustomerUsecase(customerRepo, logger)
+		resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logger)
+		auditUC := usecase.NewAuditUsecase(auditRepo, logger)
+		inventoryUC := usecase.NewInventoryUsecase(inventoryRepo, logger)
 
 		// Initialize RabbitMQ (or any MQ) for notifications
-		log.Println("RabbitMQ URL: ", cfg.RabbitMQ.URL)
-		notifier, err := service.NewRabbitMQNotifier(cfg.RabbitMQ.URL)
+		mq, err := rabbitmq.Dial(cfg.RabbitMQ, logger)
 		if err != nil {
-			log.Fatalf("Could not connect to RabbitMQ: %v", err)
+			fatal(logger, "could not connect to RabbitMQ", err)
 		}
-		defer notifier.Close()
+		notifier := service.NewRabbitMQNotifier(mq, cfg.RabbitMQ, logger)
+
+		// Cancelled on SIGINT/SIGTERM to start the shutdown
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		// Relay notifications queued in the outbox to RabbitMQ
+		relay := service.NewOutboxRelay(outboxRepo, notifier, cfg.Outbox, logger)
+		relayDone := make(chan struct{})
+		go func() {
+			defer close(relayDone)
+			relay.Run(ctx)
+		}()
 
-		// // Start listening for notifications in a separate goroutine
-		// go func() {
-		//     if err := notifier.Listen(); err != nil {
-		//         log.Printf("[WARNING] Notification listener stopped: %v\n", err)
-		//     }
-		// }()
+		// Dependencies probed by /healthz and /readyz
+		checker := health.NewChecker()
+		checker.Add("postgres", pgDB.PingContext)
+		checker.Add("rabbitmq", mq.Check)
+
+		// Role checks against the stored role bindings
+		enforcer := auth.NewEnforcer(usecase.Policy, roleRepo)
 
 		// Setup Gin Router
-		router := rest.NewRouter(customerUC, resourceUC, notifier)
+		router := rest.NewRouter(customerUC, resourceUC, auditUC, inventoryUC, checker, authenticator, enforcer, logger)
 
 		// Start HTTP server
-		log.Printf("Main Server is running on port %s", cfg.Server.Port)
-		if err := router.Run(":" + cfg.Server.Port); err != nil {
-			log.Fatalf("Server error: %v", err)
+		srv := &http.Server{
+			Addr:    ":" + cfg.Server.Port,
+			Handler: router,
+		}
+		serverErr := make(chan error, 1)
+		go func() {
+			logger.Info("main server listening", "port", cfg.Server.Port)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+			logger.Info("shutdown signal received")
+		case err := <-serverErr:
+			logger.Error("server error", "error", err)
+			stop()
+		}
+
+		// Stop taking requests first, then stop the relay and let the
+		// publisher flush before the database goes away.
+		checker.Drain()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("HTTP server shutdown", "error", err)
+		}
+		select {
+		case <-relayDone:
+		case <-shutdownCtx.Done():
+			logger.Warn("outbox relay did not stop in time")
 		}
+		if err := notifier.Shutdown(shutdownCtx); err != nil {
+			logger.Error("RabbitMQ shutdown", "error", err)
+		}
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Error("tracing shutdown", "error", err)
+		}
+		logger.Info("main server stopped")
 	},
 }
 
+func init() {
+	serverCmd.Flags().BoolVar(&migrateOnStart, "migrate", false, "apply pending database migrations before starting")
+}
+
+// fatal logs err and exits; deferred cleanups do not run, as with log.Fatal.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func main() {
 	root := &cobra.Command{Use: "aqua-sec-cloud-inventory"}
 	root.AddCommand(serverCmd)
@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/cmd"
@@ -16,10 +26,23 @@ import (
 	grpc2 "github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/transport/grpc"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/transport/rest"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
 	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/health"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/metrics"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/rabbitmq"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
 	pb "github.com/iBoBoTi/aqua-sec-inventory/proto/notification"
 )
 
+// healthSyncInterval is how often the gRPC health status is refreshed from
+// the dependency checks.
+const healthSyncInterval = 5 * time.Second
+
+// migrateOnStart applies the built-in migrations at startup.
+var migrateOnStart bool
+
 var serverCmd = &cobra.Command{
 	Use:   "notification-server",
 	Short: "Start the Aqua Security Cloud Resource Inventory Notification Server",
@@ -27,66 +50,173 @@ var serverCmd = &cobra.Command{
 		// Load config
 		cfg := config.LoadConfig()
 
+		// Init logging
+		logger, err := logging.New(cfg.Logging, os.Stdout)
+		if err != nil {
+			log.Fatalf("Could not set up logging: %v", err)
+		}
+		// Route the standard logger, used by libraries, through slog too
+		slog.SetDefault(logger)
+
+		// Init tracing
+		shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing, "notification-service")
+		if err != nil {
+			fatal(logger, "could not set up tracing", err)
+		}
+
+		// Init authentication
+		authenticator, err := auth.NewAuthenticator(cfg.Auth)
+		if err != nil {
+			fatal(logger, "could not set up authentication", err)
+		}
+
 		// Init DB
 		pgDB, err := db.NewPostgresDB(cfg.DB)
 		if err != nil {
-			log.Fatalf("Could not connect to Postgres: %v", err)
+			fatal(logger, "could not connect to Postgres", err)
 		}
 		defer pgDB.Close()
+		if err := metrics.RegisterDB(pgDB, cfg.DB.Name); err != nil {
+			logger.Warn("could not register database metrics", "error", err)
+		}
+		// Refuse to run against a schema this binary was not built for,
+		// applying pending migrations first if asked to
+		if err := db.PrepareSchema(context.Background(), pgDB, cmd.Migrations(), migrateOnStart, logger); err != nil {
+			fatal(logger, "database schema is not ready", err)
+		}
 
 		// Init Repositories
-		notificationRepo := repository.NewNotificationRepository(pgDB)
+		notificationRepo := repository.NewNotificationRepository(pgDB, db.NewTimeouts(cfg.DB))
 
 		// Init Usecases
-		notificationUC := usecase.NewNotificationUsecase(notificationRepo)
+		notificationUC := usecase.NewNotificationUsecase(notificationRepo, logger)
 
 		// Initialize RabbitMQ for notifications
-		log.Println("RabbitMQ URL: ", cfg.RabbitMQ.URL)
-		notifier, err := service.NewRabbitMQNotifier(cfg.RabbitMQ.URL, notificationRepo)
+		mq, err := rabbitmq.Dial(cfg.RabbitMQ, logger)
 		if err != nil {
-			log.Fatalf("Could not connect to RabbitMQ: %v", err)
+			fatal(logger, "could not connect to RabbitMQ", err)
 		}
-		defer notifier.Close()
+		notifier := service.NewRabbitMQNotifier(mq, cfg.RabbitMQ, notificationRepo, logger)
+
+		// Cancelled on SIGINT/SIGTERM to start the shutdown
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
 
 		// Start listening for notifications in a separate goroutine
+		listenerDone := make(chan struct{})
 		go func() {
-			if err := notifier.Listen(); err != nil {
-				log.Printf("[WARNING] Notification listener stopped: %v\n", err)
+			defer close(listenerDone)
+			if err := notifier.Listen(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Warn("notification listener stopped", "error", err)
 			}
 		}()
 
-		// Setup Gin Router
-		router := rest.NewRouter(notificationUC, notifier)
+		// Dependencies probed by /healthz, /readyz and grpc.health.v1
+		checker := health.NewChecker()
+		checker.Add("postgres", pgDB.PingContext)
+		checker.Add("rabbitmq", mq.Check)
 
-		// Start Rest HTTP server in a goroutine
-		go func() {
-			log.Printf("Notification Server is running on port %s", cfg.Server.Port)
-			if err := router.Run(":" + cfg.Server.Port); err != nil {
-				log.Fatalf("Server error: %v", err)
-			}
-		}()
+		// Setup Gin Router
+		router := rest.NewRouter(notificationUC, notifier, checker, authenticator, logger)
+		srv := &http.Server{
+			Addr:    ":" + cfg.Server.Port,
+			Handler: router,
+		}
 
-		// Setup and start gRPC server
-		grpcServer := grpc.NewServer()
+		// Setup gRPC server
+		grpcServer := grpc.NewServer(
+			tracing.ServerOption(),
+			grpc.ChainUnaryInterceptor(
+				logging.UnaryServerInterceptor(logger),
+				metrics.UnaryServerInterceptor(),
+				auth.UnaryServerInterceptor(authenticator),
+			),
+		)
 		grpcNotificationService := grpc2.NewNotificationGRPCService(notificationUC)
 		pb.RegisterNotificationServiceServer(grpcServer, grpcNotificationService)
 
+		healthServer := grpchealth.NewServer()
+		healthpb.RegisterHealthServer(grpcServer, healthServer)
+		go health.SyncGRPC(ctx, checker, healthServer, healthSyncInterval, pb.NotificationService_ServiceDesc.ServiceName)
+
 		reflection.Register(grpcServer)
 
 		// Listen on gRPC port
 		grpcAddr := cfg.GRPCServer.Port
 		listener, err := net.Listen("tcp", ":"+grpcAddr)
 		if err != nil {
-			log.Fatalf("Failed to listen on gRPC port %s: %v", grpcAddr, err)
+			fatal(logger, "failed to listen on gRPC port "+grpcAddr, err)
 		}
 
-		log.Printf("Notification gRPC server is running on port %s", grpcAddr)
-		if err := grpcServer.Serve(listener); err != nil {
-			log.Fatalf("gRPC Server error: %v", err)
+		// Start both servers; either one failing shuts the service down
+		serverErr := make(chan error, 2)
+		go func() {
+			logger.Info("notification server listening", "port", cfg.Server.Port)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- fmt.Errorf("server error: %w", err)
+			}
+		}()
+		go func() {
+			logger.Info("notification gRPC server listening", "port", grpcAddr)
+			if err := grpcServer.Serve(listener); err != nil {
+				serverErr <- fmt.Errorf("gRPC server error: %w", err)
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+			logger.Info("shutdown signal received")
+		case err := <-serverErr:
+			logger.Error("server stopped", "error", err)
+			stop()
+		}
+
+		// Stop taking requests, let the consumer finish the message in hand,
+		// then flush pending publishes before the database goes away.
+		checker.Drain()
+		healthServer.Shutdown()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("HTTP server shutdown", "error", err)
 		}
+		grpcStopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+		select {
+		case <-grpcStopped:
+		case <-shutdownCtx.Done():
+			logger.Warn("gRPC server did not drain in time, forcing stop")
+			grpcServer.Stop()
+		}
+		select {
+		case <-listenerDone:
+		case <-shutdownCtx.Done():
+			logger.Warn("notification listener did not stop in time")
+		}
+		if err := notifier.Shutdown(shutdownCtx); err != nil {
+			logger.Error("RabbitMQ shutdown", "error", err)
+		}
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Error("tracing shutdown", "error", err)
+		}
+		logger.Info("notification server stopped")
 	},
 }
 
+func init() {
+	serverCmd.Flags().BoolVar(&migrateOnStart, "migrate", false, "apply pending database migrations before starting")
+}
+
+// fatal logs err and exits; deferred cleanups do not run, as with log.Fatal.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func main() {
 	root := &cobra.Command{Use: "aqua-sec-cloud-inventory-notification"}
 	root.AddCommand(serverCmd)
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+)
+
+var (
+	tokenSubject    string
+	tokenCustomerID int64
+	tokenTTL        time.Duration
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Issue a user JWT signed with AUTH_JWT_SECRET, for development and testing",
+	Run: func(cmd *cobra.Command, args []string) {
+		if tokenSubject == "" || tokenCustomerID <= 0 {
+			log.Fatalf("--sub and --customer-id are required")
+		}
+		cfg := config.LoadConfig()
+		token, err := auth.IssueToken(cfg.Auth, tokenSubject, tokenCustomerID, tokenTTL)
+		if err != nil {
+			log.Fatalf("Could not issue token: %v", err)
+		}
+		fmt.Println(token)
+	},
+}
+
+func init() {
+	tokenCmd.Flags().StringVar(&tokenSubject, "sub", "", "user the token is issued to")
+	tokenCmd.Flags().Int64Var(&tokenCustomerID, "customer-id", 0, "customer the user belongs to")
+	tokenCmd.Flags().DurationVar(&tokenTTL, "ttl", time.Hour, "token lifetime")
+	RootCmd.AddCommand(tokenCmd)
+}
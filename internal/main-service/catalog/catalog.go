@@ -0,0 +1,198 @@
+// Package catalog reads the seed files that describe the catalogue
+// resources, customers and assignments to load into the inventory.
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+// Supported file formats.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// ErrUnknownFormat is returned for a file format Parse does not support.
+var ErrUnknownFormat = errors.New("unknown catalog format")
+
+// file is the layout of a YAML or JSON seed file.
+type file struct {
+	Resources []resource `json:"resources" yaml:"resources"`
+	Customers []customer `json:"customers" yaml:"customers"`
+}
+
+type resource struct {
+	Name       string                 `json:"name" yaml:"name"`
+	Type       string                 `json:"type" yaml:"type"`
+	Region     string                 `json:"region" yaml:"region"`
+	Provider   string                 `json:"provider" yaml:"provider"`
+	AccountID  string                 `json:"account_id" yaml:"account_id"`
+	NativeID   string                 `json:"native_id" yaml:"native_id"`
+	Tags       map[string]string      `json:"tags" yaml:"tags"`
+	Attributes map[string]interface{} `json:"attributes" yaml:"attributes"`
+}
+
+type customer struct {
+	Name      string   `json:"name" yaml:"name"`
+	Email     string   `json:"email" yaml:"email"`
+	Resources []string `json:"resources" yaml:"resources"`
+}
+
+// FormatOf returns the format of a seed file from its extension.
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("%w for %s, expected a .yaml, .yml, .json or .csv file", ErrUnknownFormat, path)
+}
+
+// Load reads and validates the seed file at path.
+func Load(path string) (*domain.Catalog, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(format, f)
+}
+
+// Parse reads and validates a seed file in the given format.
+func Parse(format string, r io.Reader) (*domain.Catalog, error) {
+	var f file
+	switch format {
+	case FormatYAML:
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case FormatJSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&f); err != nil {
+			return nil, err
+		}
+	case FormatCSV:
+		var err error
+		if f, err = parseCSV(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	return f.catalog()
+}
+
+// catalog converts and validates the file. Resource names and customer
+// emails must be unique, and native IDs unique per provider.
+func (f file) catalog() (*domain.Catalog, error) {
+	c := &domain.Catalog{
+		Resources: make([]domain.Resource, 0, len(f.Resources)),
+		Customers: make([]domain.CatalogCustomer, 0, len(f.Customers)),
+	}
+
+	names := make(map[string]bool, len(f.Resources))
+	nativeIDs := make(map[string]bool)
+	for i, r := range f.Resources {
+		res := domain.Resource{
+			Name:      strings.TrimSpace(r.Name),
+			Type:      strings.TrimSpace(r.Type),
+			Region:    strings.TrimSpace(r.Region),
+			Provider:  strings.TrimSpace(r.Provider),
+			AccountID: strings.TrimSpace(r.AccountID),
+			NativeID:  strings.TrimSpace(r.NativeID),
+			Tags:      r.Tags,
+		}
+		if res.Name == "" || res.Type == "" || res.Region == "" {
+			return nil, fmt.Errorf("resource %d: name, type and region are required", i+1)
+		}
+		switch res.Provider {
+		case "", domain.ProviderAWS, domain.ProviderGCP, domain.ProviderAzure:
+		default:
+			return nil, fmt.Errorf("resource %s: unknown provider %q", res.Name, res.Provider)
+		}
+		if names[res.Name] {
+			return nil, fmt.Errorf("resource %s is listed twice", res.Name)
+		}
+		names[res.Name] = true
+		if res.NativeID != "" {
+			key := res.Provider + "/" + res.NativeID
+			if nativeIDs[key] {
+				return nil, fmt.Errorf("resource %s: native ID %s is listed twice", res.Name, res.NativeID)
+			}
+			nativeIDs[key] = true
+		}
+		if res.Tags == nil {
+			res.Tags = map[string]string{}
+		}
+		res.Attributes = json.RawMessage("{}")
+		if len(r.Attributes) > 0 {
+			b, err := json.Marshal(r.Attributes)
+			if err != nil {
+				return nil, fmt.Errorf("resource %s: attributes: %w", res.Name, err)
+			}
+			res.Attributes = b
+		}
+		c.Resources = append(c.Resources, res)
+	}
+
+	emails := make(map[string]bool, len(f.Customers))
+	for i, cu := range f.Customers {
+		cust := domain.CatalogCustomer{
+			Name:  strings.TrimSpace(cu.Name),
+			Email: strings.TrimSpace(cu.Email),
+		}
+		if cust.Name == "" || cust.Email == "" {
+			return nil, fmt.Errorf("customer %d: name and email are required", i+1)
+		}
+		if emails[cust.Email] {
+			return nil, fmt.Errorf("customer %s is listed twice", cust.Email)
+		}
+		emails[cust.Email] = true
+		for _, name := range cu.Resources {
+			if name = strings.TrimSpace(name); name != "" {
+				cust.Resources = append(cust.Resources, name)
+			}
+		}
+		c.Customers = append(c.Customers, cust)
+	}
+	return c, nil
+}
+
+// splitList splits a CSV cell holding a semicolon separated list.
+func splitList(cell string) []string {
+	var items []string
+	for _, item := range strings.Split(cell, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// trimBOM drops the byte order mark spreadsheet tools write at the start of
+// a CSV export.
+func trimBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+}
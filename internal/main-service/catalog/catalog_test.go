@@ -0,0 +1,137 @@
+package catalog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/catalog"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+// Every format describes the same catalog.
+var expected = &domain.Catalog{
+	Resources: []domain.Resource{
+		{Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1", Provider: "aws", AccountID: "123456789012",
+			Tags: map[string]string{"env": "prod", "team": "net"}},
+		{Name: "gcp_vm_instance", Type: "Compute", Region: "us-central1", Provider: "gcp", Tags: map[string]string{}},
+	},
+	Customers: []domain.CatalogCustomer{
+		{Name: "ebuka", Email: "ebuka@gmail.com", Resources: []string{"aws_vpc_main", "gcp_vm_instance"}},
+		{Name: "jane", Email: "jane@example.com"},
+	},
+}
+
+func TestParse(t *testing.T) {
+	for format, file := range map[string]string{
+		catalog.FormatYAML: `
+resources:
+  - name: aws_vpc_main
+    type: VPC
+    region: us-east-1
+    provider: aws
+    account_id: "123456789012"
+    tags: {env: prod, team: net}
+  - name: gcp_vm_instance
+    type: Compute
+    region: us-central1
+    provider: gcp
+customers:
+  - name: ebuka
+    email: ebuka@gmail.com
+    resources: [aws_vpc_main, gcp_vm_instance]
+  - name: jane
+    email: jane@example.com
+`,
+		catalog.FormatJSON: `{
+  "resources": [
+    {"name": "aws_vpc_main", "type": "VPC", "region": "us-east-1", "provider": "aws",
+     "account_id": "123456789012", "tags": {"env": "prod", "team": "net"}},
+    {"name": "gcp_vm_instance", "type": "Compute", "region": "us-central1", "provider": "gcp"}
+  ],
+  "customers": [
+    {"name": "ebuka", "email": "ebuka@gmail.com", "resources": ["aws_vpc_main", "gcp_vm_instance"]},
+    {"name": "jane", "email": "jane@example.com"}
+  ]
+}`,
+		catalog.FormatCSV: "\xef\xbb\xbf" + `kind,name,type,region,provider,account_id,tags,email,resources
+# the catalogue
+resource,aws_vpc_main,VPC,us-east-1,aws,123456789012,env=prod;team=net,,
+,gcp_vm_instance,Compute,us-central1,gcp,,,,
+customer,ebuka,,,,,,ebuka@gmail.com,aws_vpc_main; gcp_vm_instance
+customer,jane,,,,,,jane@example.com,
+`,
+	} {
+		c, err := catalog.Parse(format, strings.NewReader(file))
+		require.NoError(t, err, format)
+
+		require.Len(t, c.Resources, len(expected.Resources), format)
+		for i, res := range c.Resources {
+			want := expected.Resources[i]
+			assert.Equal(t, want.Name, res.Name, format)
+			assert.Equal(t, want.Type, res.Type, format)
+			assert.Equal(t, want.Region, res.Region, format)
+			assert.Equal(t, want.Provider, res.Provider, format)
+			assert.Equal(t, want.AccountID, res.AccountID, format)
+			assert.Equal(t, want.Tags, res.Tags, format)
+			assert.JSONEq(t, `{}`, string(res.Attributes), format)
+		}
+		assert.Equal(t, expected.Customers, c.Customers, format)
+	}
+}
+
+func TestParse_Attributes(t *testing.T) {
+	c, err := catalog.Parse(catalog.FormatYAML, strings.NewReader(`
+resources:
+  - name: aws_vpc_main
+    type: VPC
+    region: us-east-1
+    attributes:
+      cidr_block: 10.0.0.0/16
+      subnets: [a, b]
+`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"cidr_block": "10.0.0.0/16", "subnets": ["a", "b"]}`, string(c.Resources[0].Attributes))
+}
+
+func TestParse_Errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		format string
+		file   string
+	}{
+		"unknown format":        {"toml", ""},
+		"unknown yaml field":    {catalog.FormatYAML, "resources:\n  - name: a\n    kind: VPC\n"},
+		"unknown json field":    {catalog.FormatJSON, `{"resourcez": []}`},
+		"missing type":          {catalog.FormatYAML, "resources:\n  - name: a\n    region: eu\n"},
+		"unknown provider":      {catalog.FormatJSON, `{"resources": [{"name": "a", "type": "t", "region": "r", "provider": "ibm"}]}`},
+		"duplicate name":        {catalog.FormatCSV, "name,type,region\na,t,r\na,t,r\n"},
+		"duplicate native id":   {catalog.FormatCSV, "name,type,region,provider,native_id\na,t,r,aws,arn:1\nb,t,r,aws,arn:1\n"},
+		"duplicate email":       {catalog.FormatCSV, "kind,name,email\ncustomer,a,a@x.io\ncustomer,b,a@x.io\n"},
+		"customer without mail": {catalog.FormatJSON, `{"customers": [{"name": "a"}]}`},
+		"unknown csv column":    {catalog.FormatCSV, "name,colour\na,red\n"},
+		"csv without name":      {catalog.FormatCSV, "type,region\nt,r\n"},
+		"unknown csv kind":      {catalog.FormatCSV, "kind,name\nuser,a\n"},
+		"bad csv tag":           {catalog.FormatCSV, "name,type,region,tags\na,t,r,env\n"},
+	} {
+		_, err := catalog.Parse(tc.format, strings.NewReader(tc.file))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for path, format := range map[string]string{
+		"catalog.yaml":     catalog.FormatYAML,
+		"catalog.YML":      catalog.FormatYAML,
+		"fixtures/a.json":  catalog.FormatJSON,
+		"/tmp/catalog.csv": catalog.FormatCSV,
+	} {
+		got, err := catalog.FormatOf(path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, format, got, path)
+	}
+
+	_, err := catalog.FormatOf("catalog.txt")
+	assert.ErrorIs(t, err, catalog.ErrUnknownFormat)
+}
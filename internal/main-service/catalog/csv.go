@@ -0,0 +1,108 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// csvColumns are the columns a CSV seed file may have, in any order. Only
+// name is required. Tags are written as key=value pairs and a customer's
+// resources as names, both separated by semicolons.
+var csvColumns = []string{"kind", "name", "type", "region", "provider", "account_id", "native_id", "tags", "email", "resources"}
+
+// parseCSV reads a CSV seed file with a header row. The kind column tells
+// resource rows from customer rows; without it every row is a resource.
+// Lines starting with # are ignored.
+func parseCSV(r io.Reader) (file, error) {
+	var f file
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return f, err
+	}
+	cr := csv.NewReader(bytes.NewReader(trimBOM(data)))
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return f, nil
+		}
+		return f, err
+	}
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(csvColumns, column) {
+			return f, fmt.Errorf("unknown column %q, expected some of %s", column, strings.Join(csvColumns, ", "))
+		}
+		index[column] = i
+	}
+	if _, ok := index["name"]; !ok {
+		return f, errors.New("the name column is required")
+	}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return f, nil
+		}
+		if err != nil {
+			return f, err
+		}
+		cell := func(column string) string {
+			if i, ok := index[column]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		line, _ := cr.FieldPos(0)
+		switch kind := strings.ToLower(cell("kind")); kind {
+		case "", "resource":
+			tags, err := parseTags(cell("tags"))
+			if err != nil {
+				return f, fmt.Errorf("line %d: %w", line, err)
+			}
+			f.Resources = append(f.Resources, resource{
+				Name:      cell("name"),
+				Type:      cell("type"),
+				Region:    cell("region"),
+				Provider:  cell("provider"),
+				AccountID: cell("account_id"),
+				NativeID:  cell("native_id"),
+				Tags:      tags,
+			})
+		case "customer":
+			f.Customers = append(f.Customers, customer{
+				Name:      cell("name"),
+				Email:     cell("email"),
+				Resources: splitList(cell("resources")),
+			})
+		default:
+			return f, fmt.Errorf("line %d: unknown kind %q, expected resource or customer", line, kind)
+		}
+	}
+}
+
+// parseTags reads key=value pairs separated by semicolons.
+func parseTags(cell string) (map[string]string, error) {
+	items := splitList(cell)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	tags := make(map[string]string, len(items))
+	for _, item := range items {
+		key, value, ok := strings.Cut(item, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q, expected key=value", item)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return tags, nil
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actions. Delete is a soft delete that Restore undoes; Purge removes
+// a deleted record for good.
+const (
+	AuditActionCreate   = "create"
+	AuditActionUpdate   = "update"
+	AuditActionDelete   = "delete"
+	AuditActionRestore  = "restore"
+	AuditActionPurge    = "purge"
+	AuditActionAssign   = "assign"
+	AuditActionUnassign = "unassign"
+)
+
+// Audited entity types. Assignment events use the resource ID as entity ID
+// and carry the customer in CustomerID.
+const (
+	AuditEntityCustomer   = "customer"
+	AuditEntityResource   = "resource"
+	AuditEntityAssignment = "assignment"
+)
+
+// AuditActorSystem is recorded for changes made outside a request, such as by
+// the CLI.
+const AuditActorSystem = "system"
+
+// AuditEvent is one row of the append-only audit_events table. Before is null
+// for creations and After for deletions.
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	ActorKind  string          `json:"actor_kind"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int64           `json:"entity_id"`
+	CustomerID int64           `json:"customer_id,omitempty"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"request_id,omitempty"`
+}
+
+// Assignment is the audited state of a customer_resource link.
+type Assignment struct {
+	CustomerID   int64  `json:"customer_id"`
+	ResourceID   int64  `json:"resource_id"`
+	ResourceName string `json:"resource_name"`
+}
+
+// AuditQuery is the caller-facing audit listing request. Since and Until
+// bound OccurredAt; After is the cursor returned by a previous page.
+type AuditQuery struct {
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   int64
+	CustomerID int64
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+	After      string
+}
+
+// AuditFilter is the validated form of AuditQuery handed to the repository.
+// Events are returned newest first, starting below BeforeID when it is set.
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   int64
+	CustomerID int64
+	Since      time.Time
+	Until      time.Time
+	BeforeID   int64
+	Limit      int
+}
+
+// AuditPage is one page of audit events. NextCursor is empty on the last
+// page.
+type AuditPage struct {
+	Events     []AuditEvent
+	NextCursor string
+}
@@ -0,0 +1,36 @@
+package domain
+
+// Catalog is the content of a seed file: the catalogue resources, and the
+// customers to create with the names of the resources assigned to them.
+type Catalog struct {
+	Resources []Resource
+	Customers []CatalogCustomer
+}
+
+// CatalogCustomer is a customer in a seed file. Customers are matched on
+// Email.
+type CatalogCustomer struct {
+	Name      string
+	Email     string
+	Resources []string
+}
+
+// SeedOptions controls how a catalog is applied. DryRun applies it in a
+// transaction that is rolled back, and Prune deletes the catalogue resources
+// the catalog does not list.
+type SeedOptions struct {
+	DryRun bool
+	Prune  bool
+}
+
+// SeedSummary counts what applying a catalog did, or would do on a dry run.
+type SeedSummary struct {
+	ResourcesCreated   int
+	ResourcesUpdated   int
+	ResourcesUnchanged int
+	ResourcesPruned    []string
+	CustomersCreated   int
+	CustomersUpdated   int
+	CustomersUnchanged int
+	Assigned           int
+}
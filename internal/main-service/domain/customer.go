@@ -5,7 +5,31 @@ import "time"
 type Customer struct {
 	ID        int64     `json:"id" db:"id"`
 	Name      string    `json:"name" db:"name"`
-	Email     string    `email:"email" db:"email"`
+	Email     string    `json:"email" db:"email"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// CustomerFilter narrows a customer listing. Name and Email match as
+// case-insensitive substrings; empty values are ignored.
+type CustomerFilter struct {
+	Name   string
+	Email  string
+	Limit  int
+	Offset int
+}
+
+// CustomerPage is one page of a customer listing.
+type CustomerPage struct {
+	Customers []Customer
+	Total     int64
+	Limit     int
+	Offset    int
+}
+
+// CustomerUpdate carries the fields to change on a customer. Nil fields are
+// left untouched, which lets PATCH and PUT share one code path.
+type CustomerUpdate struct {
+	Name  *string
+	Email *string
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// InventoryRow is one line of an inventory export: a customer and one of its
+// resources. A customer without resources has a single row with a nil
+// Resource.
+type InventoryRow struct {
+	Customer   Customer   `json:"customer"`
+	Resource   *Resource  `json:"resource"`
+	AssignedAt *time.Time `json:"assigned_at"`
+}
+
+// InventoryQuery is the caller-facing export request. The resource filters
+// drop customers without a matching resource.
+type InventoryQuery struct {
+	CustomerID int64
+	Provider   string
+	AccountID  string
+	Type       string
+	Region     string
+	Tags       map[string]string
+}
+
+// InventoryFilter is the validated form of InventoryQuery handed to the
+// repository. Rows are returned by customer and then resource ID, starting
+// after the AfterCustomerID and AfterResourceID pair; a customer without
+// resources sorts with resource ID 0.
+type InventoryFilter struct {
+	CustomerID      int64
+	Provider        string
+	AccountID       string
+	Type            string
+	Region          string
+	Tags            map[string]string
+	AfterCustomerID int64
+	AfterResourceID int64
+	Limit           int
+}
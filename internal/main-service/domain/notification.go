@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Notification struct {
 	Event     string    `json:"event" db:"event"`
@@ -9,3 +13,49 @@ type Notification struct {
 	Message   string    `json:"message" db:"message"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
+
+// OutboxMessage is a notification waiting in the outbox table to be relayed
+// to the message broker.
+type OutboxMessage struct {
+	ID           int64
+	Notification Notification
+	Attempts     int
+	CreatedAt    time.Time
+	// TraceContext holds the propagation headers of the request that queued
+	// the message, so the relay can continue its trace.
+	TraceContext map[string]string
+	// RequestID is the ID of the request that queued the message.
+	RequestID string
+}
+
+func ResourceAddedNotification(customerID int64, resourceName string) Notification {
+	return Notification{
+		Event:   "notification",
+		UserID:  customerID,
+		Message: fmt.Sprintf("added resource %s for customer with customerID %d", resourceName, customerID),
+	}
+}
+
+func ResourcesAddedNotification(customerID int64, resourceNames []string) Notification {
+	return Notification{
+		Event:   "notification",
+		UserID:  customerID,
+		Message: fmt.Sprintf("added resources %s for customer with customerID %d", strings.Join(resourceNames, ", "), customerID),
+	}
+}
+
+func ResourceRemovedNotification(customerID int64, resourceName string) Notification {
+	return Notification{
+		Event:   "notification",
+		UserID:  customerID,
+		Message: fmt.Sprintf("removed resource %s for customer with customerID %d", resourceName, customerID),
+	}
+}
+
+func CustomerDeletedNotification(customerID int64) Notification {
+	return Notification{
+		Event:   "notification",
+		UserID:  customerID,
+		Message: fmt.Sprintf("deleted customer with customerID %d", customerID),
+	}
+}
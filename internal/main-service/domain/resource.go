@@ -1,12 +1,161 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
+// Cloud providers a resource can belong to.
+const (
+	ProviderAWS   = "aws"
+	ProviderGCP   = "gcp"
+	ProviderAzure = "azure"
+)
+
+// Longest values the resources table accepts, in characters.
+const (
+	MaxResourceNameLength      = 255
+	MaxResourceTypeLength      = 100
+	MaxResourceRegionLength    = 100
+	MaxResourceAccountIDLength = 255
+)
+
+// Resource is one cloud resource in the catalogue. AccountID is the AWS
+// account, Azure subscription or GCP project, and NativeID the ARN or
+// provider resource ID. Attributes is a free-form JSON object.
 type Resource struct {
-	ID        int64     `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Type      string    `json:"type" db:"type"`
-	Region    string    `json:"region" db:"region"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID         int64             `json:"id" db:"id"`
+	Name       string            `json:"name" db:"name"`
+	Type       string            `json:"type" db:"type"`
+	Region     string            `json:"region" db:"region"`
+	Provider   string            `json:"provider" db:"provider"`
+	AccountID  string            `json:"account_id" db:"account_id"`
+	NativeID   string            `json:"native_id" db:"native_id"`
+	Tags       map[string]string `json:"tags" db:"tags"`
+	Attributes json.RawMessage   `json:"attributes" db:"attributes"`
+	CreatedAt  time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// ResourceUpdate carries the fields to change on a resource. Nil fields are
+// left untouched.
+type ResourceUpdate struct {
+	Name       *string
+	Type       *string
+	Region     *string
+	Provider   *string
+	AccountID  *string
+	NativeID   *string
+	Tags       map[string]string
+	Attributes json.RawMessage
+}
+
+// ResourceVersion is the state of a resource over [ValidFrom, ValidTo). The
+// current version has a nil ValidTo.
+type ResourceVersion struct {
+	ResourceID int64             `json:"resource_id"`
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Region     string            `json:"region"`
+	Provider   string            `json:"provider"`
+	AccountID  string            `json:"account_id"`
+	NativeID   string            `json:"native_id"`
+	Tags       map[string]string `json:"tags"`
+	Attributes json.RawMessage   `json:"attributes"`
+	ValidFrom  time.Time         `json:"valid_from"`
+	ValidTo    *time.Time        `json:"valid_to"`
+}
+
+// Resource listing sort keys. Prefix with "-" for descending order.
+const (
+	ResourceSortID        = "id"
+	ResourceSortName      = "name"
+	ResourceSortCreatedAt = "created_at"
+)
+
+// ResourceQuery is the caller-facing listing request: filters, sort order
+// and an opaque cursor returned by a previous page. A non-zero AsOf lists the
+// inventory as it was at that moment. Tags and Attributes match when the
+// resource has every given key with the given value.
+type ResourceQuery struct {
+	Type       string
+	Region     string
+	NamePrefix string
+	Provider   string
+	AccountID  string
+	NativeID   string
+	Tags       map[string]string
+	Attributes map[string]string
+	Sort       string
+	Limit      int
+	After      string
+	AsOf       time.Time
+}
+
+// ResourceFilter is the decoded form of ResourceQuery handed to the
+// repository. After is nil for the first page.
+type ResourceFilter struct {
+	Type       string
+	Region     string
+	NamePrefix string
+	Provider   string
+	AccountID  string
+	NativeID   string
+	Tags       map[string]string
+	Attributes map[string]string
+	SortKey    string
+	Descending bool
+	Limit      int
+	After      *ResourceCursor
+	AsOf       time.Time
+}
+
+// ResourceCursor marks the last row of a page by its sort key value and id,
+// which together are unique and give a stable keyset position.
+type ResourceCursor struct {
+	Sort string    `json:"s"`
+	Name string    `json:"n,omitempty"`
+	Time time.Time `json:"t,omitempty"`
+	ID   int64     `json:"id"`
+}
+
+// ResourcePage is one page of a resource listing. NextCursor is empty on the
+// last page.
+type ResourcePage struct {
+	Resources  []Resource
+	NextCursor string
+}
+
+// Outcomes of a single name in a batch assignment.
+const (
+	AssignmentAssigned     = "assigned"
+	AssignmentAlreadyOwned = "already_owned"
+	AssignmentUnknown      = "unknown"
+)
+
+// ResourceAssignment reports what a batch assignment did with one resource
+// name.
+type ResourceAssignment struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// ImportSummary counts what an import did with the resources it read.
+// Resources deleted from the catalogue are skipped rather than restored.
+type ImportSummary struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+// NameFromNativeID derives a resource name of at most max characters from a
+// native ID. Longer IDs keep their end, where providers put the most
+// specific part.
+func NameFromNativeID(nativeID string, max int) string {
+	runes := []rune(nativeID)
+	if len(runes) <= max {
+		return nativeID
+	}
+	return string(runes[len(runes)-max:])
 }
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// RoleBinding grants a role to a service or user, identified by the service
+// name of its API key or the sub claim of its token.
+type RoleBinding struct {
+	PrincipalKind string    `json:"principal_kind"`
+	Subject       string    `json:"subject"`
+	Role          string    `json:"role"`
+	CreatedAt     time.Time `json:"created_at"`
+}
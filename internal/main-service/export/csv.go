@@ -0,0 +1,60 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) Write(row domain.InventoryRow) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	cells := record(row)
+	for i := range cells {
+		if !numericColumns[i] {
+			cells[i] = escapeFormula(cells[i])
+		}
+	}
+	if err := c.w.Write(cells); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula prefixes cells that a spreadsheet would evaluate as a
+// formula with a quote, since names and tags come from API callers.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,108 @@
+// Package export writes inventory rows as CSV, JSON Lines or XLSX, one row at
+// a time, so that exports of any size can be streamed.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+// Supported export formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatXLSX  = "xlsx"
+)
+
+// Formats lists the supported formats, for help texts and errors.
+var Formats = []string{FormatCSV, FormatJSONL, FormatXLSX}
+
+// ErrUnknownFormat is returned by NewWriter for a format it does not support.
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Writer writes inventory rows. Close must be called once all rows are
+// written; it completes the file but does not close the underlying writer.
+type Writer interface {
+	Write(row domain.InventoryRow) error
+	Close() error
+}
+
+// NewWriter returns a Writer for format writing to w.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w), nil
+	}
+	return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownFormat, format, strings.Join(Formats, ", "))
+}
+
+// ContentType returns the MIME type of a format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// columns are the columns of the tabular formats. Attributes are left out;
+// JSON Lines carries them.
+var columns = []string{
+	"customer_id", "customer_name", "customer_email",
+	"resource_id", "resource_name", "resource_type", "region", "provider", "account_id", "native_id", "tags",
+	"assigned_at",
+}
+
+// numericColumns are the indexes of the columns holding numbers.
+var numericColumns = map[int]bool{0: true, 3: true}
+
+// record flattens a row into the cells of columns. Tags are written as
+// sorted key=value pairs separated by semicolons, the form seed files use.
+func record(row domain.InventoryRow) []string {
+	cells := make([]string, len(columns))
+	cells[0] = strconv.FormatInt(row.Customer.ID, 10)
+	cells[1] = row.Customer.Name
+	cells[2] = row.Customer.Email
+	if res := row.Resource; res != nil {
+		cells[3] = strconv.FormatInt(res.ID, 10)
+		cells[4] = res.Name
+		cells[5] = res.Type
+		cells[6] = res.Region
+		cells[7] = res.Provider
+		cells[8] = res.AccountID
+		cells[9] = res.NativeID
+		cells[10] = formatTags(res.Tags)
+	}
+	if row.AssignedAt != nil {
+		cells[11] = row.AssignedAt.UTC().Format(time.RFC3339)
+	}
+	return cells
+}
+
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+tags[k])
+	}
+	return strings.Join(pairs, ";")
+}
@@ -0,0 +1,127 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/export"
+)
+
+var assignedAt = time.Date(2024, 5, 7, 9, 30, 0, 0, time.UTC)
+
+var rows = []domain.InventoryRow{
+	{
+		Customer: domain.Customer{ID: 1, Name: "ebuka", Email: "ebuka@gmail.com"},
+		Resource: &domain.Resource{ID: 7, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1", Provider: "aws",
+			AccountID: "123456789012", Tags: map[string]string{"team": "net", "env": "prod"}, Attributes: json.RawMessage(`{}`)},
+		AssignedAt: &assignedAt,
+	},
+	{Customer: domain.Customer{ID: 2, Name: "=HYPERLINK(\"x\") & <co>", Email: "jane@example.com"}},
+}
+
+func write(t *testing.T, format string, rows []domain.InventoryRow) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := export.NewWriter(format, &buf)
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestCSV(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(write(t, export.FormatCSV, rows))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, "customer_id", records[0][0])
+	assert.Equal(t, []string{"1", "ebuka", "ebuka@gmail.com", "7", "aws_vpc_main", "VPC", "us-east-1", "aws",
+		"123456789012", "", "env=prod;team=net", "2024-05-07T09:30:00Z"}, records[1])
+	// Formulas are defused and the missing resource left blank
+	assert.Equal(t, `'=HYPERLINK("x") & <co>`, records[2][1])
+	assert.Equal(t, "", records[2][3])
+}
+
+func TestCSV_HeaderOnly(t *testing.T) {
+	assert.Equal(t, "customer_id,customer_name,customer_email,resource_id,resource_name,resource_type,region,provider,account_id,native_id,tags,assigned_at\n",
+		string(write(t, export.FormatCSV, nil)))
+}
+
+func TestJSONL(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(write(t, export.FormatJSONL, rows))), "\n")
+	require.Len(t, lines, 2)
+
+	var row domain.InventoryRow
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+	assert.Equal(t, "ebuka", row.Customer.Name)
+	require.NotNil(t, row.Resource)
+	assert.Equal(t, "aws_vpc_main", row.Resource.Name)
+	assert.True(t, assignedAt.Equal(*row.AssignedAt))
+
+	assert.Contains(t, lines[1], `"resource":null`)
+}
+
+func TestXLSX(t *testing.T) {
+	data := write(t, export.FormatXLSX, rows)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		parts[f.Name] = string(b)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		require.Contains(t, parts, name)
+	}
+
+	// Read the cells back from the sheet
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet))
+	require.Len(t, sheet.Rows, 3)
+
+	assert.Equal(t, "A1", sheet.Rows[0].Cells[0].Ref)
+	assert.Equal(t, "customer_id", sheet.Rows[0].Cells[0].Inline)
+
+	first := sheet.Rows[1].Cells
+	assert.Equal(t, "1", first[0].Value)
+	assert.Equal(t, "", first[0].Type)
+	assert.Equal(t, "ebuka", first[1].Inline)
+	assert.Equal(t, "L2", first[len(first)-1].Ref)
+	assert.Equal(t, "2024-05-07T09:30:00Z", first[len(first)-1].Inline)
+
+	// Inline strings are never evaluated, so the name is kept as is
+	assert.Equal(t, `=HYPERLINK("x") & <co>`, sheet.Rows[2].Cells[1].Inline)
+	assert.Len(t, sheet.Rows[2].Cells, 3)
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := export.NewWriter("pdf", io.Discard)
+	assert.ErrorIs(t, err, export.ErrUnknownFormat)
+}
@@ -0,0 +1,24 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	return &jsonlWriter{enc: json.NewEncoder(w)}
+}
+
+func (j *jsonlWriter) Write(row domain.InventoryRow) error {
+	return j.enc.Encode(&row)
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+// The fixed parts of a workbook with a single sheet. Strings are written
+// inline in the sheet rather than to a shared strings table, which would
+// have to be held in memory until the end.
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Inventory" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	// Style 1 is the bold header
+	{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// The sheet around its rows, with the header row frozen.
+const (
+	xlsxSheetStart = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+type xlsxWriter struct {
+	zw      *zip.Writer
+	sheet   *bufio.Writer
+	started bool
+	rows    int
+}
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	return &xlsxWriter{zw: zip.NewWriter(w)}
+}
+
+// start writes the fixed parts and the header row, leaving the sheet open
+// for the data rows.
+func (x *xlsxWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+
+	for _, part := range xlsxParts {
+		f, err := x.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+	f, err := x.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.sheet = bufio.NewWriter(f)
+	if _, err := x.sheet.WriteString(xlsxSheetStart); err != nil {
+		return err
+	}
+	return x.writeRow(columns, true)
+}
+
+func (x *xlsxWriter) Write(row domain.InventoryRow) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if err := x.writeRow(record(row), false); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Flush()
+}
+
+func (x *xlsxWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+// writeRow writes one row of cells. Empty cells are left out, numeric
+// columns are written as numbers and everything else as inline strings.
+func (x *xlsxWriter) writeRow(cells []string, header bool) error {
+	x.rows++
+	n := strconv.Itoa(x.rows)
+	w := x.sheet
+	w.WriteString(`<row r="` + n + `">`)
+	for i, cell := range cells {
+		if cell == "" {
+			continue
+		}
+		ref := columnName(i) + n
+		switch {
+		case header:
+			w.WriteString(`<c r="` + ref + `" s="1" t="inlineStr"><is><t>`)
+			xml.EscapeText(w, []byte(cell))
+			w.WriteString(`</t></is></c>`)
+		case numericColumns[i]:
+			w.WriteString(`<c r="` + ref + `"><v>` + cell + `</v></c>`)
+		default:
+			w.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			xml.EscapeText(w, []byte(cell))
+			w.WriteString(`</t></is></c>`)
+		}
+	}
+	// A bufio.Writer keeps its first error, so checking once is enough
+	_, err := w.WriteString(`</row>`)
+	return err
+}
+
+// columnName returns the spreadsheet name of the zero based column i, such
+// as A, Z or AA.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+// awsConfigSnapshot is the part of an AWS Config snapshot file that we read.
+type awsConfigSnapshot struct {
+	ConfigurationItems []awsConfigItem `json:"configurationItems"`
+}
+
+type awsConfigItem struct {
+	Status        string            `json:"configurationItemStatus"`
+	ResourceType  string            `json:"resourceType"`
+	ResourceID    string            `json:"resourceId"`
+	ResourceName  string            `json:"resourceName"`
+	ARN           string            `json:"ARN"`
+	AWSRegion     string            `json:"awsRegion"`
+	AWSAccountID  string            `json:"awsAccountId"`
+	Tags          map[string]string `json:"tags"`
+	Configuration json.RawMessage   `json:"configuration"`
+}
+
+// parseAWSConfig reads an AWS Config snapshot, as delivered to S3 or returned
+// by get-resource-config-history. Items for deleted or unrecorded resources
+// are skipped.
+func parseAWSConfig(data []byte) ([]domain.Resource, error) {
+	var snapshot awsConfigSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	if snapshot.ConfigurationItems == nil {
+		return nil, errors.New("no configurationItems found")
+	}
+
+	resources := make([]domain.Resource, 0, len(snapshot.ConfigurationItems))
+	for i, item := range snapshot.ConfigurationItems {
+		switch item.Status {
+		case "ResourceDeleted", "ResourceDeletedNotRecorded", "ResourceNotRecorded":
+			continue
+		}
+
+		nativeID := item.ARN
+		if nativeID == "" {
+			nativeID = item.ResourceID
+		}
+		name := item.ResourceName
+		if name == "" {
+			name = item.ResourceID
+		}
+		res, err := normalize(i, domain.Resource{
+			Name:       name,
+			Type:       item.ResourceType,
+			Region:     item.AWSRegion,
+			Provider:   domain.ProviderAWS,
+			AccountID:  item.AWSAccountID,
+			NativeID:   nativeID,
+			Tags:       item.Tags,
+			Attributes: item.Configuration,
+		})
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, res)
+	}
+	return resources, nil
+}
@@ -0,0 +1,60 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+// azureGraphResult is the envelope az graph query prints. Older CLI versions
+// print the bare array of rows instead.
+type azureGraphResult struct {
+	Data []azureResource `json:"data"`
+}
+
+type azureResource struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Type           string            `json:"type"`
+	Location       string            `json:"location"`
+	SubscriptionID string            `json:"subscriptionId"`
+	Tags           map[string]string `json:"tags"`
+	Properties     json.RawMessage   `json:"properties"`
+}
+
+// parseAzureGraph reads the JSON output of an Azure Resource Graph query over
+// the Resources table.
+func parseAzureGraph(data []byte) ([]domain.Resource, error) {
+	var rows []azureResource
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &rows); err != nil {
+			return nil, err
+		}
+	} else {
+		var result azureGraphResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+		rows = result.Data
+	}
+
+	resources := make([]domain.Resource, 0, len(rows))
+	for i, row := range rows {
+		res, err := normalize(i, domain.Resource{
+			Name:       row.Name,
+			Type:       row.Type,
+			Region:     row.Location,
+			Provider:   domain.ProviderAzure,
+			AccountID:  row.SubscriptionID,
+			NativeID:   row.ID,
+			Tags:       row.Tags,
+			Attributes: row.Properties,
+		})
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, res)
+	}
+	return resources, nil
+}
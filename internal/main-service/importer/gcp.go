@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+// gcpAsset is one Cloud Asset Inventory asset with resource content. Exports
+// to Cloud Storage use snake_case keys and gcloud asset list camelCase, so
+// the asset type is read under both.
+type gcpAsset struct {
+	Name           string           `json:"name"`
+	AssetType      string           `json:"asset_type"`
+	AssetTypeCamel string           `json:"assetType"`
+	Resource       gcpAssetResource `json:"resource"`
+	Ancestors      []string         `json:"ancestors"`
+}
+
+type gcpAssetResource struct {
+	Location string          `json:"location"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// gcpAssetData is the part of the resource data that maps onto catalogue
+// fields; the whole object is kept as attributes.
+type gcpAssetData struct {
+	Name   interface{}       `json:"name"`
+	Labels map[string]string `json:"labels"`
+}
+
+// parseGCPAssets reads a Cloud Asset Inventory export with the resource
+// content type, either as newline-delimited JSON or as a JSON array.
+func parseGCPAssets(data []byte) ([]domain.Resource, error) {
+	assets, err := decodeItems[gcpAsset](data)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]domain.Resource, 0, len(assets))
+	for i, asset := range assets {
+		assetType := asset.AssetType
+		if assetType == "" {
+			assetType = asset.AssetTypeCamel
+		}
+
+		var content gcpAssetData
+		if len(asset.Resource.Data) > 0 {
+			// Data that is not an object just leaves the fields empty
+			_ = json.Unmarshal(asset.Resource.Data, &content)
+		}
+		name, _ := content.Name.(string)
+		if name == "" {
+			name = lastSegment(asset.Name)
+		}
+
+		res, err := normalize(i, domain.Resource{
+			Name:       name,
+			Type:       assetType,
+			Region:     asset.Resource.Location,
+			Provider:   domain.ProviderGCP,
+			AccountID:  gcpProject(asset),
+			NativeID:   asset.Name,
+			Tags:       content.Labels,
+			Attributes: asset.Resource.Data,
+		})
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, res)
+	}
+	return resources, nil
+}
+
+// gcpProject returns the project from the asset name, such as my-project in
+// //compute.googleapis.com/projects/my-project/zones/..., falling back to the
+// project number among the ancestors.
+func gcpProject(asset gcpAsset) string {
+	if _, rest, ok := strings.Cut(asset.Name, "/projects/"); ok {
+		project, _, _ := strings.Cut(rest, "/")
+		return project
+	}
+	for _, ancestor := range asset.Ancestors {
+		if project, ok := strings.CutPrefix(ancestor, "projects/"); ok {
+			return project
+		}
+	}
+	return ""
+}
+
+func lastSegment(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
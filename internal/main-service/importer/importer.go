@@ -0,0 +1,130 @@
+// Package importer reads provider-native inventory exports and normalizes
+// them into catalogue resources.
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+// Supported export formats.
+const (
+	FormatAWSConfig  = "aws-config"
+	FormatGCPAsset   = "gcp-asset"
+	FormatAzureGraph = "azure-graph"
+)
+
+// globalRegion is recorded for resources that are not tied to a region.
+const globalRegion = "global"
+
+// Formats lists the supported formats, for help texts and errors.
+var Formats = []string{FormatAWSConfig, FormatGCPAsset, FormatAzureGraph}
+
+// ErrUnknownFormat is returned by Parse for a format it does not support.
+var ErrUnknownFormat = errors.New("unknown import format")
+
+// Parse reads one export in the given format. Every returned resource has a
+// provider, a native ID, a name, a type and a region; tags and attributes
+// are never nil.
+func Parse(format string, r io.Reader) ([]domain.Resource, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []domain.Resource
+	switch format {
+	case FormatAWSConfig:
+		resources, err = parseAWSConfig(data)
+	case FormatGCPAsset:
+		resources, err = parseGCPAssets(data)
+	case FormatAzureGraph:
+		resources, err = parseAzureGraph(data)
+	default:
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownFormat, format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s export: %w", format, err)
+	}
+	return resources, nil
+}
+
+// normalize fills the defaults shared by every format and checks the fields
+// the catalogue requires. i is the item's position in the export, for error
+// messages.
+func normalize(i int, res domain.Resource) (domain.Resource, error) {
+	res.NativeID = strings.TrimSpace(res.NativeID)
+	if res.NativeID == "" {
+		return res, fmt.Errorf("item %d has no resource ID", i)
+	}
+	if strings.TrimSpace(res.Type) == "" {
+		return res, fmt.Errorf("item %d (%s) has no resource type", i, res.NativeID)
+	}
+	if strings.TrimSpace(res.Name) == "" {
+		res.Name = domain.NameFromNativeID(res.NativeID, domain.MaxResourceNameLength)
+	}
+	if strings.TrimSpace(res.Region) == "" {
+		res.Region = globalRegion
+	}
+	for _, f := range []struct {
+		field, value string
+		max          int
+	}{
+		{"name", res.Name, domain.MaxResourceNameLength},
+		{"type", res.Type, domain.MaxResourceTypeLength},
+		{"region", res.Region, domain.MaxResourceRegionLength},
+		{"account ID", res.AccountID, domain.MaxResourceAccountIDLength},
+	} {
+		if utf8.RuneCountInString(f.value) > f.max {
+			return res, fmt.Errorf("item %d (%s) has a %s longer than %d characters", i, res.NativeID, f.field, f.max)
+		}
+	}
+	if res.Tags == nil {
+		res.Tags = map[string]string{}
+	}
+	res.Attributes = jsonObject(res.Attributes)
+	return res, nil
+}
+
+// jsonObject returns raw if it holds a JSON object and an empty object
+// otherwise, since the catalogue only stores objects as attributes.
+func jsonObject(raw json.RawMessage) json.RawMessage {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '{' || !json.Valid(raw) {
+		return json.RawMessage("{}")
+	}
+	return raw
+}
+
+// decodeItems decodes either a JSON array or newline-delimited JSON objects
+// into a slice of T.
+func decodeItems[T any](data []byte) ([]T, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var items []T
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+
+	var items []T
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			if errors.Is(err, io.EOF) {
+				return items, nil
+			}
+			return nil, fmt.Errorf("line %d: %w", len(items)+1, err)
+		}
+		items = append(items, item)
+	}
+}
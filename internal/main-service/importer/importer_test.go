@@ -0,0 +1,156 @@
+package importer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/importer"
+)
+
+func TestParseAWSConfig(t *testing.T) {
+	export := `{
+  "fileVersion": "1.0",
+  "configurationItems": [
+    {
+      "configurationItemStatus": "OK",
+      "resourceType": "AWS::EC2::VPC",
+      "resourceId": "vpc-0abc",
+      "ARN": "arn:aws:ec2:us-east-1:123456789012:vpc/vpc-0abc",
+      "awsRegion": "us-east-1",
+      "awsAccountId": "123456789012",
+      "tags": {"env": "prod"},
+      "configuration": {"cidrBlock": "10.0.0.0/16"}
+    },
+    {
+      "configurationItemStatus": "ResourceDiscovered",
+      "resourceType": "AWS::IAM::Role",
+      "resourceId": "AROAEXAMPLE",
+      "resourceName": "deployer",
+      "awsRegion": "",
+      "awsAccountId": "123456789012"
+    },
+    {
+      "configurationItemStatus": "ResourceDeleted",
+      "resourceType": "AWS::S3::Bucket",
+      "resourceId": "old-bucket"
+    }
+  ]
+}`
+
+	resources, err := importer.Parse(importer.FormatAWSConfig, strings.NewReader(export))
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+
+	vpc := resources[0]
+	assert.Equal(t, domain.ProviderAWS, vpc.Provider)
+	assert.Equal(t, "vpc-0abc", vpc.Name)
+	assert.Equal(t, "AWS::EC2::VPC", vpc.Type)
+	assert.Equal(t, "us-east-1", vpc.Region)
+	assert.Equal(t, "123456789012", vpc.AccountID)
+	assert.Equal(t, "arn:aws:ec2:us-east-1:123456789012:vpc/vpc-0abc", vpc.NativeID)
+	assert.Equal(t, map[string]string{"env": "prod"}, vpc.Tags)
+	assert.JSONEq(t, `{"cidrBlock": "10.0.0.0/16"}`, string(vpc.Attributes))
+
+	role := resources[1]
+	assert.Equal(t, "deployer", role.Name)
+	assert.Equal(t, "AROAEXAMPLE", role.NativeID)
+	assert.Equal(t, "global", role.Region)
+	assert.Empty(t, role.Tags)
+	assert.JSONEq(t, `{}`, string(role.Attributes))
+}
+
+func TestParseGCPAssets(t *testing.T) {
+	export := `{"name": "//compute.googleapis.com/projects/my-project/zones/us-central1-a/instances/web-1", "asset_type": "compute.googleapis.com/Instance", "resource": {"location": "us-central1-a", "data": {"name": "web-1", "labels": {"team": "web"}, "machineType": "e2-small"}}}
+{"name": "//storage.googleapis.com/assets-bucket", "assetType": "storage.googleapis.com/Bucket", "ancestors": ["projects/123456", "organizations/42"], "resource": {"location": "us", "data": {}}}
+`
+
+	resources, err := importer.Parse(importer.FormatGCPAsset, strings.NewReader(export))
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+
+	vm := resources[0]
+	assert.Equal(t, domain.ProviderGCP, vm.Provider)
+	assert.Equal(t, "web-1", vm.Name)
+	assert.Equal(t, "compute.googleapis.com/Instance", vm.Type)
+	assert.Equal(t, "us-central1-a", vm.Region)
+	assert.Equal(t, "my-project", vm.AccountID)
+	assert.Equal(t, map[string]string{"team": "web"}, vm.Tags)
+	assert.JSONEq(t, `{"name": "web-1", "labels": {"team": "web"}, "machineType": "e2-small"}`, string(vm.Attributes))
+
+	bucket := resources[1]
+	assert.Equal(t, "assets-bucket", bucket.Name)
+	assert.Equal(t, "storage.googleapis.com/Bucket", bucket.Type)
+	assert.Equal(t, "123456", bucket.AccountID)
+
+	// gcloud asset list prints a JSON array
+	resources, err = importer.Parse(importer.FormatGCPAsset, strings.NewReader(`[{"name": "//storage.googleapis.com/b", "assetType": "storage.googleapis.com/Bucket"}]`))
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	assert.Equal(t, "global", resources[0].Region)
+}
+
+func TestParseAzureGraph(t *testing.T) {
+	row := `{"id": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/logs",
+		"name": "logs", "type": "microsoft.storage/storageaccounts", "location": "westeurope",
+		"subscriptionId": "sub-1", "tags": {"owner": "ops"}, "properties": {"accessTier": "Hot"}}`
+
+	for name, export := range map[string]string{
+		"envelope": `{"count": 1, "data": [` + row + `], "skip_token": null}`,
+		"array":    `[` + row + `]`,
+	} {
+		resources, err := importer.Parse(importer.FormatAzureGraph, strings.NewReader(export))
+		require.NoError(t, err, name)
+		require.Len(t, resources, 1, name)
+
+		res := resources[0]
+		assert.Equal(t, domain.ProviderAzure, res.Provider, name)
+		assert.Equal(t, "logs", res.Name, name)
+		assert.Equal(t, "microsoft.storage/storageaccounts", res.Type, name)
+		assert.Equal(t, "westeurope", res.Region, name)
+		assert.Equal(t, "sub-1", res.AccountID, name)
+		assert.Equal(t, "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/logs", res.NativeID, name)
+		assert.Equal(t, map[string]string{"owner": "ops"}, res.Tags, name)
+		assert.JSONEq(t, `{"accessTier": "Hot"}`, string(res.Attributes), name)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := importer.Parse("csv", strings.NewReader("{}"))
+	assert.ErrorIs(t, err, importer.ErrUnknownFormat)
+
+	for name, tc := range map[string]struct {
+		format string
+		export string
+	}{
+		"aws not json":        {importer.FormatAWSConfig, "{"},
+		"aws no items":        {importer.FormatAWSConfig, `{"fileVersion": "1.0"}`},
+		"aws missing type":    {importer.FormatAWSConfig, `{"configurationItems": [{"resourceId": "vpc-1"}]}`},
+		"gcp missing name":    {importer.FormatGCPAsset, `{"assetType": "storage.googleapis.com/Bucket"}`},
+		"gcp bad line":        {importer.FormatGCPAsset, "{\"name\": \"//a/b\", \"assetType\": \"t\"}\nnot json\n"},
+		"azure missing id":    {importer.FormatAzureGraph, `[{"name": "logs", "type": "t"}]`},
+		"azure wrong payload": {importer.FormatAzureGraph, `"resources"`},
+		"aws long type": {importer.FormatAWSConfig,
+			`{"configurationItems": [{"resourceId": "vpc-1", "resourceType": "` + strings.Repeat("t", 101) + `"}]}`},
+		"azure long name": {importer.FormatAzureGraph,
+			`[{"id": "/subscriptions/s/x", "name": "` + strings.Repeat("n", 256) + `", "type": "t"}]`},
+	} {
+		_, err := importer.Parse(tc.format, strings.NewReader(tc.export))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParse_LongNativeID(t *testing.T) {
+	id := "/subscriptions/s/resourceGroups/" + strings.Repeat("g", 300) + "/providers/Microsoft.Storage/storageAccounts/logs"
+	resources, err := importer.Parse(importer.FormatAzureGraph, strings.NewReader(`[{"id": "`+id+`", "type": "t"}]`))
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+
+	// The name keeps the end of the ID, and the ID itself is unchanged
+	assert.Len(t, resources[0].Name, domain.MaxResourceNameLength)
+	assert.True(t, strings.HasSuffix(resources[0].Name, "/storageAccounts/logs"))
+	assert.Equal(t, id, resources[0].NativeID)
+}
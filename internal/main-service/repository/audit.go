@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+// AuditRepository reads the audit events that the other repositories record
+// alongside their own changes. There is deliberately no way to change them.
+type AuditRepository interface {
+	List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error)
+}
+
+type auditRepo struct {
+	db       *sql.DB
+	timeouts db.Timeouts
+}
+
+func NewAuditRepository(conn *sql.DB, timeouts db.Timeouts) AuditRepository {
+	return tracedAuditRepo{next: &auditRepo{db: conn, timeouts: timeouts}}
+}
+
+func (r *auditRepo) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	var conds []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Actor != "" {
+		conds = append(conds, "actor = "+arg(filter.Actor))
+	}
+	if filter.Action != "" {
+		conds = append(conds, "action = "+arg(filter.Action))
+	}
+	if filter.EntityType != "" {
+		conds = append(conds, "entity_type = "+arg(filter.EntityType))
+	}
+	if filter.EntityID != 0 {
+		conds = append(conds, "entity_id = "+arg(filter.EntityID))
+	}
+	if filter.CustomerID != 0 {
+		conds = append(conds, "customer_id = "+arg(filter.CustomerID))
+	}
+	if !filter.Since.IsZero() {
+		conds = append(conds, "occurred_at >= "+arg(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		conds = append(conds, "occurred_at < "+arg(filter.Until))
+	}
+	if filter.BeforeID != 0 {
+		conds = append(conds, "id < "+arg(filter.BeforeID))
+	}
+
+	query := `SELECT id, occurred_at, actor_kind, actor, action, entity_type, entity_id,
+                     COALESCE(customer_id, 0), before, after, COALESCE(request_id, '')
+              FROM audit_events`
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	query += ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + arg(filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.AuditEvent
+	for rows.Next() {
+		var e domain.AuditEvent
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.ActorKind, &e.Actor, &e.Action, &e.EntityType,
+			&e.EntityID, &e.CustomerID, &before, &after, &e.RequestID); err != nil {
+			return nil, err
+		}
+		e.Before = jsonOrNull(before)
+		e.After = jsonOrNull(after)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func jsonOrNull(b []byte) json.RawMessage {
+	if b == nil {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(b)
+}
+
+// auditEntry describes one change for recordAudit. Before and After are
+// marshalled to JSON; nil is stored as NULL.
+type auditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   int64
+	CustomerID int64
+	Before     interface{}
+	After      interface{}
+}
+
+// recordAudit appends an audit event using the caller's transaction, so the
+// event is only recorded if the change it describes commits. The actor is
+// the authenticated caller in ctx, or the system for internal callers.
+func recordAudit(ctx context.Context, tx execer, e auditEntry) error {
+	actorKind, actor := domain.AuditActorSystem, domain.AuditActorSystem
+	if p, ok := auth.FromContext(ctx); ok {
+		actorKind, actor = string(p.Kind), p.Subject
+	}
+	before, err := marshalAuditState(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalAuditState(e.After)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO audit_events (actor_kind, actor, action, entity_type, entity_id, customer_id, before, after, request_id)
+        VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, $8, NULLIF($9, ''))
+    `
+	_, err = tx.ExecContext(ctx, query, actorKind, actor, e.Action, e.EntityType, e.EntityID,
+		e.CustomerID, before, after, logging.RequestID(ctx))
+	return err
+}
+
+func marshalAuditState(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// removeAssignments soft deletes the live customer_resource rows matching
+// cond, which takes id as $1, and records an unassign event for each. The
+// rows get the transaction's NOW() as deleted_at, the same as the resource or
+// customer deleted alongside them.
+func removeAssignments(ctx context.Context, tx *sql.Tx, cond string, id int64) error {
+	query := `
+        UPDATE customer_resource cr
+        SET deleted_at = NOW()
+        FROM resources r
+        WHERE r.id = cr.resource_id AND cr.deleted_at IS NULL AND ` + cond + `
+        RETURNING cr.customer_id, cr.resource_id, r.name
+    `
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	var removed []domain.Assignment
+	for rows.Next() {
+		var a domain.Assignment
+		if err := rows.Scan(&a.CustomerID, &a.ResourceID, &a.ResourceName); err != nil {
+			rows.Close()
+			return err
+		}
+		removed = append(removed, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range removed {
+		if err := recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionUnassign,
+			EntityType: domain.AuditEntityAssignment,
+			EntityID:   removed[i].ResourceID,
+			CustomerID: removed[i].CustomerID,
+			Before:     &removed[i],
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/lib/pq"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+)
+
+type CatalogRepository interface {
+	// Seed applies a catalog in one transaction: resources are upserted by
+	// name, customers by email, and the listed assignments added. Nothing is
+	// written unless every step succeeds.
+	Seed(ctx context.Context, catalog *domain.Catalog, opts domain.SeedOptions) (domain.SeedSummary, error)
+}
+
+type catalogRepo struct {
+	db       *sql.DB
+	timeouts db.Timeouts
+}
+
+func NewCatalogRepository(conn *sql.DB, timeouts db.Timeouts) CatalogRepository {
+	return tracedCatalogRepo{next: &catalogRepo{db: conn, timeouts: timeouts}}
+}
+
+// Seed records every change in the audit log, and queues one notification
+// per customer for its new assignments, in the same transaction. A deleted
+// resource listed in the catalog is undeleted, without its old assignments.
+// On a dry run the transaction is rolled back after the summary is taken.
+func (r *catalogRepo) Seed(ctx context.Context, catalog *domain.Catalog, opts domain.SeedOptions) (summary domain.SeedSummary, err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return summary, err
+	}
+	defer func() {
+		if err != nil || opts.DryRun {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	names := make([]string, 0, len(catalog.Resources))
+	for i := range catalog.Resources {
+		res := &catalog.Resources[i]
+		names = append(names, res.Name)
+		if err = seedResource(ctx, tx, res, &summary); err != nil {
+			return summary, fmt.Errorf("resource %s: %w", res.Name, err)
+		}
+	}
+
+	if opts.Prune {
+		if summary.ResourcesPruned, err = pruneResources(ctx, tx, names); err != nil {
+			return summary, err
+		}
+	}
+
+	for i := range catalog.Customers {
+		c := &catalog.Customers[i]
+		if err = seedCustomer(ctx, tx, c, &summary); err != nil {
+			return summary, fmt.Errorf("customer %s: %w", c.Email, err)
+		}
+	}
+	return summary, nil
+}
+
+// seedResource creates, updates or undeletes the resource with res's name.
+// Provider, account, native ID, tags and attributes left out of the catalog
+// keep their current values, so that seeding does not strip an imported
+// resource of the identity the next import matches it by.
+func seedResource(ctx context.Context, tx *sql.Tx, res *domain.Resource, summary *domain.SeedSummary) error {
+	var id int64
+	var deleted bool
+	query := `SELECT id, deleted_at IS NOT NULL FROM resources WHERE name = $1 FOR UPDATE`
+	err := tx.QueryRowContext(ctx, query, res.Name).Scan(&id, &deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		tags, attributes, err := resourceJSON(res)
+		if err != nil {
+			return err
+		}
+		var created domain.Resource
+		query = `
+            INSERT INTO resources (name, type, region, provider, account_id, native_id, tags, attributes, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+            RETURNING ` + resourceColumns + `
+        `
+		if err = scanResource(tx.QueryRowContext(ctx, query, res.Name, res.Type, res.Region, res.Provider,
+			res.AccountID, res.NativeID, tags, attributes), &created); err != nil {
+			return err
+		}
+		summary.ResourcesCreated++
+		return recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionCreate,
+			EntityType: domain.AuditEntityResource,
+			EntityID:   created.ID,
+			After:      &created,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	var before domain.Resource
+	query = `SELECT ` + resourceColumns + ` FROM resources WHERE id = $1`
+	if err = scanResource(tx.QueryRowContext(ctx, query, id), &before); err != nil {
+		return err
+	}
+	if res.NativeID != "" && before.NativeID != "" && res.NativeID != before.NativeID {
+		return fmt.Errorf("native ID %s does not match %s", res.NativeID, before.NativeID)
+	}
+
+	want := *res
+	if want.Provider == "" {
+		want.Provider = before.Provider
+	}
+	if want.AccountID == "" {
+		want.AccountID = before.AccountID
+	}
+	if want.NativeID == "" {
+		want.NativeID = before.NativeID
+	}
+	if len(want.Tags) == 0 {
+		want.Tags = before.Tags
+	}
+	if emptyObject(want.Attributes) {
+		want.Attributes = before.Attributes
+	}
+
+	if !deleted && before.Type == want.Type && before.Region == want.Region && before.Provider == want.Provider &&
+		before.AccountID == want.AccountID && before.NativeID == want.NativeID &&
+		maps.Equal(before.Tags, want.Tags) && sameJSON(before.Attributes, want.Attributes) {
+		summary.ResourcesUnchanged++
+		return nil
+	}
+
+	tags, attributes, err := resourceJSON(&want)
+	if err != nil {
+		return err
+	}
+	var after domain.Resource
+	query = `
+        UPDATE resources
+        SET type = $1, region = $2, provider = $3, account_id = $4, native_id = $5, tags = $6, attributes = $7,
+            deleted_at = NULL, updated_at = NOW()
+        WHERE id = $8
+        RETURNING ` + resourceColumns + `
+    `
+	if err = scanResource(tx.QueryRowContext(ctx, query, want.Type, want.Region, want.Provider, want.AccountID,
+		want.NativeID, tags, attributes, id), &after); err != nil {
+		return err
+	}
+	summary.ResourcesUpdated++
+
+	action := domain.AuditActionUpdate
+	if deleted {
+		action = domain.AuditActionRestore
+		// The assignments deleted with the resource are not restored, and
+		// would otherwise keep the customers from being assigned it again
+		query = `DELETE FROM customer_resource WHERE resource_id = $1 AND deleted_at IS NOT NULL`
+		if _, err = tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+	return recordAudit(ctx, tx, auditEntry{
+		Action:     action,
+		EntityType: domain.AuditEntityResource,
+		EntityID:   after.ID,
+		Before:     &before,
+		After:      &after,
+	})
+}
+
+// emptyObject reports whether raw is missing or an empty JSON object.
+func emptyObject(raw json.RawMessage) bool {
+	var v map[string]interface{}
+	return len(raw) == 0 || (json.Unmarshal(raw, &v) == nil && len(v) == 0)
+}
+
+// pruneResources soft deletes the live resources whose name is not in keep,
+// together with their assignments, and returns their names.
+func pruneResources(ctx context.Context, tx *sql.Tx, keep []string) ([]string, error) {
+	query := `SELECT id FROM resources WHERE deleted_at IS NULL AND NOT (name = ANY($1)) ORDER BY name FOR UPDATE`
+	rows, err := tx.QueryContext(ctx, query, pq.Array(keep))
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	pruned := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if err := removeAssignments(ctx, tx, "cr.resource_id = $1", id); err != nil {
+			return nil, err
+		}
+		var before domain.Resource
+		query := `
+            UPDATE resources SET deleted_at = NOW()
+            WHERE id = $1
+            RETURNING ` + resourceColumns + `
+        `
+		if err := scanResource(tx.QueryRowContext(ctx, query, id), &before); err != nil {
+			return nil, err
+		}
+		if err := recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionDelete,
+			EntityType: domain.AuditEntityResource,
+			EntityID:   id,
+			Before:     &before,
+		}); err != nil {
+			return nil, err
+		}
+		pruned = append(pruned, before.Name)
+	}
+	return pruned, nil
+}
+
+// seedCustomer creates the customer or renames the live customer with its
+// email, then assigns the listed resources it does not have yet.
+func seedCustomer(ctx context.Context, tx *sql.Tx, c *domain.CatalogCustomer, summary *domain.SeedSummary) error {
+	var before domain.Customer
+	query := `SELECT id, name, email, created_at, updated_at FROM customers WHERE email = $1 AND deleted_at IS NULL FOR UPDATE`
+	err := tx.QueryRowContext(ctx, query, c.Email).Scan(&before.ID, &before.Name, &before.Email, &before.CreatedAt, &before.UpdatedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		created := domain.Customer{Name: c.Name, Email: c.Email}
+		query = `
+            INSERT INTO customers (name, email, created_at, updated_at)
+            VALUES ($1, $2, NOW(), NOW())
+            RETURNING id, created_at, updated_at
+        `
+		if err = tx.QueryRowContext(ctx, query, created.Name, created.Email).Scan(&created.ID, &created.CreatedAt, &created.UpdatedAt); err != nil {
+			return err
+		}
+		if err = recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionCreate,
+			EntityType: domain.AuditEntityCustomer,
+			EntityID:   created.ID,
+			CustomerID: created.ID,
+			After:      &created,
+		}); err != nil {
+			return err
+		}
+		summary.CustomersCreated++
+		before = created
+	case err != nil:
+		return err
+	case before.Name == c.Name:
+		summary.CustomersUnchanged++
+	default:
+		after := before
+		after.Name = c.Name
+		query = `UPDATE customers SET name = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at`
+		if err = tx.QueryRowContext(ctx, query, after.Name, after.ID).Scan(&after.UpdatedAt); err != nil {
+			return err
+		}
+		if err = recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionUpdate,
+			EntityType: domain.AuditEntityCustomer,
+			EntityID:   after.ID,
+			CustomerID: after.ID,
+			Before:     &before,
+			After:      &after,
+		}); err != nil {
+			return err
+		}
+		summary.CustomersUpdated++
+	}
+
+	if len(c.Resources) == 0 {
+		return nil
+	}
+	assigned, err := seedAssignments(ctx, tx, before.ID, c.Resources)
+	if err != nil {
+		return err
+	}
+	summary.Assigned += len(assigned)
+	if len(assigned) == 0 {
+		return nil
+	}
+	return enqueueNotification(ctx, tx, domain.ResourcesAddedNotification(before.ID, assigned))
+}
+
+// seedAssignments links the named resources to the customer and returns the
+// names that were not linked before. Every name must be a live resource.
+func seedAssignments(ctx context.Context, tx *sql.Tx, customerID int64, resourceNames []string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, name FROM resources WHERE name = ANY($1) AND deleted_at IS NULL`, pq.Array(resourceNames))
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(resourceNames))
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids[name] = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var assigned []string
+	for _, name := range resourceNames {
+		id, ok := ids[name]
+		if !ok {
+			return nil, fmt.Errorf("resource %s is not in the catalog", name)
+		}
+		query := `
+            INSERT INTO customer_resource (customer_id, resource_id)
+            VALUES ($1, $2)
+            ON CONFLICT (customer_id, resource_id) DO NOTHING
+        `
+		result, err := tx.ExecContext(ctx, query, customerID, id)
+		if err != nil {
+			return nil, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+		if err := recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionAssign,
+			EntityType: domain.AuditEntityAssignment,
+			EntityID:   id,
+			CustomerID: customerID,
+			After:      &domain.Assignment{CustomerID: customerID, ResourceID: id, ResourceName: name},
+		}); err != nil {
+			return nil, err
+		}
+		assigned = append(assigned, name)
+	}
+	return assigned, nil
+}
@@ -0,0 +1,192 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+)
+
+func testCatalog() *domain.Catalog {
+	return &domain.Catalog{
+		Resources: []domain.Resource{
+			{Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1", Provider: "aws", Attributes: json.RawMessage(`{}`)},
+			{Name: "gcp_vm_instance", Type: "Compute", Region: "us-central1", Provider: "gcp", Attributes: json.RawMessage(`{}`)},
+		},
+		Customers: []domain.CatalogCustomer{
+			{Name: "ebuka", Email: "ebuka@gmail.com", Resources: []string{"aws_vpc_main"}},
+		},
+	}
+}
+
+func TestCatalogSeed_IntegrationTest_CreatesAndIsIdempotent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	db := setUpTestDB(t)
+	repo := repository.NewCatalogRepository(db, testTimeouts)
+
+	summary, err := repo.Seed(context.Background(), testCatalog(), domain.SeedOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, domain.SeedSummary{ResourcesCreated: 2, CustomersCreated: 1, Assigned: 1}, summary)
+	assert.Equal(t, 1, count(t, db, `SELECT COUNT(*) FROM outbox`))
+
+	summary, err = repo.Seed(context.Background(), testCatalog(), domain.SeedOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, domain.SeedSummary{ResourcesUnchanged: 2, CustomersUnchanged: 1}, summary)
+}
+
+func TestCatalogSeed_IntegrationTest_DryRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	db := setUpTestDB(t)
+	repo := repository.NewCatalogRepository(db, testTimeouts)
+
+	summary, err := repo.Seed(context.Background(), testCatalog(), domain.SeedOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, domain.SeedSummary{ResourcesCreated: 2, CustomersCreated: 1, Assigned: 1}, summary)
+
+	// Nothing is kept
+	assert.Equal(t, 0, count(t, db, `SELECT COUNT(*) FROM resources`))
+	assert.Equal(t, 0, count(t, db, `SELECT COUNT(*) FROM customers`))
+	assert.Equal(t, 0, count(t, db, `SELECT COUNT(*) FROM audit_events`))
+	assert.Equal(t, 0, count(t, db, `SELECT COUNT(*) FROM outbox`))
+}
+
+func TestCatalogSeed_IntegrationTest_Prune(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	db := setUpTestDB(t)
+	repo := repository.NewCatalogRepository(db, testTimeouts)
+
+	customer := insertCustomer(t, db, "ebuka", "ebuka@gmail.com")
+	stale := insertResource(t, db, domain.Resource{Name: "azure_vm_old", Type: "VM", Region: "westeurope"})
+	assign(t, db, customer.ID, stale.ID)
+
+	summary, err := repo.Seed(context.Background(), testCatalog(), domain.SeedOptions{Prune: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"azure_vm_old"}, summary.ResourcesPruned)
+
+	assert.Equal(t, 1, count(t, db, `SELECT COUNT(*) FROM resources WHERE id = $1 AND deleted_at IS NOT NULL`, stale.ID))
+	assert.Equal(t, 1, count(t, db, `SELECT COUNT(*) FROM customer_resource WHERE resource_id = $1 AND deleted_at IS NOT NULL`, stale.ID))
+	assert.Equal(t, []string{domain.AuditActionDelete}, auditActions(t, db, domain.AuditEntityResource, stale.ID))
+	assert.Equal(t, []string{domain.AuditActionUnassign}, auditActions(t, db, domain.AuditEntityAssignment, stale.ID))
+
+	// The catalog resources are kept
+	assert.Equal(t, 2, count(t, db, `SELECT COUNT(*) FROM resources WHERE deleted_at IS NULL`))
+}
+
+func TestCatalogSeed_IntegrationTest_UnknownAssignmentRollsBack(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	db := setUpTestDB(t)
+	repo := repository.NewCatalogRepository(db, testTimeouts)
+
+	catalog := testCatalog()
+	catalog.Customers[0].Resources = append(catalog.Customers[0].Resources, "aws_s3_missing")
+
+	_, err := repo.Seed(context.Background(), catalog, domain.SeedOptions{})
+	assert.ErrorContains(t, err, "aws_s3_missing")
+
+	assert.Equal(t, 0, count(t, db, `SELECT COUNT(*) FROM resources`))
+	assert.Equal(t, 0, count(t, db, `SELECT COUNT(*) FROM customers`))
+	assert.Equal(t, 0, count(t, db, `SELECT COUNT(*) FROM audit_events`))
+}
+
+func TestCatalogSeed_IntegrationTest_UndeleteThenAssign(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	db := setUpTestDB(t)
+	ctx := context.Background()
+	catalogRepo := repository.NewCatalogRepository(db, testTimeouts)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+
+	customer := insertCustomer(t, db, "ebuka", "ebuka@gmail.com")
+	vpc := insertResource(t, db, domain.Resource{Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1", Provider: "aws"})
+	assign(t, db, customer.ID, vpc.ID)
+	require.NoError(t, resourceRepo.Delete(ctx, vpc.ID))
+
+	// Seeding undeletes the resource without its old assignment
+	summary, err := catalogRepo.Seed(ctx, &domain.Catalog{Resources: testCatalog().Resources[:1]}, domain.SeedOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.ResourcesUpdated)
+	assert.Contains(t, auditActions(t, db, domain.AuditEntityResource, vpc.ID), domain.AuditActionRestore)
+
+	owned, err := resourceRepo.GetResourcesByCustomer(ctx, customer.ID, domain.ResourceFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, owned)
+
+	// The customer can be assigned it again
+	results, err := resourceRepo.AddResourcesToCustomer(ctx, []string{vpc.Name}, customer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ResourceAssignment{{Name: vpc.Name, Status: domain.AssignmentAssigned}}, results)
+
+	owned, err = resourceRepo.GetResourcesByCustomer(ctx, customer.ID, domain.ResourceFilter{})
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	assert.Equal(t, vpc.ID, owned[0].ID)
+}
+
+func TestCatalogSeed_IntegrationTest_UndeleteThenSeedAssignment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	db := setUpTestDB(t)
+	ctx := context.Background()
+	catalogRepo := repository.NewCatalogRepository(db, testTimeouts)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+
+	customer := insertCustomer(t, db, "ebuka", "ebuka@gmail.com")
+	vpc := insertResource(t, db, domain.Resource{Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1", Provider: "aws"})
+	assign(t, db, customer.ID, vpc.ID)
+	require.NoError(t, resourceRepo.Delete(ctx, vpc.ID))
+
+	summary, err := catalogRepo.Seed(ctx, testCatalog(), domain.SeedOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Assigned)
+
+	has, err := resourceRepo.DoesCustomerHaveResource(ctx, customer.ID, vpc.Name)
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestCatalogSeed_IntegrationTest_KeepsImportedIdentity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	db := setUpTestDB(t)
+	ctx := context.Background()
+	repo := repository.NewCatalogRepository(db, testTimeouts)
+
+	arn := "arn:aws:ec2:us-east-1:123456789012:vpc/vpc-0abc"
+	vpc := insertResource(t, db, domain.Resource{Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1",
+		Provider: "aws", AccountID: "123456789012", NativeID: arn})
+
+	// The catalog entry leaves the account and native ID out
+	catalog := &domain.Catalog{Resources: []domain.Resource{
+		{Name: "aws_vpc_main", Type: "VPC", Region: "us-east-2", Attributes: json.RawMessage(`{}`)},
+	}}
+	summary, err := repo.Seed(ctx, catalog, domain.SeedOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.ResourcesUpdated)
+
+	var provider, accountID, nativeID, region string
+	err = db.QueryRow(`SELECT provider, account_id, native_id, region FROM resources WHERE id = $1`, vpc.ID).
+		Scan(&provider, &accountID, &nativeID, &region)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aws", "123456789012", arn, "us-east-2"}, []string{provider, accountID, nativeID, region})
+
+	// A different native ID is refused
+	catalog.Resources[0].NativeID = "arn:aws:ec2:us-east-1:123456789012:vpc/vpc-0def"
+	_, err = repo.Seed(ctx, catalog, domain.SeedOptions{})
+	assert.ErrorContains(t, err, "does not match")
+}
@@ -1,37 +1,78 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
 )
 
 type CustomerRepository interface {
-	Create(customer *domain.Customer) error
-	GetByID(id int64) (*domain.Customer, error)
-	GetByEmail(email string) (*domain.Customer, error)
+	Create(ctx context.Context, customer *domain.Customer) error
+	GetByID(ctx context.Context, id int64) (*domain.Customer, error)
+	GetByEmail(ctx context.Context, email string) (*domain.Customer, error)
+	List(ctx context.Context, filter domain.CustomerFilter) ([]domain.Customer, int64, error)
+	Update(ctx context.Context, customer *domain.Customer) error
+	Delete(ctx context.Context, id int64) error
+	// Purge permanently removes the customers deleted before deletedBefore
+	// and returns how many there were.
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type customerRepo struct {
-	db *sql.DB
+	db       *sql.DB
+	timeouts db.Timeouts
 }
 
-func NewCustomerRepository(db *sql.DB) CustomerRepository {
-	return &customerRepo{db: db}
+func NewCustomerRepository(conn *sql.DB, timeouts db.Timeouts) CustomerRepository {
+	return tracedCustomerRepo{next: &customerRepo{db: conn, timeouts: timeouts}}
 }
 
-func (r *customerRepo) Create(c *domain.Customer) error {
+// Create inserts the customer and records the creation in the audit log in
+// the same transaction.
+func (r *customerRepo) Create(ctx context.Context, c *domain.Customer) (err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
 	query := `
         INSERT INTO customers (name, email, created_at, updated_at)
         VALUES ($1, $2, NOW(), NOW())
-        RETURNING id
+        RETURNING id, created_at, updated_at
     `
-	return r.db.QueryRow(query, c.Name, c.Email).Scan(&c.ID)
+	if err = tx.QueryRowContext(ctx, query, c.Name, c.Email).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, auditEntry{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityCustomer,
+		EntityID:   c.ID,
+		CustomerID: c.ID,
+		After:      c,
+	})
 }
 
-func (r *customerRepo) GetByID(id int64) (*domain.Customer, error) {
-	query := `SELECT id, name, email, created_at, updated_at FROM customers WHERE id = $1`
-	row := r.db.QueryRow(query, id)
+func (r *customerRepo) GetByID(ctx context.Context, id int64) (*domain.Customer, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	query := `SELECT id, name, email, created_at, updated_at FROM customers WHERE id = $1 AND deleted_at IS NULL`
+	row := r.db.QueryRowContext(ctx, query, id)
 	var c domain.Customer
 	if err := row.Scan(&c.ID, &c.Name, &c.Email, &c.CreatedAt, &c.UpdatedAt); err != nil {
 		return nil, err
@@ -39,12 +80,210 @@ func (r *customerRepo) GetByID(id int64) (*domain.Customer, error) {
 	return &c, nil
 }
 
-func (r *customerRepo) GetByEmail(email string) (*domain.Customer, error) {
-	query := `SELECT id, name, email, created_at, updated_at FROM customers WHERE email = $1`
-	row := r.db.QueryRow(query, email)
+func (r *customerRepo) GetByEmail(ctx context.Context, email string) (*domain.Customer, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	query := `SELECT id, name, email, created_at, updated_at FROM customers WHERE email = $1 AND deleted_at IS NULL`
+	row := r.db.QueryRowContext(ctx, query, email)
 	var c domain.Customer
 	if err := row.Scan(&c.ID, &c.Name, &c.Email, &c.CreatedAt, &c.UpdatedAt); err != nil {
 		return nil, err
 	}
 	return &c, nil
 }
+
+// List returns one page of live customers matching filter along with the
+// total number of matches, ordered by id.
+func (r *customerRepo) List(ctx context.Context, filter domain.CustomerFilter) ([]domain.Customer, int64, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	conds := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	if filter.Name != "" {
+		args = append(args, escapeLike(filter.Name))
+		conds = append(conds, fmt.Sprintf("name ILIKE '%%' || $%d || '%%'", len(args)))
+	}
+	if filter.Email != "" {
+		args = append(args, escapeLike(filter.Email))
+		conds = append(conds, fmt.Sprintf("email ILIKE '%%' || $%d || '%%'", len(args)))
+	}
+	where := " WHERE " + strings.Join(conds, " AND ")
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM customers`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`SELECT id, name, email, created_at, updated_at FROM customers%s
+              ORDER BY id LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var customers []domain.Customer
+	for rows.Next() {
+		var c domain.Customer
+		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		customers = append(customers, c)
+	}
+	return customers, total, rows.Err()
+}
+
+// Update saves the customer and records the old and new values in the audit
+// log in the same transaction. It returns sql.ErrNoRows when the customer
+// does not exist.
+func (r *customerRepo) Update(ctx context.Context, c *domain.Customer) (err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var before domain.Customer
+	query := `SELECT id, name, email, created_at, updated_at FROM customers WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err = tx.QueryRowContext(ctx, query, c.ID).Scan(&before.ID, &before.Name, &before.Email, &before.CreatedAt, &before.UpdatedAt); err != nil {
+		return err
+	}
+
+	query = `
+        UPDATE customers
+        SET name = $1, email = $2, updated_at = NOW()
+        WHERE id = $3
+        RETURNING created_at, updated_at
+    `
+	if err = tx.QueryRowContext(ctx, query, c.Name, c.Email, c.ID).Scan(&c.CreatedAt, &c.UpdatedAt); err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, auditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityCustomer,
+		EntityID:   c.ID,
+		CustomerID: c.ID,
+		Before:     &before,
+		After:      c,
+	})
+}
+
+// Delete soft deletes the customer together with its customer_resource rows.
+// The deletion notification is queued in the outbox, and the removed customer
+// and assignments are recorded in the audit log, in the same transaction.
+// It returns sql.ErrNoRows when the customer does not exist.
+func (r *customerRepo) Delete(ctx context.Context, id int64) (err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// Remove the assignments explicitly rather than through the cascade, so
+	// that each one is audited
+	if err = removeAssignments(ctx, tx, "cr.customer_id = $1", id); err != nil {
+		return err
+	}
+
+	var before domain.Customer
+	query := `
+        UPDATE customers SET deleted_at = NOW()
+        WHERE id = $1 AND deleted_at IS NULL
+        RETURNING id, name, email, created_at, updated_at
+    `
+	if err = tx.QueryRowContext(ctx, query, id).Scan(&before.ID, &before.Name, &before.Email, &before.CreatedAt, &before.UpdatedAt); err != nil {
+		return err
+	}
+	if err = recordAudit(ctx, tx, auditEntry{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityCustomer,
+		EntityID:   id,
+		CustomerID: id,
+		Before:     &before,
+	}); err != nil {
+		return err
+	}
+	return enqueueNotification(ctx, tx, domain.CustomerDeletedNotification(id))
+}
+
+// Purge deletes the customers soft deleted before deletedBefore, and through
+// the foreign key their customer_resource rows, recording each in the audit
+// log in the same transaction.
+func (r *customerRepo) Purge(ctx context.Context, deletedBefore time.Time) (n int64, err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	query := `
+        DELETE FROM customers
+        WHERE deleted_at < $1
+        RETURNING id, name, email, created_at, updated_at
+    `
+	rows, err := tx.QueryContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	var purged []domain.Customer
+	for rows.Next() {
+		var c domain.Customer
+		if err = rows.Scan(&c.ID, &c.Name, &c.Email, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		purged = append(purged, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i := range purged {
+		if err = recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionPurge,
+			EntityType: domain.AuditEntityCustomer,
+			EntityID:   purged[i].ID,
+			CustomerID: purged[i].ID,
+			Before:     &purged[i],
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(purged)), nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+)
+
+// InventoryRepository reads customers together with their assigned
+// resources, for exports.
+type InventoryRepository interface {
+	List(ctx context.Context, filter domain.InventoryFilter) ([]domain.InventoryRow, error)
+}
+
+type inventoryRepo struct {
+	db       *sql.DB
+	timeouts db.Timeouts
+}
+
+func NewInventoryRepository(conn *sql.DB, timeouts db.Timeouts) InventoryRepository {
+	return tracedInventoryRepo{next: &inventoryRepo{db: conn, timeouts: timeouts}}
+}
+
+func (r *inventoryRepo) List(ctx context.Context, filter domain.InventoryFilter) ([]domain.InventoryRow, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	conds := []string{"c.deleted_at IS NULL"}
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.CustomerID != 0 {
+		conds = append(conds, "c.id = "+arg(filter.CustomerID))
+	}
+	// Comparisons with the NULLs of a customer without resources are never
+	// true, so the resource filters also drop such customers
+	if filter.Provider != "" {
+		conds = append(conds, "r.provider = "+arg(filter.Provider))
+	}
+	if filter.AccountID != "" {
+		conds = append(conds, "r.account_id = "+arg(filter.AccountID))
+	}
+	if filter.Type != "" {
+		conds = append(conds, "r.type = "+arg(filter.Type))
+	}
+	if filter.Region != "" {
+		conds = append(conds, "r.region = "+arg(filter.Region))
+	}
+	if len(filter.Tags) > 0 {
+		tags, err := json.Marshal(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, "r.tags @> "+arg(string(tags))+"::jsonb")
+	}
+	if filter.AfterCustomerID != 0 || filter.AfterResourceID != 0 {
+		conds = append(conds, fmt.Sprintf("(c.id, COALESCE(r.id, 0)) > (%s, %s)",
+			arg(filter.AfterCustomerID), arg(filter.AfterResourceID)))
+	}
+
+	query := `
+        SELECT c.id, c.name, c.email, c.created_at, c.updated_at, cr.created_at,
+               r.id, r.name, r.type, r.region, r.provider, r.account_id, r.native_id, r.tags, r.attributes,
+               r.created_at, r.updated_at
+        FROM customers c
+        LEFT JOIN (customer_resource cr JOIN resources r ON r.id = cr.resource_id AND r.deleted_at IS NULL)
+            ON cr.customer_id = c.id AND cr.deleted_at IS NULL
+        WHERE ` + strings.Join(conds, " AND ") + `
+        ORDER BY c.id, COALESCE(r.id, 0)`
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + arg(filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []domain.InventoryRow
+	for rows.Next() {
+		var row domain.InventoryRow
+		var assignedAt, resCreatedAt, resUpdatedAt sql.NullTime
+		var resID sql.NullInt64
+		var name, typ, region, provider, accountID, nativeID sql.NullString
+		var tags, attributes []byte
+		if err := rows.Scan(&row.Customer.ID, &row.Customer.Name, &row.Customer.Email, &row.Customer.CreatedAt,
+			&row.Customer.UpdatedAt, &assignedAt, &resID, &name, &typ, &region, &provider, &accountID, &nativeID,
+			&tags, &attributes, &resCreatedAt, &resUpdatedAt); err != nil {
+			return nil, err
+		}
+		if resID.Valid {
+			row.Resource = &domain.Resource{
+				ID:         resID.Int64,
+				Name:       name.String,
+				Type:       typ.String,
+				Region:     region.String,
+				Provider:   provider.String,
+				AccountID:  accountID.String,
+				NativeID:   nativeID.String,
+				Attributes: json.RawMessage(attributes),
+				CreatedAt:  resCreatedAt.Time,
+				UpdatedAt:  resUpdatedAt.Time,
+			}
+			if err := json.Unmarshal(tags, &row.Resource.Tags); err != nil {
+				return nil, err
+			}
+			if assignedAt.Valid {
+				row.AssignedAt = &assignedAt.Time
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
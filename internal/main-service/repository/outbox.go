@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
+)
+
+// OutboxRepository reads and settles the notifications that the other
+// repositories write to the outbox table alongside their own changes.
+type OutboxRepository interface {
+	// ClaimPending leases up to limit due messages for the given duration so
+	// that concurrent relays skip them, and bumps their attempt count.
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error)
+	MarkSent(ctx context.Context, id int64) error
+	// MarkFailed records the publish error and makes the message due again
+	// at retryAt.
+	MarkFailed(ctx context.Context, id int64, publishErr error, retryAt time.Time) error
+}
+
+type outboxRepo struct {
+	db       *sql.DB
+	timeouts db.Timeouts
+}
+
+func NewOutboxRepository(conn *sql.DB, timeouts db.Timeouts) OutboxRepository {
+	return tracedOutboxRepo{next: &outboxRepo{db: conn, timeouts: timeouts}}
+}
+
+func (r *outboxRepo) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	query := `
+        UPDATE outbox
+        SET available_at = NOW() + make_interval(secs => $2), attempts = attempts + 1
+        WHERE id IN (
+            SELECT id FROM outbox
+            WHERE sent_at IS NULL AND available_at <= NOW()
+            ORDER BY id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, payload, attempts, created_at, trace_context, COALESCE(request_id, '')
+    `
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []domain.OutboxMessage
+	for rows.Next() {
+		var msg domain.OutboxMessage
+		var payload, traceContext []byte
+		if err := rows.Scan(&msg.ID, &payload, &msg.Attempts, &msg.CreatedAt, &traceContext, &msg.RequestID); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &msg.Notification); err != nil {
+			return nil, err
+		}
+		if traceContext != nil {
+			if err := json.Unmarshal(traceContext, &msg.TraceContext); err != nil {
+				return nil, err
+			}
+		}
+		msgs = append(msgs, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not preserve the subquery order
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].ID < msgs[j].ID })
+	return msgs, nil
+}
+
+func (r *outboxRepo) MarkSent(ctx context.Context, id int64) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `UPDATE outbox SET sent_at = NOW(), last_error = NULL WHERE id = $1`, id)
+	return err
+}
+
+func (r *outboxRepo) MarkFailed(ctx context.Context, id int64, publishErr error, retryAt time.Time) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	query := `UPDATE outbox SET last_error = $2, available_at = $3 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, publishErr.Error(), retryAt)
+	return err
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// enqueueNotification writes n to the outbox using the caller's transaction,
+// so the notification is only recorded if the change it describes commits.
+// The trace context and request ID of ctx are stored with it for the relay
+// to pick up.
+func enqueueNotification(ctx context.Context, tx execer, n domain.Notification) error {
+	payload, err := json.Marshal(&n)
+	if err != nil {
+		return err
+	}
+	traceContext, err := json.Marshal(tracing.Inject(ctx))
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO outbox (event, payload, trace_context, request_id) VALUES ($1, $2, $3, NULLIF($4, ''))`
+	_, err = tx.ExecContext(ctx, query, n.Event, payload, traceContext, logging.RequestID(ctx))
+	return err
+}
@@ -1,128 +1,328 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+	"github.com/lib/pq"
 )
 
 type ResourceRepository interface {
-	GetAll() ([]domain.Resource, error)
-	AddResourcesToCustomer(resourceNames []string, customerID int64) error
-	GetResourcesByCustomer(customerID int64) ([]domain.Resource, error)
-	GetByID(resourceID int64) (*domain.Resource, error)
-	Update(resource *domain.Resource) error
-	Delete(resourceID int64) error
+	GetAll(ctx context.Context, filter domain.ResourceFilter) ([]domain.Resource, error)
+	AddResourcesToCustomer(ctx context.Context, resourceNames []string, customerID int64) ([]domain.ResourceAssignment, error)
+	GetResourcesByCustomer(ctx context.Context, customerID int64, filter domain.ResourceFilter) ([]domain.Resource, error)
+	GetByID(ctx context.Context, resourceID int64) (*domain.Resource, error)
+	Update(ctx context.Context, resource *domain.Resource) error
+	Delete(ctx context.Context, resourceID int64) error
 	// Optionally: create or get resource by name
-	GetByName(name string) (*domain.Resource, error)
-	AddResourceToCustomer(resourceName string, customerID int64) error
-	GetCustomerResourceByResourceName(customerID int64, resourceName string) (*domain.Resource, error)
-	DoesCustomerHaveResource(customerID int64, resourceName string) (bool, error)
+	GetByName(ctx context.Context, name string) (*domain.Resource, error)
+	AddResourceToCustomer(ctx context.Context, resourceName string, customerID int64) error
+	GetCustomerResourceByResourceName(ctx context.Context, customerID int64, resourceName string) (*domain.Resource, error)
+	DoesCustomerHaveResource(ctx context.Context, customerID int64, resourceName string) (bool, error)
+	RemoveResourceFromCustomer(ctx context.Context, customerID, resourceID int64) error
+	// Restore undeletes a deleted resource along with the assignments its
+	// deletion removed. It returns sql.ErrNoRows when there is no deleted
+	// resource with the id.
+	Restore(ctx context.Context, resourceID int64) (*domain.Resource, error)
+	// Purge permanently removes the resources deleted before deletedBefore
+	// and returns how many there were.
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// Import upserts resources by provider and native ID, committing them in
+	// batches, and reports what it did with them.
+	Import(ctx context.Context, resources []domain.Resource) (domain.ImportSummary, error)
+	// GetHistory returns every version of the resource, oldest first.
+	GetHistory(ctx context.Context, resourceID int64) ([]domain.ResourceVersion, error)
 }
 
 type resourceRepo struct {
-	db *sql.DB
+	db       *sql.DB
+	timeouts db.Timeouts
 }
 
-func NewResourceRepository(db *sql.DB) ResourceRepository {
-	return &resourceRepo{db: db}
+func NewResourceRepository(conn *sql.DB, timeouts db.Timeouts) ResourceRepository {
+	return tracedResourceRepo{next: &resourceRepo{db: conn, timeouts: timeouts}}
 }
 
-func (r *resourceRepo) GetAll() ([]domain.Resource, error) {
-	query := `SELECT id, name, type, region, created_at, updated_at FROM resources`
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return nil, err
+// resourceColumns are the resources columns read by scanResource, in order.
+const resourceColumns = `id, name, type, region, provider, account_id, native_id, tags, attributes, created_at, updated_at`
+
+// joinedResourceColumns is resourceColumns qualified with the r alias used in
+// joins.
+const joinedResourceColumns = `r.id, r.name, r.type, r.region, r.provider, r.account_id, r.native_id, r.tags, r.attributes, r.created_at, r.updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanResource(row rowScanner, res *domain.Resource) error {
+	var tags []byte
+	if err := row.Scan(&res.ID, &res.Name, &res.Type, &res.Region, &res.Provider, &res.AccountID, &res.NativeID,
+		&tags, &res.Attributes, &res.CreatedAt, &res.UpdatedAt); err != nil {
+		return err
 	}
-	defer rows.Close()
+	return json.Unmarshal(tags, &res.Tags)
+}
 
-	var resources []domain.Resource
-	for rows.Next() {
-		var res domain.Resource
-		if err := rows.Scan(&res.ID, &res.Name, &res.Type, &res.Region, &res.CreatedAt, &res.UpdatedAt); err != nil {
-			return nil, err
+// resourceJSON encodes the tags and attributes of res for a write. Missing
+// values are stored as empty objects.
+func resourceJSON(res *domain.Resource) (tags, attributes string, err error) {
+	tags = "{}"
+	if len(res.Tags) > 0 {
+		b, err := json.Marshal(res.Tags)
+		if err != nil {
+			return "", "", err
 		}
-		resources = append(resources, res)
+		tags = string(b)
+	}
+	attributes = "{}"
+	if len(res.Attributes) > 0 {
+		attributes = string(res.Attributes)
 	}
-	return resources, nil
+	return tags, attributes, nil
+}
+
+func (r *resourceRepo) GetAll(ctx context.Context, filter domain.ResourceFilter) ([]domain.Resource, error) {
+	return r.list(ctx, 0, filter)
 }
 
-func (r *resourceRepo) GetByName(name string) (*domain.Resource, error) {
-	query := `SELECT id, name, type, region, created_at, updated_at
-              FROM resources WHERE name = $1`
-	row := r.db.QueryRow(query, name)
+func (r *resourceRepo) GetByName(ctx context.Context, name string) (*domain.Resource, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	query := `SELECT ` + resourceColumns + `
+              FROM resources WHERE name = $1 AND deleted_at IS NULL`
+	row := r.db.QueryRowContext(ctx, query, name)
 	var res domain.Resource
-	if err := row.Scan(&res.ID, &res.Name, &res.Type, &res.Region, &res.CreatedAt, &res.UpdatedAt); err != nil {
+	if err := scanResource(row, &res); err != nil {
 		return nil, err
 	}
 	return &res, nil
 }
 
-func (r *resourceRepo) AddResourcesToCustomer(resourceNames []string, customerID int64) error {
-	tx, err := r.db.Begin()
+// AddResourcesToCustomer links every known name to the customer through
+// customer_resource in a single transaction and reports, per name, whether it
+// was assigned, already owned or not in the catalog. A notification listing
+// the newly assigned names is queued in the outbox, and each assignment is
+// recorded in the audit log, in the same transaction.
+func (r *resourceRepo) AddResourcesToCustomer(ctx context.Context, resourceNames []string, customerID int64) (results []domain.ResourceAssignment, err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err != nil {
 			_ = tx.Rollback()
 		} else {
-			_ = tx.Commit()
+			err = tx.Commit()
 		}
 	}()
 
+	// Resolve names to ids
+	rows, err := tx.QueryContext(ctx, `SELECT id, name FROM resources WHERE name = ANY($1) AND deleted_at IS NULL`, pq.Array(resourceNames))
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(resourceNames))
+	for rows.Next() {
+		var id int64
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids[name] = id
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	known := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		known = append(known, id)
+	}
+
+	// Assign whatever is not linked yet; the unique constraint tells us which
+	// rows the customer already had.
+	query := `
+        INSERT INTO customer_resource (customer_id, resource_id)
+        SELECT $1, unnest($2::int[])
+        ON CONFLICT (customer_id, resource_id) DO NOTHING
+        RETURNING resource_id
+    `
+	rows, err = tx.QueryContext(ctx, query, customerID, pq.Array(known))
+	if err != nil {
+		return nil, err
+	}
+	inserted := make(map[int64]bool, len(known))
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		inserted[id] = true
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results = make([]domain.ResourceAssignment, 0, len(resourceNames))
+	var assigned []string
 	for _, name := range resourceNames {
-		// Ensure resource exists
-		resource, errGet := r.getResourceByNameTx(tx, name)
-		if errGet != nil {
-			return errors.New("resource " + name + " does not exist")
+		status := domain.AssignmentUnknown
+		if id, ok := ids[name]; ok {
+			status = domain.AssignmentAlreadyOwned
+			if inserted[id] {
+				status = domain.AssignmentAssigned
+				assigned = append(assigned, name)
+				if err = recordAudit(ctx, tx, auditEntry{
+					Action:     domain.AuditActionAssign,
+					EntityType: domain.AuditEntityAssignment,
+					EntityID:   id,
+					CustomerID: customerID,
+					After:      &domain.Assignment{CustomerID: customerID, ResourceID: id, ResourceName: name},
+				}); err != nil {
+					return nil, err
+				}
+			}
 		}
+		results = append(results, domain.ResourceAssignment{Name: name, Status: status})
+	}
 
-		// Assign resource to customer
-		updateQuery := `UPDATE resources SET customer_id = $1, updated_at = NOW() WHERE id = $2`
-		_, errExec := tx.Exec(updateQuery, customerID, resource.ID)
-		if errExec != nil {
-			return errExec
+	// One aggregated notification for the whole batch
+	if len(assigned) > 0 {
+		if err = enqueueNotification(ctx, tx, domain.ResourcesAddedNotification(customerID, assigned)); err != nil {
+			return nil, err
 		}
 	}
-	return nil
+	return results, nil
 }
 
-func (r *resourceRepo) AddResourceToCustomer(resourceName string, customerID int64) error {
+// AddResourceToCustomer links the resource to the customer, queues the
+// matching notification in the outbox and records the assignment in the
+// audit log within the same transaction.
+func (r *resourceRepo) AddResourceToCustomer(ctx context.Context, resourceName string, customerID int64) (err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
 	// Ensure resource exists
-	resource, errGet := r.getResourceByName(resourceName)
+	resource, errGet := r.getResourceByName(ctx, resourceName)
 	if errGet != nil {
 		return errors.New("resource " + resourceName + " does not exist")
 	}
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
 	query := `INSERT INTO customer_resource (customer_id, resource_id) VALUES ($1, $2);`
-	_, err := r.db.Exec(query, customerID, resource.ID)
+	if _, err = tx.ExecContext(ctx, query, customerID, resource.ID); err != nil {
+		return err
+	}
+	if err = recordAudit(ctx, tx, auditEntry{
+		Action:     domain.AuditActionAssign,
+		EntityType: domain.AuditEntityAssignment,
+		EntityID:   resource.ID,
+		CustomerID: customerID,
+		After:      &domain.Assignment{CustomerID: customerID, ResourceID: resource.ID, ResourceName: resource.Name},
+	}); err != nil {
+		return err
+	}
+	return enqueueNotification(ctx, tx, domain.ResourceAddedNotification(customerID, resource.Name))
+}
+
+// RemoveResourceFromCustomer deletes the customer_resource link only; the
+// resource stays in the catalog. The removal notification is queued in the
+// outbox, and the removal recorded in the audit log, in the same transaction.
+// It returns sql.ErrNoRows when the customer does not own the resource.
+func (r *resourceRepo) RemoveResourceFromCustomer(ctx context.Context, customerID, resourceID int64) (err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	return nil
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
 
+	query := `
+        DELETE FROM customer_resource cr
+        USING resources r
+        WHERE r.id = cr.resource_id AND cr.customer_id = $1 AND cr.resource_id = $2
+          AND cr.deleted_at IS NULL AND r.deleted_at IS NULL
+        RETURNING r.name
+    `
+	var name string
+	if err = tx.QueryRowContext(ctx, query, customerID, resourceID).Scan(&name); err != nil {
+		return err
+	}
+	if err = recordAudit(ctx, tx, auditEntry{
+		Action:     domain.AuditActionUnassign,
+		EntityType: domain.AuditEntityAssignment,
+		EntityID:   resourceID,
+		CustomerID: customerID,
+		Before:     &domain.Assignment{CustomerID: customerID, ResourceID: resourceID, ResourceName: name},
+	}); err != nil {
+		return err
+	}
+	return enqueueNotification(ctx, tx, domain.ResourceRemovedNotification(customerID, name))
 }
 
-func (r *resourceRepo) GetCustomerResourceByResourceName(customerID int64, resourceName string) (*domain.Resource, error) {
-	query := `SELECT r.* FROM resources r JOIN customer_resource cr ON r.id = cr.resource_id 
-                WHERE cr.customer_id = $1 AND r.name = $2`
+func (r *resourceRepo) GetCustomerResourceByResourceName(ctx context.Context, customerID int64, resourceName string) (*domain.Resource, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
 
-	row := r.db.QueryRow(query, customerID, resourceName)
+	query := `SELECT ` + joinedResourceColumns + `
+                FROM resources r JOIN customer_resource cr ON r.id = cr.resource_id
+                WHERE cr.customer_id = $1 AND r.name = $2 AND r.deleted_at IS NULL AND cr.deleted_at IS NULL`
+
+	row := r.db.QueryRowContext(ctx, query, customerID, resourceName)
 	var res domain.Resource
-	if err := row.Scan(&res.ID, &res.Name, &res.Type, &res.Region, &res.CreatedAt, &res.UpdatedAt); err != nil {
+	if err := scanResource(row, &res); err != nil {
 		return nil, err
 	}
 	return &res, nil
 }
 
-func (r *resourceRepo) DoesCustomerHaveResource(customerID int64, resourceName string) (bool, error) {
-	query := `SELECT EXISTS ( SELECT 1 FROM resources r JOIN customer_resource cr ON r.id = cr.resource_id 
-        WHERE cr.customer_id = $1 AND r.name = $2) AS resource_owned;`
+func (r *resourceRepo) DoesCustomerHaveResource(ctx context.Context, customerID int64, resourceName string) (bool, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	query := `SELECT EXISTS ( SELECT 1 FROM resources r JOIN customer_resource cr ON r.id = cr.resource_id
+        WHERE cr.customer_id = $1 AND r.name = $2 AND r.deleted_at IS NULL AND cr.deleted_at IS NULL) AS resource_owned;`
 
 	var exists bool
-	row := r.db.QueryRow(query, customerID, resourceName)
+	row := r.db.QueryRowContext(ctx, query, customerID, resourceName)
 	if err := row.Scan(&exists); err != nil {
 		return false, err
 	}
@@ -130,36 +330,125 @@ func (r *resourceRepo) DoesCustomerHaveResource(customerID int64, resourceName s
 
 }
 
-func (r *resourceRepo) getResourceByNameTx(tx *sql.Tx, name string) (*domain.Resource, error) {
-	query := `SELECT id, name, type, region, created_at, updated_at
-              FROM resources WHERE name = $1`
-	row := tx.QueryRow(query, name)
+func (r *resourceRepo) getResourceByName(ctx context.Context, name string) (*domain.Resource, error) {
+	query := `SELECT ` + resourceColumns + `
+              FROM resources
+              WHERE name = $1 AND deleted_at IS NULL`
+	row := r.db.QueryRowContext(ctx, query, name)
 	var res domain.Resource
-	if err := row.Scan(&res.ID, &res.Name, &res.Type, &res.Region, &res.CreatedAt, &res.UpdatedAt); err != nil {
+	if err := scanResource(row, &res); err != nil {
 		return nil, err
 	}
 	return &res, nil
 }
 
-func (r *resourceRepo) getResourceByName(name string) (*domain.Resource, error) {
-	query := `SELECT id, name, type, region, created_at, updated_at
-              FROM resources
-              WHERE name = $1`
-	row := r.db.QueryRow(query, name)
-	var res domain.Resource
-	if err := row.Scan(&res.ID, &res.Name, &res.Type, &res.Region, &res.CreatedAt, &res.UpdatedAt); err != nil {
-		return nil, err
-	}
-	return &res, nil
+func (r *resourceRepo) GetResourcesByCustomer(ctx context.Context, customerID int64, filter domain.ResourceFilter) ([]domain.Resource, error) {
+	return r.list(ctx, customerID, filter)
 }
 
-func (r *resourceRepo) GetResourcesByCustomer(customerID int64) ([]domain.Resource, error) {
-	// query := `SELECT id, name, type, region, customer_id, created_at, updated_at
-	//           FROM resources
-	//           WHERE customer_id = $1`
+// list runs a keyset-paginated query over the live resources. A non-zero
+// customerID limits the result to that customer's assignments. A non-zero
+// filter.AsOf reads the
+// resource and assignment versions valid at that moment instead of the
+// current rows; UpdatedAt is then the start of the version. Up to
+// filter.Limit rows are returned, so callers ask for one extra row to learn
+// whether a next page exists.
+func (r *resourceRepo) list(ctx context.Context, customerID int64, filter domain.ResourceFilter) ([]domain.Resource, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	var conds []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	from := `resources r`
+	join := `customer_resource cr ON r.id = cr.resource_id AND cr.deleted_at IS NULL`
+	if filter.AsOf.IsZero() {
+		conds = append(conds, "r.deleted_at IS NULL")
+	} else {
+		asOf := arg(filter.AsOf)
+		from = `(SELECT resource_id AS id, name, type, region, provider, account_id, native_id, tags, attributes,
+                        created_at, valid_from AS updated_at
+                 FROM resource_versions
+                 WHERE valid_from <= ` + asOf + ` AND (valid_to IS NULL OR valid_to > ` + asOf + `)) r`
+		join = `customer_resource_versions cr ON r.id = cr.resource_id
+                 AND cr.valid_from <= ` + asOf + ` AND (cr.valid_to IS NULL OR cr.valid_to > ` + asOf + `)`
+	}
+	if customerID != 0 {
+		from += ` JOIN ` + join
+		conds = append(conds, "cr.customer_id = "+arg(customerID))
+	}
+	if filter.Type != "" {
+		conds = append(conds, "r.type = "+arg(filter.Type))
+	}
+	if filter.Region != "" {
+		conds = append(conds, "r.region = "+arg(filter.Region))
+	}
+	if filter.NamePrefix != "" {
+		conds = append(conds, "r.name LIKE "+arg(escapeLike(filter.NamePrefix))+" || '%'")
+	}
+	if filter.Provider != "" {
+		conds = append(conds, "r.provider = "+arg(filter.Provider))
+	}
+	if filter.AccountID != "" {
+		conds = append(conds, "r.account_id = "+arg(filter.AccountID))
+	}
+	if filter.NativeID != "" {
+		conds = append(conds, "r.native_id = "+arg(filter.NativeID))
+	}
+	if len(filter.Tags) > 0 {
+		tags, err := json.Marshal(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, "r.tags @> "+arg(string(tags))+"::jsonb")
+	}
+	// Sorted so the same filter always builds the same statement
+	keys := make([]string, 0, len(filter.Attributes))
+	for k := range filter.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		conds = append(conds, "r.attributes ->> "+arg(k)+" = "+arg(filter.Attributes[k]))
+	}
+
+	cmp, dir := ">", "ASC"
+	if filter.Descending {
+		cmp, dir = "<", "DESC"
+	}
+	orderBy := "r.id " + dir
+	switch filter.SortKey {
+	case domain.ResourceSortName:
+		orderBy = "r.name " + dir + ", " + orderBy
+	case domain.ResourceSortCreatedAt:
+		orderBy = "r.created_at " + dir + ", " + orderBy
+	}
+
+	if after := filter.After; after != nil {
+		switch filter.SortKey {
+		case domain.ResourceSortName:
+			conds = append(conds, fmt.Sprintf("(r.name, r.id) %s (%s, %s)", cmp, arg(after.Name), arg(after.ID)))
+		case domain.ResourceSortCreatedAt:
+			conds = append(conds, fmt.Sprintf("(r.created_at, r.id) %s (%s, %s)", cmp, arg(after.Time), arg(after.ID)))
+		default:
+			conds = append(conds, fmt.Sprintf("r.id %s %s", cmp, arg(after.ID)))
+		}
+	}
+
+	query := `SELECT ` + joinedResourceColumns + ` FROM ` + from
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	query += ` ORDER BY ` + orderBy
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + arg(filter.Limit)
+	}
 
-	query := `SELECT r.* FROM resources r JOIN customer_resource cr ON r.id = cr.resource_id WHERE cr.customer_id = $1;`
-	rows, err := r.db.Query(query, customerID)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -168,39 +457,453 @@ func (r *resourceRepo) GetResourcesByCustomer(customerID int64) ([]domain.Resour
 	var resources []domain.Resource
 	for rows.Next() {
 		var res domain.Resource
-		err := rows.Scan(&res.ID, &res.Name, &res.Type, &res.Region, &res.CreatedAt, &res.UpdatedAt)
-		if err != nil {
+		if err := scanResource(rows, &res); err != nil {
 			return nil, err
 		}
 		resources = append(resources, res)
 	}
-	return resources, nil
+	return resources, rows.Err()
+}
+
+func (r *resourceRepo) GetHistory(ctx context.Context, resourceID int64) ([]domain.ResourceVersion, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	query := `SELECT resource_id, name, type, region, provider, account_id, native_id, tags, attributes, valid_from, valid_to
+              FROM resource_versions
+              WHERE resource_id = $1
+              ORDER BY valid_from, id`
+	rows, err := r.db.QueryContext(ctx, query, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []domain.ResourceVersion
+	for rows.Next() {
+		var v domain.ResourceVersion
+		var validTo sql.NullTime
+		var tags []byte
+		if err := rows.Scan(&v.ResourceID, &v.Name, &v.Type, &v.Region, &v.Provider, &v.AccountID, &v.NativeID,
+			&tags, &v.Attributes, &v.ValidFrom, &validTo); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(tags, &v.Tags); err != nil {
+			return nil, err
+		}
+		if validTo.Valid {
+			v.ValidTo = &validTo.Time
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
 }
 
-func (r *resourceRepo) GetByID(resourceID int64) (*domain.Resource, error) {
-	query := `SELECT id, name, type, region, created_at, updated_at
+func (r *resourceRepo) GetByID(ctx context.Context, resourceID int64) (*domain.Resource, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	query := `SELECT ` + resourceColumns + `
               FROM resources
-              WHERE id = $1`
-	row := r.db.QueryRow(query, resourceID)
+              WHERE id = $1 AND deleted_at IS NULL`
+	row := r.db.QueryRowContext(ctx, query, resourceID)
 	var res domain.Resource
-	if err := row.Scan(&res.ID, &res.Name, &res.Type, &res.Region, &res.CreatedAt, &res.UpdatedAt); err != nil {
+	if err := scanResource(row, &res); err != nil {
 		return nil, err
 	}
 	return &res, nil
 }
 
-func (r *resourceRepo) Update(resource *domain.Resource) error {
-	query := `
+// Update saves the resource and records the old and new values in the audit
+// log in the same transaction. It returns sql.ErrNoRows when the resource
+// does not exist.
+func (r *resourceRepo) Update(ctx context.Context, resource *domain.Resource) (err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var before domain.Resource
+	query := `SELECT ` + resourceColumns + ` FROM resources WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err = scanResource(tx.QueryRowContext(ctx, query, resource.ID), &before); err != nil {
+		return err
+	}
+
+	tags, attributes, err := resourceJSON(resource)
+	if err != nil {
+		return err
+	}
+	query = `
         UPDATE resources
-        SET name = $1, type = $2, region = $3, updated_at = NOW()
-        WHERE id = $4
+        SET name = $1, type = $2, region = $3, provider = $4, account_id = $5, native_id = $6,
+            tags = $7, attributes = $8, updated_at = NOW()
+        WHERE id = $9
+        RETURNING ` + resourceColumns + `
     `
-	_, err := r.db.Exec(query, resource.Name, resource.Type, resource.Region, resource.ID)
-	return err
+	if err = scanResource(tx.QueryRowContext(ctx, query, resource.Name, resource.Type, resource.Region, resource.Provider,
+		resource.AccountID, resource.NativeID, tags, attributes, resource.ID), resource); err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, auditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityResource,
+		EntityID:   resource.ID,
+		Before:     &before,
+		After:      resource,
+	})
 }
 
-func (r *resourceRepo) Delete(resourceID int64) error {
-	query := `DELETE FROM resources WHERE id = $1`
-	_, err := r.db.Exec(query, resourceID)
-	return err
+// Delete soft deletes the resource together with its customer_resource rows,
+// recording the removed resource and assignments in the audit log in the
+// same transaction. Restore brings both back until Purge removes them. It
+// returns sql.ErrNoRows when the resource does not exist.
+func (r *resourceRepo) Delete(ctx context.Context, resourceID int64) (err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// Remove the assignments explicitly rather than through the cascade, so
+	// that each one is audited
+	if err = removeAssignments(ctx, tx, "cr.resource_id = $1", resourceID); err != nil {
+		return err
+	}
+
+	var before domain.Resource
+	query := `
+        UPDATE resources SET deleted_at = NOW()
+        WHERE id = $1 AND deleted_at IS NULL
+        RETURNING ` + resourceColumns + `
+    `
+	if err = scanResource(tx.QueryRowContext(ctx, query, resourceID), &before); err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, auditEntry{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityResource,
+		EntityID:   resourceID,
+		Before:     &before,
+	})
+}
+
+// Restore clears deleted_at on the resource and on the assignments deleted
+// with it, that is with the same deleted_at, unless their customer has been
+// deleted since. The restored resource and assignments are recorded in the
+// audit log in the same transaction.
+func (r *resourceRepo) Restore(ctx context.Context, resourceID int64) (res *domain.Resource, err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var deletedAt time.Time
+	query := `SELECT deleted_at FROM resources WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`
+	if err = tx.QueryRowContext(ctx, query, resourceID).Scan(&deletedAt); err != nil {
+		return nil, err
+	}
+
+	res = &domain.Resource{}
+	query = `
+        UPDATE resources SET deleted_at = NULL, updated_at = NOW()
+        WHERE id = $1
+        RETURNING ` + resourceColumns + `
+    `
+	if err = scanResource(tx.QueryRowContext(ctx, query, resourceID), res); err != nil {
+		return nil, err
+	}
+	if err = recordAudit(ctx, tx, auditEntry{
+		Action:     domain.AuditActionRestore,
+		EntityType: domain.AuditEntityResource,
+		EntityID:   resourceID,
+		After:      res,
+	}); err != nil {
+		return nil, err
+	}
+
+	query = `
+        UPDATE customer_resource cr
+        SET deleted_at = NULL
+        FROM customers c
+        WHERE c.id = cr.customer_id AND c.deleted_at IS NULL
+          AND cr.resource_id = $1 AND cr.deleted_at = $2
+        RETURNING cr.customer_id
+    `
+	rows, err := tx.QueryContext(ctx, query, resourceID, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	var customerIDs []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		customerIDs = append(customerIDs, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, customerID := range customerIDs {
+		if err = recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionAssign,
+			EntityType: domain.AuditEntityAssignment,
+			EntityID:   resourceID,
+			CustomerID: customerID,
+			After:      &domain.Assignment{CustomerID: customerID, ResourceID: resourceID, ResourceName: res.Name},
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Purge deletes the resources soft deleted before deletedBefore, and through
+// the foreign key their customer_resource rows, recording each in the audit
+// log in the same transaction. Their history is kept.
+func (r *resourceRepo) Purge(ctx context.Context, deletedBefore time.Time) (n int64, err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	query := `
+        DELETE FROM resources
+        WHERE deleted_at < $1
+        RETURNING ` + resourceColumns + `
+    `
+	rows, err := tx.QueryContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	var purged []domain.Resource
+	for rows.Next() {
+		var res domain.Resource
+		if err = scanResource(rows, &res); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		purged = append(purged, res)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i := range purged {
+		if err = recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionPurge,
+			EntityType: domain.AuditEntityResource,
+			EntityID:   purged[i].ID,
+			Before:     &purged[i],
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(purged)), nil
+}
+
+// importBatchSize is how many resources Import writes per transaction, so
+// that a large export neither holds one long transaction nor has to fit in
+// a single write timeout.
+const importBatchSize = 500
+
+// Import upserts resources by provider and native ID in transactions of
+// importBatchSize, recording each creation and update in the audit log. An
+// existing resource keeps its name, so that assignments by name are not
+// affected; a new one whose name is already taken is named after its native
+// ID instead, with a numeric suffix if that is taken too. Deleted resources
+// are skipped.
+//
+// When a batch fails, the batches before it stay committed and are counted
+// in the returned summary. As an import is idempotent, it can simply be run
+// again.
+func (r *resourceRepo) Import(ctx context.Context, resources []domain.Resource) (summary domain.ImportSummary, err error) {
+	for start := 0; start < len(resources); start += importBatchSize {
+		batch, err := r.importBatch(ctx, resources[start:min(start+importBatchSize, len(resources))])
+		if err != nil {
+			return summary, err
+		}
+		summary.Created += batch.Created
+		summary.Updated += batch.Updated
+		summary.Unchanged += batch.Unchanged
+		summary.Skipped += batch.Skipped
+	}
+	return summary, nil
+}
+
+// importBatch imports resources in one transaction, bounded by the write
+// timeout.
+func (r *resourceRepo) importBatch(ctx context.Context, resources []domain.Resource) (summary domain.ImportSummary, err error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return summary, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	for i := range resources {
+		res := &resources[i]
+		var tags, attributes string
+		if tags, attributes, err = resourceJSON(res); err != nil {
+			return summary, err
+		}
+
+		var id int64
+		var deleted bool
+		query := `SELECT id, deleted_at IS NOT NULL FROM resources WHERE provider = $1 AND native_id = $2 FOR UPDATE`
+		err = tx.QueryRowContext(ctx, query, res.Provider, res.NativeID).Scan(&id, &deleted)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			if err = importCreate(ctx, tx, res, tags, attributes); err != nil {
+				return summary, err
+			}
+			summary.Created++
+			continue
+		case err != nil:
+			return summary, err
+		case deleted:
+			summary.Skipped++
+			continue
+		}
+
+		var before domain.Resource
+		query = `SELECT ` + resourceColumns + ` FROM resources WHERE id = $1`
+		if err = scanResource(tx.QueryRowContext(ctx, query, id), &before); err != nil {
+			return summary, err
+		}
+		if before.Type == res.Type && before.Region == res.Region && before.AccountID == res.AccountID &&
+			maps.Equal(before.Tags, res.Tags) && sameJSON(before.Attributes, res.Attributes) {
+			summary.Unchanged++
+			continue
+		}
+
+		var after domain.Resource
+		query = `
+            UPDATE resources
+            SET type = $1, region = $2, account_id = $3, tags = $4, attributes = $5, updated_at = NOW()
+            WHERE id = $6
+            RETURNING ` + resourceColumns + `
+        `
+		if err = scanResource(tx.QueryRowContext(ctx, query, res.Type, res.Region, res.AccountID, tags, attributes, id), &after); err != nil {
+			return summary, err
+		}
+		if err = recordAudit(ctx, tx, auditEntry{
+			Action:     domain.AuditActionUpdate,
+			EntityType: domain.AuditEntityResource,
+			EntityID:   id,
+			Before:     &before,
+			After:      &after,
+		}); err != nil {
+			return summary, err
+		}
+		summary.Updated++
+	}
+	return summary, nil
+}
+
+// importCreate inserts an imported resource and records its creation in the
+// audit log.
+func importCreate(ctx context.Context, tx *sql.Tx, res *domain.Resource, tags, attributes string) error {
+	name, err := importName(ctx, tx, res)
+	if err != nil {
+		return err
+	}
+
+	var created domain.Resource
+	query := `
+        INSERT INTO resources (name, type, region, provider, account_id, native_id, tags, attributes, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+        RETURNING ` + resourceColumns + `
+    `
+	if err := scanResource(tx.QueryRowContext(ctx, query, name, res.Type, res.Region, res.Provider, res.AccountID,
+		res.NativeID, tags, attributes), &created); err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, auditEntry{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityResource,
+		EntityID:   created.ID,
+		After:      &created,
+	})
+}
+
+// importName returns the first free name among the resource's own name, its
+// native ID and the native ID with a numeric suffix, each cut to fit.
+func importName(ctx context.Context, tx *sql.Tx, res *domain.Resource) (string, error) {
+	name := res.Name
+	for n := 1; ; n++ {
+		var taken bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM resources WHERE name = $1)`, name).Scan(&taken); err != nil {
+			return "", err
+		}
+		if !taken {
+			return name, nil
+		}
+		suffix := ""
+		if n > 1 {
+			suffix = fmt.Sprintf("-%d", n)
+		}
+		name = domain.NameFromNativeID(res.NativeID, domain.MaxResourceNameLength-len(suffix)) + suffix
+	}
+}
+
+// sameJSON reports whether a and b encode the same value, ignoring
+// formatting and key order.
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
 }
@@ -0,0 +1,77 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+)
+
+func importedResource(name, nativeID string) domain.Resource {
+	return domain.Resource{Name: name, Type: "AWS::EC2::VPC", Region: "us-east-1", Provider: domain.ProviderAWS,
+		AccountID: "123456789012", NativeID: nativeID, Tags: map[string]string{}, Attributes: json.RawMessage(`{}`)}
+}
+
+func TestImport_IntegrationTest_NameCollisions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	db := setUpTestDB(t)
+	repo := repository.NewResourceRepository(db, testTimeouts)
+
+	longID := "arn:aws:ec2:us-east-1:123456789012:vpc/" + strings.Repeat("x", 300)
+	insertResource(t, db, domain.Resource{Name: "main", Type: "VPC", Region: "us-east-1"})
+	insertResource(t, db, domain.Resource{Name: domain.NameFromNativeID(longID, domain.MaxResourceNameLength), Type: "VPC", Region: "us-east-1"})
+
+	summary, err := repo.Import(context.Background(), []domain.Resource{importedResource("main", longID)})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportSummary{Created: 1}, summary)
+
+	// Both the name and the native ID are taken, so a suffix is added
+	var name string
+	require.NoError(t, db.QueryRow(`SELECT name FROM resources WHERE native_id = $1`, longID).Scan(&name))
+	assert.Len(t, name, domain.MaxResourceNameLength)
+	assert.True(t, strings.HasSuffix(name, "x-2"))
+}
+
+func TestImport_IntegrationTest_Batches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	db := setUpTestDB(t)
+	repo := repository.NewResourceRepository(db, testTimeouts)
+
+	// More than one batch
+	resources := make([]domain.Resource, 1201)
+	for i := range resources {
+		id := fmt.Sprintf("vpc-%04d", i)
+		resources[i] = importedResource(id, "arn:aws:ec2:us-east-1:123456789012:vpc/"+id)
+	}
+	deleted := insertResource(t, db, importedResource("old", resources[0].NativeID))
+	_, err := db.Exec(`UPDATE resources SET deleted_at = NOW() WHERE id = $1`, deleted.ID)
+	require.NoError(t, err)
+
+	summary, err := repo.Import(context.Background(), resources)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportSummary{Created: 1200, Skipped: 1}, summary)
+	assert.Equal(t, 1200, count(t, db, `SELECT COUNT(*) FROM audit_events WHERE action = $1`, domain.AuditActionCreate))
+
+	// Importing again changes only what differs
+	resources[1].Region = "us-east-2"
+	resources[1].Tags = map[string]string{"env": "prod"}
+	summary, err = repo.Import(context.Background(), resources)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportSummary{Updated: 1, Unchanged: 1199, Skipped: 1}, summary)
+
+	res, err := repo.GetByName(context.Background(), "vpc-0001")
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-2", res.Region)
+	assert.Equal(t, map[string]string{"env": "prod"}, res.Tags)
+}
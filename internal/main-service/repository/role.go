@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+)
+
+type RoleRepository interface {
+	auth.RoleStore
+	Grant(ctx context.Context, binding *domain.RoleBinding) error
+	// Revoke reports whether the binding existed.
+	Revoke(ctx context.Context, binding domain.RoleBinding) (bool, error)
+	List(ctx context.Context) ([]domain.RoleBinding, error)
+}
+
+type roleRepo struct {
+	db       *sql.DB
+	timeouts db.Timeouts
+}
+
+func NewRoleRepository(conn *sql.DB, timeouts db.Timeouts) RoleRepository {
+	return tracedRoleRepo{next: &roleRepo{db: conn, timeouts: timeouts}}
+}
+
+func (r *roleRepo) RolesFor(ctx context.Context, kind auth.Kind, subject string) ([]auth.Role, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	query := `SELECT role FROM role_bindings WHERE principal_kind = $1 AND subject = $2`
+	rows, err := r.db.QueryContext(ctx, query, string(kind), subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []auth.Role
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, auth.Role(role))
+	}
+	return roles, rows.Err()
+}
+
+// Grant is idempotent; granting a role twice keeps the original binding.
+func (r *roleRepo) Grant(ctx context.Context, b *domain.RoleBinding) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	query := `
+        INSERT INTO role_bindings (principal_kind, subject, role)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (principal_kind, subject, role) DO UPDATE SET role = EXCLUDED.role
+        RETURNING created_at
+    `
+	return r.db.QueryRowContext(ctx, query, b.PrincipalKind, b.Subject, b.Role).Scan(&b.CreatedAt)
+}
+
+func (r *roleRepo) Revoke(ctx context.Context, b domain.RoleBinding) (bool, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	query := `DELETE FROM role_bindings WHERE principal_kind = $1 AND subject = $2 AND role = $3`
+	res, err := r.db.ExecContext(ctx, query, b.PrincipalKind, b.Subject, b.Role)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *roleRepo) List(ctx context.Context) ([]domain.RoleBinding, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	query := `SELECT principal_kind, subject, role, created_at FROM role_bindings ORDER BY principal_kind, subject, role`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bindings []domain.RoleBinding
+	for rows.Next() {
+		var b domain.RoleBinding
+		if err := rows.Scan(&b.PrincipalKind, &b.Subject, &b.Role, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings, rows.Err()
+}
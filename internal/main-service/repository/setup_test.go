@@ -0,0 +1,111 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/cmd"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	dbpkg "github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+)
+
+// testTimeouts disables per-operation deadlines for the integration tests.
+var testTimeouts = dbpkg.Timeouts{}
+
+// setUpTestDB starts Postgres in a container and applies the migrations
+// built into the binary.
+func setUpTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	postgresContainer, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("testuser"),
+		postgres.WithPassword("testpassword"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(10*time.Second)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, postgresContainer.Terminate(ctx))
+	})
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432/tcp")
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("host=%s port=%s user=testuser password=testpassword dbname=testdb sslmode=disable", host, port.Port())
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	provider, err := dbpkg.NewMigrator(db, cmd.Migrations())
+	require.NoError(t, err)
+	_, err = provider.Up(ctx)
+	require.NoError(t, err)
+
+	return db
+}
+
+func insertCustomer(t *testing.T, db *sql.DB, name, email string) domain.Customer {
+	t.Helper()
+	customer := domain.Customer{Name: name, Email: email}
+	err := db.QueryRow(`INSERT INTO customers (name, email) VALUES ($1, $2) RETURNING id`, name, email).Scan(&customer.ID)
+	require.NoError(t, err)
+	return customer
+}
+
+func insertResource(t *testing.T, db *sql.DB, res domain.Resource) domain.Resource {
+	t.Helper()
+	query := `
+		INSERT INTO resources (name, type, region, provider, account_id, native_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	err := db.QueryRow(query, res.Name, res.Type, res.Region, res.Provider, res.AccountID, res.NativeID).Scan(&res.ID)
+	require.NoError(t, err)
+	return res
+}
+
+func assign(t *testing.T, db *sql.DB, customerID, resourceID int64) {
+	t.Helper()
+	_, err := db.Exec(`INSERT INTO customer_resource (customer_id, resource_id) VALUES ($1, $2)`, customerID, resourceID)
+	require.NoError(t, err)
+}
+
+func count(t *testing.T, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	require.NoError(t, db.QueryRow(query, args...).Scan(&n))
+	return n
+}
+
+// auditActions returns the actions recorded for an entity, oldest first.
+func auditActions(t *testing.T, db *sql.DB, entityType string, entityID int64) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT action FROM audit_events WHERE entity_type = $1 AND entity_id = $2 ORDER BY id`, entityType, entityID)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var actions []string
+	for rows.Next() {
+		var action string
+		require.NoError(t, rows.Scan(&action))
+		actions = append(actions, action)
+	}
+	require.NoError(t, rows.Err())
+	return actions
+}
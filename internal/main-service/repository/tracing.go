@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
+)
+
+// The traced* decorators wrap every repository call in a span, so that the
+// database time shows up under the request or relay that caused it.
+
+type tracedCustomerRepo struct {
+	next CustomerRepository
+}
+
+func (r tracedCustomerRepo) Create(ctx context.Context, customer *domain.Customer) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "customers.Create")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Create(ctx, customer)
+}
+
+func (r tracedCustomerRepo) GetByID(ctx context.Context, id int64) (_ *domain.Customer, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "customers.GetByID")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r tracedCustomerRepo) GetByEmail(ctx context.Context, email string) (_ *domain.Customer, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "customers.GetByEmail")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetByEmail(ctx, email)
+}
+
+func (r tracedCustomerRepo) List(ctx context.Context, filter domain.CustomerFilter) (_ []domain.Customer, _ int64, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "customers.List")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.List(ctx, filter)
+}
+
+func (r tracedCustomerRepo) Update(ctx context.Context, customer *domain.Customer) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "customers.Update")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Update(ctx, customer)
+}
+
+func (r tracedCustomerRepo) Delete(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "customers.Delete")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Delete(ctx, id)
+}
+
+func (r tracedCustomerRepo) Purge(ctx context.Context, deletedBefore time.Time) (_ int64, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "customers.Purge")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Purge(ctx, deletedBefore)
+}
+
+type tracedResourceRepo struct {
+	next ResourceRepository
+}
+
+func (r tracedResourceRepo) GetAll(ctx context.Context, filter domain.ResourceFilter) (_ []domain.Resource, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.GetAll")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetAll(ctx, filter)
+}
+
+func (r tracedResourceRepo) AddResourcesToCustomer(ctx context.Context, resourceNames []string, customerID int64) (_ []domain.ResourceAssignment, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.AddResourcesToCustomer")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.AddResourcesToCustomer(ctx, resourceNames, customerID)
+}
+
+func (r tracedResourceRepo) GetResourcesByCustomer(ctx context.Context, customerID int64, filter domain.ResourceFilter) (_ []domain.Resource, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.GetResourcesByCustomer")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetResourcesByCustomer(ctx, customerID, filter)
+}
+
+func (r tracedResourceRepo) GetByID(ctx context.Context, resourceID int64) (_ *domain.Resource, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.GetByID")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetByID(ctx, resourceID)
+}
+
+func (r tracedResourceRepo) Update(ctx context.Context, resource *domain.Resource) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.Update")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Update(ctx, resource)
+}
+
+func (r tracedResourceRepo) Delete(ctx context.Context, resourceID int64) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.Delete")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Delete(ctx, resourceID)
+}
+
+func (r tracedResourceRepo) Restore(ctx context.Context, resourceID int64) (_ *domain.Resource, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.Restore")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Restore(ctx, resourceID)
+}
+
+func (r tracedResourceRepo) Purge(ctx context.Context, deletedBefore time.Time) (_ int64, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.Purge")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Purge(ctx, deletedBefore)
+}
+
+func (r tracedResourceRepo) Import(ctx context.Context, resources []domain.Resource) (_ domain.ImportSummary, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.Import")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Import(ctx, resources)
+}
+
+func (r tracedResourceRepo) GetByName(ctx context.Context, name string) (_ *domain.Resource, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.GetByName")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetByName(ctx, name)
+}
+
+func (r tracedResourceRepo) AddResourceToCustomer(ctx context.Context, resourceName string, customerID int64) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.AddResourceToCustomer")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.AddResourceToCustomer(ctx, resourceName, customerID)
+}
+
+func (r tracedResourceRepo) GetCustomerResourceByResourceName(ctx context.Context, customerID int64, resourceName string) (_ *domain.Resource, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.GetCustomerResourceByResourceName")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetCustomerResourceByResourceName(ctx, customerID, resourceName)
+}
+
+func (r tracedResourceRepo) DoesCustomerHaveResource(ctx context.Context, customerID int64, resourceName string) (_ bool, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.DoesCustomerHaveResource")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.DoesCustomerHaveResource(ctx, customerID, resourceName)
+}
+
+func (r tracedResourceRepo) RemoveResourceFromCustomer(ctx context.Context, customerID, resourceID int64) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.RemoveResourceFromCustomer")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.RemoveResourceFromCustomer(ctx, customerID, resourceID)
+}
+
+func (r tracedResourceRepo) GetHistory(ctx context.Context, resourceID int64) (_ []domain.ResourceVersion, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "resources.GetHistory")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetHistory(ctx, resourceID)
+}
+
+type tracedOutboxRepo struct {
+	next OutboxRepository
+}
+
+func (r tracedOutboxRepo) ClaimPending(ctx context.Context, limit int, lease time.Duration) (_ []domain.OutboxMessage, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "outbox.ClaimPending")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.ClaimPending(ctx, limit, lease)
+}
+
+func (r tracedOutboxRepo) MarkSent(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "outbox.MarkSent")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.MarkSent(ctx, id)
+}
+
+func (r tracedOutboxRepo) MarkFailed(ctx context.Context, id int64, publishErr error, retryAt time.Time) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "outbox.MarkFailed")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.MarkFailed(ctx, id, publishErr, retryAt)
+}
+
+type tracedRoleRepo struct {
+	next RoleRepository
+}
+
+func (r tracedRoleRepo) RolesFor(ctx context.Context, kind auth.Kind, subject string) (_ []auth.Role, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "role_bindings.RolesFor")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.RolesFor(ctx, kind, subject)
+}
+
+func (r tracedRoleRepo) Grant(ctx context.Context, binding *domain.RoleBinding) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "role_bindings.Grant")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Grant(ctx, binding)
+}
+
+func (r tracedRoleRepo) Revoke(ctx context.Context, binding domain.RoleBinding) (_ bool, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "role_bindings.Revoke")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Revoke(ctx, binding)
+}
+
+func (r tracedRoleRepo) List(ctx context.Context) (_ []domain.RoleBinding, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "role_bindings.List")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.List(ctx)
+}
+
+type tracedAuditRepo struct {
+	next AuditRepository
+}
+
+func (r tracedAuditRepo) List(ctx context.Context, filter domain.AuditFilter) (_ []domain.AuditEvent, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "audit_events.List")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.List(ctx, filter)
+}
+
+type tracedCatalogRepo struct {
+	next CatalogRepository
+}
+
+func (r tracedCatalogRepo) Seed(ctx context.Context, catalog *domain.Catalog, opts domain.SeedOptions) (_ domain.SeedSummary, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "catalog.Seed")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Seed(ctx, catalog, opts)
+}
+
+type tracedInventoryRepo struct {
+	next InventoryRepository
+}
+
+func (r tracedInventoryRepo) List(ctx context.Context, filter domain.InventoryFilter) (_ []domain.InventoryRow, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "inventory.List")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.List(ctx, filter)
+}
@@ -1,119 +1,95 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"log"
+	"log/slog"
 
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/metrics"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/rabbitmq"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type Notifier interface {
-	Publish(message domain.Notification) error
-	Listen() error
+	// Publish queues message for delivery without waiting for the broker.
+	// done, if not nil, is called with nil once the broker confirmed the
+	// message, or with the error that made delivery fail.
+	Publish(ctx context.Context, message domain.Notification, done func(error)) error
 	Close()
 }
 
 type RabbitMQNotifier struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	queue   amqp.Queue
+	conn      *rabbitmq.Connection
+	publisher *rabbitmq.Publisher
+	logger    *slog.Logger
 }
 
-func NewRabbitMQNotifier(amqpURL string) (*RabbitMQNotifier, error) {
-	conn, err := amqp.Dial(amqpURL)
-	if err != nil {
-		return nil, fmt.Errorf("error dailing RabbitMQ server: %w", err)
-	}
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, fmt.Errorf("error opening rabbitmq channel: %w", err)
-	}
-	q, err := ch.QueueDeclare(
-		"notifications",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("error declaring queue: %w", err)
-	}
-
+func NewRabbitMQNotifier(conn *rabbitmq.Connection, cfg config.RabbitMQConfig, logger *slog.Logger) *RabbitMQNotifier {
 	return &RabbitMQNotifier{
-		conn:    conn,
-		channel: ch,
-		queue:   q,
-	}, nil
+		conn:      conn,
+		publisher: rabbitmq.NewPublisher(conn, cfg, logger),
+		logger:    logger,
+	}
 }
 
-func (n *RabbitMQNotifier) Publish(payload domain.Notification) error {
+func (n *RabbitMQNotifier) Publish(ctx context.Context, payload domain.Notification, done func(error)) error {
 	body, err := json.Marshal(&payload)
 	if err != nil {
-		log.Println(fmt.Errorf("error marshalling payload: %v", err))
+		n.logger.ErrorContext(ctx, "error marshalling notification", "error", err)
 		return err
 	}
 
-	return n.channel.Publish(
-		"",
-		n.queue.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        body,
-		},
-	)
-}
+	headers := amqp.Table{}
+	logging.InjectAMQP(ctx, headers)
+	span := tracing.StartPublishSpan(ctx, rabbitmq.NotificationsQueue, headers)
 
-func (n *RabbitMQNotifier) Listen() error {
-	msgs, err := n.channel.Consume(
-		n.queue.Name,
-		"",
-		true,  // auto-ack
-		false, // exclusive
-		false, // noLocal
-		false, // noWait
-		nil,
-	)
+	err = n.publisher.Publish("", rabbitmq.NotificationsQueue, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  "text/plain",
+		DeliveryMode: amqp.Persistent,
+		Body:         body,
+	}, func(err error) {
+		tracing.EndSpan(span, err)
+		countPublished(err)
+		if done != nil {
+			done(err)
+		}
+	})
 	if err != nil {
-		return err
+		tracing.EndSpan(span, err)
+		countPublished(err)
 	}
+	return err
+}
 
-	forever := make(chan bool)
-
-	go func() {
-		for d := range msgs {
-			log.Printf("[NotificationService] Received: %s", d.Body)
-			var payload domain.Notification
-			err := json.Unmarshal(d.Body, &payload)
-
-			if err != nil {
-				log.Printf("Failed to decode message: %s", err)
-				continue
-			}
-			// if payload.Event == "notification" && payload.UserID != 0 && payload.Message != "" {
-			//     log.Println("notification payload: ", payload)
-			//     if err := n.notificationRepo.Create(&payload); err != nil {
-			//         log.Println("error creating notification: ", err)
-			//     }
-			// }
+func countPublished(err error) {
+	if err != nil {
+		metrics.NotificationsFailed.WithLabelValues(metrics.StagePublish).Inc()
+		return
+	}
+	metrics.NotificationsPublished.Inc()
+}
 
-		}
-	}()
+// PublishStats reports the delivery counters of the background publisher.
+func (n *RabbitMQNotifier) PublishStats() rabbitmq.PublisherStats {
+	return n.publisher.Stats()
+}
 
-	fmt.Println("[NotificationService] Listening for messages... Press CTRL+C to exit.")
-	<-forever
-	return nil
+// Shutdown waits, at most until ctx ends, for buffered messages to be
+// confirmed and then closes the connection.
+func (n *RabbitMQNotifier) Shutdown(ctx context.Context) error {
+	err := n.publisher.Shutdown(ctx)
+	n.conn.Close()
+	return err
 }
 
+// Close waits for buffered notifications to be confirmed before closing the
+// connection.
 func (n *RabbitMQNotifier) Close() {
-	if n.channel != nil {
-		_ = n.channel.Close()
-	}
-	if n.conn != nil {
-		_ = n.conn.Close()
-	}
+	n.publisher.Close()
+	n.conn.Close()
 }
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
+)
+
+// OutboxRelay publishes the notifications that the repositories queue in the
+// outbox table. A message is only marked as sent once the broker confirmed
+// it; failures are retried with exponential backoff.
+type OutboxRelay struct {
+	repo     repository.OutboxRepository
+	notifier Notifier
+	cfg      config.OutboxConfig
+	logger   *slog.Logger
+}
+
+func NewOutboxRelay(repo repository.OutboxRepository, notifier Notifier, cfg config.OutboxConfig, logger *slog.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		repo:     repo,
+		notifier: notifier,
+		cfg:      cfg,
+		logger:   logger.With("component", "outbox_relay"),
+	}
+}
+
+// Run relays pending messages every poll interval until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while full batches come back
+		for {
+			n, err := r.Flush(ctx)
+			if err != nil {
+				r.logger.Error("error relaying messages", "error", err)
+				break
+			}
+			if n < r.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush claims one batch of due messages and hands them to the notifier. It
+// returns the number of messages claimed. Each message is settled
+// asynchronously once the notifier reports the delivery result; until then
+// the lease keeps other relays from claiming it.
+func (r *OutboxRelay) Flush(ctx context.Context) (int, error) {
+	msgs, err := r.repo.ClaimPending(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, msg := range msgs {
+		// Continue the trace and keep the request ID of the request that
+		// queued the message
+		ctx := logging.WithRequestID(tracing.Extract(ctx, msg.TraceContext), msg.RequestID)
+		if err := r.notifier.Publish(ctx, msg.Notification, func(err error) { r.settle(ctx, msg, err) }); err != nil {
+			r.settle(ctx, msg, err)
+		}
+	}
+	return len(msgs), nil
+}
+
+// settle records the delivery result of msg. msgCtx only provides the
+// correlation IDs; the flush that claimed the message may be long gone.
+func (r *OutboxRelay) settle(msgCtx context.Context, msg domain.OutboxMessage, publishErr error) {
+	ctx := context.WithoutCancel(msgCtx)
+
+	if publishErr != nil {
+		retryAt := time.Now().Add(r.backoff(msg.Attempts))
+		r.logger.WarnContext(ctx, "publishing message failed, retrying",
+			"message_id", msg.ID, "attempt", msg.Attempts, "retry_at", retryAt, "error", publishErr)
+		if err := r.repo.MarkFailed(ctx, msg.ID, publishErr, retryAt); err != nil {
+			r.logger.ErrorContext(ctx, "error recording message failure", "message_id", msg.ID, "error", err)
+		}
+		return
+	}
+	if err := r.repo.MarkSent(ctx, msg.ID); err != nil {
+		// The lease expires and the message is published again
+		r.logger.ErrorContext(ctx, "error marking message as sent", "message_id", msg.ID, "error", err)
+	}
+}
+
+// backoff doubles the delay from one second for every attempt, capped at
+// MaxBackoff.
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if r.cfg.MaxBackoff > 0 && delay > r.cfg.MaxBackoff {
+		delay = r.cfg.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/service"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+type mockOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *mockOutboxRepo) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	args := m.Called(limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.OutboxMessage), args.Error(1)
+}
+
+func (m *mockOutboxRepo) MarkSent(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) MarkFailed(ctx context.Context, id int64, publishErr error, retryAt time.Time) error {
+	args := m.Called(id, publishErr, retryAt)
+	return args.Error(0)
+}
+
+type mockNotifier struct {
+	mock.Mock
+}
+
+// Publish returns the first error from Publish and, when that is nil, reports
+// the second one as the delivery result.
+func (m *mockNotifier) Publish(ctx context.Context, message domain.Notification, done func(error)) error {
+	args := m.Called(message)
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+	if done != nil {
+		done(args.Error(1))
+	}
+	return nil
+}
+func (m *mockNotifier) Close() {
+	m.Called()
+}
+
+var testOutboxConfig = config.OutboxConfig{
+	PollInterval: time.Second,
+	BatchSize:    10,
+	Lease:        30 * time.Second,
+	MaxBackoff:   time.Minute,
+}
+
+func TestOutboxRelay_Flush_Sent(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	notifier := new(mockNotifier)
+	relay := service.NewOutboxRelay(repo, notifier, testOutboxConfig, logging.Discard())
+
+	n := domain.ResourceAddedNotification(1, "aws_vpc_main")
+	repo.On("ClaimPending", 10, 30*time.Second).Return([]domain.OutboxMessage{{ID: 7, Notification: n, Attempts: 1}}, nil)
+	notifier.On("Publish", n).Return(nil, nil)
+	repo.On("MarkSent", int64(7)).Return(nil)
+
+	count, err := relay.Flush(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	repo.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestOutboxRelay_Flush_NotConfirmed(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	notifier := new(mockNotifier)
+	relay := service.NewOutboxRelay(repo, notifier, testOutboxConfig, logging.Discard())
+
+	n := domain.CustomerDeletedNotification(1)
+	publishErr := errors.New("broker nacked the message")
+	repo.On("ClaimPending", 10, 30*time.Second).Return([]domain.OutboxMessage{{ID: 7, Notification: n, Attempts: 3}}, nil)
+	notifier.On("Publish", n).Return(nil, publishErr)
+
+	before := time.Now()
+	repo.On("MarkFailed", int64(7), publishErr, mock.MatchedBy(func(retryAt time.Time) bool {
+		// Third attempt waits four seconds
+		delay := retryAt.Sub(before)
+		return delay >= 4*time.Second && delay < 5*time.Second
+	})).Return(nil)
+
+	count, err := relay.Flush(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "MarkSent", mock.Anything)
+	notifier.AssertExpectations(t)
+}
+
+func TestOutboxRelay_Flush_BackoffCapped(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	notifier := new(mockNotifier)
+	relay := service.NewOutboxRelay(repo, notifier, testOutboxConfig, logging.Discard())
+
+	n := domain.CustomerDeletedNotification(1)
+	publishErr := errors.New("rabbitmq publish buffer full")
+	repo.On("ClaimPending", 10, 30*time.Second).Return([]domain.OutboxMessage{{ID: 7, Notification: n, Attempts: 40}}, nil)
+	notifier.On("Publish", n).Return(publishErr)
+
+	before := time.Now()
+	repo.On("MarkFailed", int64(7), publishErr, mock.MatchedBy(func(retryAt time.Time) bool {
+		delay := retryAt.Sub(before)
+		return delay >= time.Minute && delay < time.Minute+time.Second
+	})).Return(nil)
+
+	_, err := relay.Flush(context.Background())
+	assert.NoError(t, err)
+
+	repo.AssertExpectations(t)
+}
+
+func TestOutboxRelay_Flush_ClaimError(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	notifier := new(mockNotifier)
+	relay := service.NewOutboxRelay(repo, notifier, testOutboxConfig, logging.Discard())
+
+	repo.On("ClaimPending", 10, 30*time.Second).Return(nil, errors.New("db down"))
+
+	count, err := relay.Flush(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 0, count)
+
+	notifier.AssertNotCalled(t, "Publish", mock.Anything)
+}
@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+)
+
+// Audit listing formats.
+const (
+	auditFormatJSON  = "json"
+	auditFormatJSONL = "jsonl"
+)
+
+type AuditHandler struct {
+	auditUC usecase.AuditUsecase
+	logger  *slog.Logger
+}
+
+func NewAuditHandler(auditUC usecase.AuditUsecase, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditUC: auditUC,
+		logger:  logger,
+	}
+}
+
+// GET /audit?actor=&action=&entity_type=&entity_id=&customer_id=&since=&until=&limit=&after=&format=
+//
+// format=jsonl streams every matching event as JSON Lines instead of
+// returning one page.
+func (h *AuditHandler) ListAuditEvents(c *gin.Context) {
+	var req struct {
+		Actor      string `form:"actor"`
+		Action     string `form:"action"`
+		EntityType string `form:"entity_type"`
+		EntityID   int64  `form:"entity_id" binding:"omitempty,min=1"`
+		CustomerID int64  `form:"customer_id" binding:"omitempty,min=1"`
+		Since      string `form:"since"`
+		Until      string `form:"until"`
+		Limit      int    `form:"limit" binding:"omitempty,min=1"`
+		After      string `form:"after"`
+		Format     string `form:"format"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := domain.AuditQuery{
+		Actor:      req.Actor,
+		Action:     req.Action,
+		EntityType: req.EntityType,
+		EntityID:   req.EntityID,
+		CustomerID: req.CustomerID,
+		Limit:      req.Limit,
+		After:      req.After,
+	}
+	var err error
+	if query.Since, err = parseTimeParam(req.Since); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC 3339 timestamp"})
+		return
+	}
+	if query.Until, err = parseTimeParam(req.Until); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "until must be an RFC 3339 timestamp"})
+		return
+	}
+
+	switch req.Format {
+	case "", auditFormatJSON:
+		h.listPage(c, query)
+	case auditFormatJSONL:
+		h.export(c, query)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or jsonl"})
+	}
+}
+
+func (h *AuditHandler) listPage(c *gin.Context, query domain.AuditQuery) {
+	page, err := h.auditUC.ListEvents(c.Request.Context(), query)
+	if err != nil {
+		if isAuditQueryError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	events := page.Events
+	if events == nil {
+		events = []domain.AuditEvent{}
+	}
+	var next interface{}
+	if page.NextCursor != "" {
+		next = page.NextCursor
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        events,
+		"next_cursor": next,
+	})
+}
+
+// export streams events as they are read. Once the first line is written the
+// status can no longer change, so later errors cut the connection, for the
+// client to see that the download is incomplete.
+func (h *AuditHandler) export(c *gin.Context, query domain.AuditQuery) {
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+
+	enc := json.NewEncoder(c.Writer)
+	started := false
+	err := h.auditUC.ExportEvents(c.Request.Context(), query, func(e domain.AuditEvent) error {
+		started = true
+		if err := enc.Encode(&e); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	switch {
+	case err == nil:
+		if !started {
+			c.Status(http.StatusOK)
+		}
+	case started:
+		h.logger.WarnContext(c.Request.Context(), "audit export aborted", "error", err)
+		panic(http.ErrAbortHandler)
+	case isAuditQueryError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
+
+func isAuditQueryError(err error) bool {
+	return errors.Is(err, usecase.ErrInvalidAuditFilter) || errors.Is(err, usecase.ErrInvalidCursor)
+}
+
+// parseTimeParam parses an optional RFC 3339 query parameter.
+func parseTimeParam(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
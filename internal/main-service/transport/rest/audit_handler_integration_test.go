@@ -0,0 +1,94 @@
+package rest_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/transport/rest"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+func TestAuditHandler_IntegrationTest_RecordsResourceUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	db, err := setUpTestDB(t, "testdb", "testuser", "testpassword")
+	defer db.Close()
+	assert.NoError(t, err)
+
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	auditUC := usecase.NewAuditUsecase(repository.NewAuditRepository(db, testTimeouts), logging.Discard())
+	resourceHandler := rest.NewResourceHandler(resourceUC, logging.Discard())
+	auditHandler := rest.NewAuditHandler(auditUC, logging.Discard())
+
+	resource := seedResource1(t, db)
+
+	r := gin.Default()
+	r.Use(logging.RequestIDMiddleware(), func(c *gin.Context) {
+		p := auth.Principal{Kind: auth.KindUser, Subject: "alice", CustomerID: 1}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+	})
+	r.PUT("/resources/:id", resourceHandler.UpdateResource)
+	r.GET("/audit", auditHandler.ListAuditEvents)
+
+	body, _ := json.Marshal(map[string]string{"name": "renamed", "type": "VPC", "region": "us-east-1"})
+	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/resources/%d", resource.ID), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(logging.RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/audit?entity_type=resource&entity_id=%d", resource.ID), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []domain.AuditEvent `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 1)
+	event := response.Data[0]
+	assert.Equal(t, "alice", event.Actor)
+	assert.Equal(t, domain.AuditActionUpdate, event.Action)
+	assert.Equal(t, "req-123", event.RequestID)
+
+	var before, after domain.Resource
+	require.NoError(t, json.Unmarshal(event.Before, &before))
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	assert.Equal(t, "aws_vpc_main", before.Name)
+	assert.Equal(t, "renamed", after.Name)
+
+	// The same event as JSON Lines
+	req, _ = http.NewRequest(http.MethodGet, "/audit?format=jsonl&actor=alice", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	lines := 0
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var e domain.AuditEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		lines++
+	}
+	assert.Equal(t, 1, lines)
+}
@@ -0,0 +1,140 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/transport/rest"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+// Mock AuditUsecase
+type mockAuditUsecase struct {
+	mock.Mock
+}
+
+func (m *mockAuditUsecase) ListEvents(ctx context.Context, query domain.AuditQuery) (*domain.AuditPage, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AuditPage), args.Error(1)
+}
+
+func (m *mockAuditUsecase) ExportEvents(ctx context.Context, query domain.AuditQuery, fn func(domain.AuditEvent) error) error {
+	args := m.Called(query)
+	if events, ok := args.Get(0).([]domain.AuditEvent); ok {
+		for _, e := range events {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func newAuditRouter(uc usecase.AuditUsecase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(rest.Recovery())
+	r.GET("/audit", rest.NewAuditHandler(uc, logging.Discard()).ListAuditEvents)
+	return r
+}
+
+func TestListAuditEventsHandler_OK(t *testing.T) {
+	mockUC := new(mockAuditUsecase)
+	r := newAuditRouter(mockUC)
+
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mockUC.On("ListEvents", domain.AuditQuery{Action: "delete", EntityType: "resource", Since: since, Limit: 10}).
+		Return(&domain.AuditPage{Events: []domain.AuditEvent{{ID: 4, Action: "delete"}}, NextCursor: "4"}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/audit?action=delete&entity_type=resource&since=2024-05-01T00:00:00Z&limit=10", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp["data"], 1)
+	assert.Equal(t, "4", resp["next_cursor"])
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestListAuditEventsHandler_BadRequest(t *testing.T) {
+	mockUC := new(mockAuditUsecase)
+	r := newAuditRouter(mockUC)
+
+	mockUC.On("ListEvents", domain.AuditQuery{Action: "rename"}).Return(nil, usecase.ErrInvalidAuditFilter)
+
+	for _, url := range []string{
+		"/audit?since=yesterday",
+		"/audit?format=xml",
+		"/audit?entity_id=abc",
+		"/audit?action=rename",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestListAuditEventsHandler_JSONLines(t *testing.T) {
+	mockUC := new(mockAuditUsecase)
+	r := newAuditRouter(mockUC)
+
+	mockUC.On("ExportEvents", domain.AuditQuery{CustomerID: 3}).
+		Return([]domain.AuditEvent{{ID: 2, Action: "assign"}, {ID: 1, Action: "create"}}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/audit?customer_id=3&format=jsonl", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	var first domain.AuditEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, int64(2), first.ID)
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestListAuditEventsHandler_JSONLinesFailure(t *testing.T) {
+	mockUC := new(mockAuditUsecase)
+	srv := httptest.NewServer(newAuditRouter(mockUC))
+	defer srv.Close()
+
+	mockUC.On("ExportEvents", domain.AuditQuery{CustomerID: 3}).
+		Return([]domain.AuditEvent{{ID: 2, Action: "assign"}}, errors.New("connection reset"))
+
+	resp, err := http.Get(srv.URL + "/audit?customer_id=3&format=jsonl")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The connection is cut, so the body does not end cleanly
+	_, err = io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	mockUC.AssertExpectations(t)
+}
@@ -1,19 +1,26 @@
 package rest
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
 )
 
 type CustomerHandler struct {
 	customerUC usecase.CustomerUsecase
+	logger     *slog.Logger
 }
 
-func NewCustomerHandler(customerUC usecase.CustomerUsecase) *CustomerHandler {
-	return &CustomerHandler{customerUC: customerUC}
+func NewCustomerHandler(customerUC usecase.CustomerUsecase, logger *slog.Logger) *CustomerHandler {
+	return &CustomerHandler{
+		customerUC: customerUC,
+		logger:     logger,
+	}
 }
 
 func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
@@ -26,23 +33,18 @@ func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 		return
 	}
 
-	customer, err := h.customerUC.CreateCustomer(req.Name, req.Email)
+	customer, err := h.customerUC.CreateCustomer(c.Request.Context(), req.Name, req.Email)
 	if err != nil {
-		if err.Error() == "internal server error" {
+		if errors.Is(err, usecase.ErrInternal) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.logger.InfoContext(c.Request.Context(), "customer rejected", "email", req.Email, "reason", err.Error())
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"data": map[string]interface{}{
-			"id":    customer.ID,
-			"name":  customer.Name,
-			"email": customer.Email,
-		},
-	})
+	c.JSON(http.StatusCreated, gin.H{"data": customerResponse(customer)})
 }
 
 func (h *CustomerHandler) GetCustomerByID(c *gin.Context) {
@@ -53,16 +55,143 @@ func (h *CustomerHandler) GetCustomerByID(c *gin.Context) {
 		return
 	}
 
-	customer, err := h.customerUC.GetCustomerByID(id)
+	customer, err := h.customerUC.GetCustomerByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "customer not found"})
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{"data": customerResponse(customer)})
+}
+
+// GET /customers?name=&email=&limit=&offset=
+func (h *CustomerHandler) ListCustomers(c *gin.Context) {
+	var query struct {
+		Name   string `form:"name"`
+		Email  string `form:"email"`
+		Limit  int    `form:"limit" binding:"omitempty,min=1"`
+		Offset int    `form:"offset" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := domain.CustomerFilter{
+		Name:   query.Name,
+		Email:  query.Email,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	page, err := h.customerUC.ListCustomers(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := make([]map[string]interface{}, 0, len(page.Customers))
+	for i := range page.Customers {
+		data = append(data, customerResponse(&page.Customers[i]))
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"data": map[string]interface{}{
-			"id":    customer.ID,
-			"name":  customer.Name,
-			"email": customer.Email,
-		}})
+		"data": data,
+		"meta": map[string]interface{}{
+			"total":  page.Total,
+			"limit":  page.Limit,
+			"offset": page.Offset,
+		},
+	})
+}
+
+// PUT /customers/:id
+func (h *CustomerHandler) ReplaceCustomer(c *gin.Context) {
+	var req struct {
+		Name  string `json:"name" binding:"required"`
+		Email string `json:"email" binding:"required,email"`
+	}
+	id, ok := parseCustomerID(c)
+	if !ok {
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.updateCustomer(c, id, domain.CustomerUpdate{Name: &req.Name, Email: &req.Email})
+}
+
+// PATCH /customers/:id
+func (h *CustomerHandler) PatchCustomer(c *gin.Context) {
+	var req struct {
+		Name  *string `json:"name"`
+		Email *string `json:"email" binding:"omitempty,email"`
+	}
+	id, ok := parseCustomerID(c)
+	if !ok {
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == nil && req.Email == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
+
+	h.updateCustomer(c, id, domain.CustomerUpdate{Name: req.Name, Email: req.Email})
+}
+
+func (h *CustomerHandler) updateCustomer(c *gin.Context, id int64, update domain.CustomerUpdate) {
+	customer, err := h.customerUC.UpdateCustomer(c.Request.Context(), id, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrCustomerNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, usecase.ErrInternal):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": customerResponse(customer)})
+}
+
+// DELETE /customers/:id
+func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
+	id, ok := parseCustomerID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.customerUC.DeleteCustomer(c.Request.Context(), id); err != nil {
+		if errors.Is(err, usecase.ErrCustomerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Customer deleted successfully"})
+}
+
+func parseCustomerID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func customerResponse(customer *domain.Customer) map[string]interface{} {
+	return map[string]interface{}{
+		"id":    customer.ID,
+		"name":  customer.Name,
+		"email": customer.Email,
+	}
 }
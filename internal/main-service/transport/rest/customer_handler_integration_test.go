@@ -14,6 +14,7 @@ import (
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/transport/rest"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
 )
 
 func TestCreateCustomerHandler_IntegrationTest_OK(t *testing.T) {
@@ -26,9 +27,9 @@ func TestCreateCustomerHandler_IntegrationTest_OK(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	repo := repository.NewCustomerRepository(db)
-	resourceUC := usecase.NewCustomerUsecase(repo)
-	handler := rest.NewCustomerHandler(resourceUC)
+	repo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewCustomerUsecase(repo, logging.Discard())
+	handler := rest.NewCustomerHandler(resourceUC, logging.Discard())
 
 	r.POST("/customers", handler.CreateCustomer)
 
@@ -66,9 +67,9 @@ func TestCreateCustomerHandler_IntegrationTest_InvalidEmail(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	repo := repository.NewCustomerRepository(db)
-	resourceUC := usecase.NewCustomerUsecase(repo)
-	handler := rest.NewCustomerHandler(resourceUC)
+	repo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewCustomerUsecase(repo, logging.Discard())
+	handler := rest.NewCustomerHandler(resourceUC, logging.Discard())
 
 	r.POST("/customers", handler.CreateCustomer)
 
@@ -90,6 +91,58 @@ func TestCreateCustomerHandler_IntegrationTest_InvalidEmail(t *testing.T) {
 
 }
 
+func TestListCustomersHandler_IntegrationTest_FiltersMatchLiterally(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	db, err := setUpTestDB(t, "testdb", "testuser", "testpassword")
+	defer db.Close()
+	assert.NoError(t, err)
+	for name, email := range map[string]string{
+		"ebuka":    "ebuka@gmail.com",
+		"ebuka_o":  "ebuka.o@gmail.com",
+		"100% ada": "ada@gmail.com",
+	} {
+		_, err := db.Exec(`INSERT INTO customers (name, email) VALUES ($1, $2)`, name, email)
+		assert.NoError(t, err)
+	}
+
+	r := gin.Default()
+	repo := repository.NewCustomerRepository(db, testTimeouts)
+	customerUC := usecase.NewCustomerUsecase(repo, logging.Discard())
+	handler := rest.NewCustomerHandler(customerUC, logging.Discard())
+
+	r.GET("/customers", handler.ListCustomers)
+
+	// % and _ are not wildcards
+	for query, want := range map[string][]string{
+		"name=EBUKA":     {"ebuka", "ebuka_o"},
+		"name=_":         {"ebuka_o"},
+		"name=%25":       {"100% ada"},
+		"email=a_o@":     nil,
+		"email=a.o@":     {"ebuka_o"},
+		"email=%25gmail": nil,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/customers?"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, query)
+
+		var response struct {
+			Data []struct {
+				Name string `json:"name"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var names []string
+		for _, c := range response.Data {
+			names = append(names, c.Name)
+		}
+		assert.ElementsMatch(t, want, names, query)
+	}
+}
+
 func TestGetCustomerByIDHandler_IntegrationTest_OK(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test")
@@ -101,9 +154,9 @@ func TestGetCustomerByIDHandler_IntegrationTest_OK(t *testing.T) {
 	createdCustomer := seedCustomer(t, db)
 
 	r := gin.Default()
-	repo := repository.NewCustomerRepository(db)
-	resourceUC := usecase.NewCustomerUsecase(repo)
-	handler := rest.NewCustomerHandler(resourceUC)
+	repo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewCustomerUsecase(repo, logging.Discard())
+	handler := rest.NewCustomerHandler(resourceUC, logging.Discard())
 
 	r.GET("/customers/:id", handler.GetCustomerByID)
 
@@ -137,9 +190,9 @@ func TestGetCustomerByIDHandler_IntegrationTest_InvalidCustomerID(t *testing.T)
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	repo := repository.NewCustomerRepository(db)
-	resourceUC := usecase.NewCustomerUsecase(repo)
-	handler := rest.NewCustomerHandler(resourceUC)
+	repo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewCustomerUsecase(repo, logging.Discard())
+	handler := rest.NewCustomerHandler(resourceUC, logging.Discard())
 
 	r.GET("/customers/:id", handler.GetCustomerByID)
 
@@ -170,9 +223,9 @@ func TestGetCustomerByIDHandler_IntegrationTest_NotFound(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	repo := repository.NewCustomerRepository(db)
-	resourceUC := usecase.NewCustomerUsecase(repo)
-	handler := rest.NewCustomerHandler(resourceUC)
+	repo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewCustomerUsecase(repo, logging.Discard())
+	handler := rest.NewCustomerHandler(resourceUC, logging.Discard())
 
 	r.GET("/customers/:id", handler.GetCustomerByID)
 
@@ -2,6 +2,7 @@ package rest_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -14,6 +15,8 @@ import (
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/transport/rest"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
 )
 
 // Mock ResourceUsecase
@@ -21,12 +24,12 @@ type mockCustomerUsecase struct {
 	mock.Mock
 }
 
-func (m *mockCustomerUsecase) CreateCustomer(name, email string) (*domain.Customer, error) {
+func (m *mockCustomerUsecase) CreateCustomer(ctx context.Context, name, email string) (*domain.Customer, error) {
 	args := m.Called(name, email)
 	return args.Get(0).(*domain.Customer), args.Error(1)
 }
 
-func (m *mockCustomerUsecase) GetCustomerByID(customerID int64) (*domain.Customer, error) {
+func (m *mockCustomerUsecase) GetCustomerByID(ctx context.Context, customerID int64) (*domain.Customer, error) {
 	args := m.Called(customerID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -34,11 +37,32 @@ func (m *mockCustomerUsecase) GetCustomerByID(customerID int64) (*domain.Custome
 	return args.Get(0).(*domain.Customer), args.Error(1)
 }
 
+func (m *mockCustomerUsecase) ListCustomers(ctx context.Context, filter domain.CustomerFilter) (*domain.CustomerPage, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CustomerPage), args.Error(1)
+}
+
+func (m *mockCustomerUsecase) UpdateCustomer(ctx context.Context, id int64, update domain.CustomerUpdate) (*domain.Customer, error) {
+	args := m.Called(id, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
+
+func (m *mockCustomerUsecase) DeleteCustomer(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 func TestCreateCustomerHandler_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockCustomerUsecase)
-	handler := rest.NewCustomerHandler(mockUC)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -76,7 +100,7 @@ func TestCreateCustomerHandler_EmptyEmail(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockCustomerUsecase)
-	handler := rest.NewCustomerHandler(mockUC)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -106,7 +130,7 @@ func TestGetCustomerByIDHandler_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockCustomerUsecase)
-	handler := rest.NewCustomerHandler(mockUC)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -143,7 +167,7 @@ func TestGetCustomerByIDHandler_InvalidCustomerID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockCustomerUsecase)
-	handler := rest.NewCustomerHandler(mockUC)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -170,7 +194,7 @@ func TestGetCustomerByIDHandler_CustomerNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockCustomerUsecase)
-	handler := rest.NewCustomerHandler(mockUC)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -196,3 +220,174 @@ func TestGetCustomerByIDHandler_CustomerNotFound(t *testing.T) {
 
 	mockUC.AssertExpectations(t)
 }
+
+func TestListCustomersHandler_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockCustomerUsecase)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.GET("/customers", handler.ListCustomers)
+
+	mockUC.On("ListCustomers", domain.CustomerFilter{Email: "example.com", Limit: 10}).Return(&domain.CustomerPage{
+		Customers: []domain.Customer{{ID: 1, Name: "ebuka", Email: "ebuka@example.com"}},
+		Total:     1,
+		Limit:     10,
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/customers?email=example.com&limit=10", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+	data := resp["data"].([]interface{})
+	assert.Len(t, data, 1)
+	meta := resp["meta"].(map[string]interface{})
+	assert.Equal(t, float64(1), meta["total"])
+	assert.Equal(t, float64(10), meta["limit"])
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestPatchCustomerHandler_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockCustomerUsecase)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.PATCH("/customers/:id", handler.PatchCustomer)
+
+	name := "chinedu"
+	mockUC.On("UpdateCustomer", int64(1), domain.CustomerUpdate{Name: &name}).Return(&domain.Customer{
+		ID:    1,
+		Name:  name,
+		Email: "test@email.com",
+	}, nil)
+
+	body := `{"name":"chinedu"}`
+	req, _ := http.NewRequest("PATCH", "/customers/1", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+	customer := resp["data"].(map[string]interface{})
+	assert.Equal(t, "chinedu", customer["name"])
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestPatchCustomerHandler_NoFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockCustomerUsecase)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.PATCH("/customers/:id", handler.PatchCustomer)
+
+	req, _ := http.NewRequest("PATCH", "/customers/1", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestReplaceCustomerHandler_DuplicateEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockCustomerUsecase)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.PUT("/customers/:id", handler.ReplaceCustomer)
+
+	name, email := "ebuka", "taken@email.com"
+	mockUC.On("UpdateCustomer", int64(1), domain.CustomerUpdate{Name: &name, Email: &email}).
+		Return((*domain.Customer)(nil), usecase.ErrEmailTaken)
+
+	body := `{"name":"ebuka","email":"taken@email.com"}`
+	req, _ := http.NewRequest("PUT", "/customers/1", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "customer with this email already exists", resp["error"])
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestDeleteCustomerHandler_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockCustomerUsecase)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.DELETE("/customers/:id", handler.DeleteCustomer)
+
+	mockUC.On("DeleteCustomer", int64(1)).Return(nil)
+
+	req, _ := http.NewRequest("DELETE", "/customers/1", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestDeleteCustomerHandler_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockCustomerUsecase)
+	handler := rest.NewCustomerHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.DELETE("/customers/:id", handler.DeleteCustomer)
+
+	mockUC.On("DeleteCustomer", int64(9)).Return(usecase.ErrCustomerNotFound)
+
+	req, _ := http.NewRequest("DELETE", "/customers/9", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockUC.AssertExpectations(t)
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/export"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+)
+
+type InventoryHandler struct {
+	inventoryUC usecase.InventoryUsecase
+	logger      *slog.Logger
+}
+
+func NewInventoryHandler(inventoryUC usecase.InventoryUsecase, logger *slog.Logger) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryUC: inventoryUC,
+		logger:      logger,
+	}
+}
+
+// GET /export?format=&customer_id=&provider=&account_id=&type=&region=&tag=
+//
+// Every customer is streamed with one row per assigned resource, or a single
+// row without a resource when it has none. Once the first row is written the
+// status can no longer change, so later errors cut the connection, for the
+// client to see that the download is incomplete.
+func (h *InventoryHandler) Export(c *gin.Context) {
+	var req struct {
+		Format     string   `form:"format"`
+		CustomerID int64    `form:"customer_id" binding:"omitempty,min=1"`
+		Provider   string   `form:"provider"`
+		AccountID  string   `form:"account_id"`
+		Type       string   `form:"type"`
+		Region     string   `form:"region"`
+		Tags       []string `form:"tag"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = export.FormatCSV
+	}
+	tags, ok := parseKeyValues(req.Tags)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tag must be key=value"})
+		return
+	}
+
+	w, err := export.NewWriter(req.Format, c.Writer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of " + strings.Join(export.Formats, ", ")})
+		return
+	}
+
+	query := domain.InventoryQuery{
+		CustomerID: req.CustomerID,
+		Provider:   req.Provider,
+		AccountID:  req.AccountID,
+		Type:       req.Type,
+		Region:     req.Region,
+		Tags:       tags,
+	}
+	// The headers are only set once there is something to send, so that an
+	// early error is still returned as JSON
+	started := false
+	start := func() {
+		if !started {
+			started = true
+			c.Header("Content-Type", export.ContentType(req.Format))
+			c.Header("Content-Disposition", `attachment; filename="inventory.`+req.Format+`"`)
+		}
+	}
+	err = h.inventoryUC.Export(c.Request.Context(), query, func(row domain.InventoryRow) error {
+		start()
+		if err := w.Write(row); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		start()
+		err = w.Close()
+	}
+	switch {
+	case err == nil:
+		c.Status(http.StatusOK)
+	case started:
+		h.logger.WarnContext(c.Request.Context(), "inventory export aborted", "error", err)
+		panic(http.ErrAbortHandler)
+	case errors.Is(err, usecase.ErrInvalidFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,77 @@
+package rest_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/transport/rest"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+func TestExportHandler_IntegrationTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	db, err := setUpTestDB(t, "testdb", "testuser", "testpassword")
+	defer db.Close()
+	assert.NoError(t, err)
+
+	inventoryUC := usecase.NewInventoryUsecase(repository.NewInventoryRepository(db, testTimeouts), logging.Discard())
+	handler := rest.NewInventoryHandler(inventoryUC, logging.Discard())
+
+	customer := seedCustomer(t, db)
+	resource1 := seedResource1(t, db)
+	resource2 := seedResource2(t, db)
+	_, err = db.Exec(`UPDATE resources SET provider = 'gcp' WHERE id = $1`, resource2.ID)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO customer_resource (customer_id, resource_id) VALUES ($1, $2), ($1, $3)`,
+		customer.ID, resource1.ID, resource2.ID)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO customers (name, email) VALUES ('jane', 'jane@example.com')`)
+	require.NoError(t, err)
+
+	r := gin.Default()
+	r.GET("/export", handler.Export)
+
+	// Every assignment, and the customer without any
+	req, _ := http.NewRequest(http.MethodGet, "/export?format=csv", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "ebuka", records[1][1])
+	assert.Equal(t, resource1.Name, records[1][4])
+	assert.Equal(t, resource2.Name, records[2][4])
+	assert.Equal(t, "jane", records[3][1])
+	assert.Equal(t, "", records[3][4])
+
+	// A resource filter drops the customer without resources
+	req, _ = http.NewRequest(http.MethodGet, "/export?format=jsonl&provider=gcp", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 1)
+	var row domain.InventoryRow
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+	assert.Equal(t, customer.ID, row.Customer.ID)
+	require.NotNil(t, row.Resource)
+	assert.Equal(t, resource2.ID, row.Resource.ID)
+	assert.NotNil(t, row.AssignedAt)
+}
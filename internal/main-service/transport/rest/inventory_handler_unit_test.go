@@ -0,0 +1,113 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/transport/rest"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+// Mock InventoryUsecase
+type mockInventoryUsecase struct {
+	mock.Mock
+}
+
+func (m *mockInventoryUsecase) Export(ctx context.Context, query domain.InventoryQuery, fn func(domain.InventoryRow) error) error {
+	args := m.Called(query)
+	if rows, ok := args.Get(0).([]domain.InventoryRow); ok {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func newInventoryRouter(uc usecase.InventoryUsecase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(rest.Recovery())
+	r.GET("/export", rest.NewInventoryHandler(uc, logging.Discard()).Export)
+	return r
+}
+
+func TestExportHandler_CSV(t *testing.T) {
+	mockUC := new(mockInventoryUsecase)
+	r := newInventoryRouter(mockUC)
+
+	mockUC.On("Export", domain.InventoryQuery{Provider: "aws", Tags: map[string]string{"env": "prod"}}).Return([]domain.InventoryRow{
+		{Customer: domain.Customer{ID: 1, Name: "ebuka", Email: "ebuka@gmail.com"}, Resource: &domain.Resource{ID: 7, Name: "aws_vpc_main"}},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/export?provider=aws&tag=env=prod", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "inventory.csv")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[1], "1,ebuka,ebuka@gmail.com,7,aws_vpc_main,"))
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestExportHandler_Errors(t *testing.T) {
+	mockUC := new(mockInventoryUsecase)
+	r := newInventoryRouter(mockUC)
+
+	mockUC.On("Export", domain.InventoryQuery{Provider: "ibm"}).Return(nil, usecase.ErrInvalidFilter)
+	mockUC.On("Export", domain.InventoryQuery{Region: "eu"}).Return(nil, errors.New("connection reset"))
+
+	for path, code := range map[string]int{
+		"/export?format=pdf":            http.StatusBadRequest,
+		"/export?tag=env":               http.StatusBadRequest,
+		"/export?customer_id=abc":       http.StatusBadRequest,
+		"/export?provider=ibm":          http.StatusBadRequest,
+		"/export?format=xlsx&region=eu": http.StatusInternalServerError,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, path)
+		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"), path)
+	}
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestExportHandler_FailureAfterFirstRow(t *testing.T) {
+	mockUC := new(mockInventoryUsecase)
+	srv := httptest.NewServer(newInventoryRouter(mockUC))
+	defer srv.Close()
+
+	mockUC.On("Export", domain.InventoryQuery{}).Return([]domain.InventoryRow{
+		{Customer: domain.Customer{ID: 1, Name: "ebuka", Email: "ebuka@gmail.com"}},
+	}, errors.New("connection reset"))
+
+	resp, err := http.Get(srv.URL + "/export")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The connection is cut, so the body does not end cleanly
+	_, err = io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	mockUC.AssertExpectations(t)
+}
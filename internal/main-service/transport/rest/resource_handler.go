@@ -1,45 +1,76 @@
 package rest
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
-	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/service"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/importer"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
 )
 
+// maxImportSize bounds the body of POST /resources/import.
+const maxImportSize = 64 << 20
+
 type ResourceHandler struct {
 	resourceUC usecase.ResourceUsecase
-	notifier   service.Notifier
+	logger     *slog.Logger
 }
 
-func NewResourceHandler(resourceUC usecase.ResourceUsecase, notifier service.Notifier) *ResourceHandler {
+func NewResourceHandler(resourceUC usecase.ResourceUsecase, logger *slog.Logger) *ResourceHandler {
 	return &ResourceHandler{
 		resourceUC: resourceUC,
-		notifier:   notifier,
+		logger:     logger,
 	}
 }
 
-// GET /resources
+// GET /resources?limit=&after=&type=&region=&name_prefix=&provider=&account_id=&native_id=&tag=&attribute=&sort=
+//
+// tag and attribute take key=value and may be repeated.
 func (h *ResourceHandler) GetAllAvailableResources(c *gin.Context) {
-	resources, err := h.resourceUC.GetAllAvailableResources()
+	query, ok := bindResourceQuery(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.resourceUC.GetAllAvailableResources(c.Request.Context(), query)
 	if err != nil {
+		if isResourceQueryError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.ErrorContext(c.Request.Context(), "error listing resources", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": resources})
+	c.JSON(http.StatusOK, resourcePageResponse(page))
+}
+
+// POST /customers/:id/:method
+//
+// Gin cannot route a literal colon inside a path segment, so custom methods
+// on a customer are registered as a parameter and dispatched here.
+func (h *ResourceHandler) CustomerMethod(c *gin.Context) {
+	switch c.Param("method") {
+	case "resources:batch":
+		h.AddCloudResources(c)
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	}
 }
 
+// POST /customers/:id/resources:batch
 func (h *ResourceHandler) AddCloudResources(c *gin.Context) {
 	customerIDParam := c.Param("id")
 	customerID, err := strconv.ParseInt(customerIDParam, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer_id"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
 		return
 	}
 
@@ -51,17 +82,26 @@ func (h *ResourceHandler) AddCloudResources(c *gin.Context) {
 		return
 	}
 
-	for i, name := range req.ResourceNames {
-		req.ResourceNames[i] = strings.TrimSpace(name)
-	}
-
-	err = h.resourceUC.AddCloudResources(customerID, req.ResourceNames)
+	results, err := h.resourceUC.AddCloudResources(c.Request.Context(), customerID, req.ResourceNames)
 	if err != nil {
+		if errors.Is(err, usecase.ErrCustomerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Resources assigned successfully"})
+	assigned := 0
+	for _, r := range results {
+		if r.Status == domain.AssignmentAssigned {
+			assigned++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d of %d resources assigned", assigned, len(results)),
+		"data":    results,
+	})
 }
 
 // POST /customers/:id/resources
@@ -81,24 +121,62 @@ func (h *ResourceHandler) AddCloudResource(c *gin.Context) {
 		return
 	}
 
-	err = h.resourceUC.AddCloudResource(customerID, strings.TrimSpace(req.ResourceName))
+	err = h.resourceUC.AddCloudResource(c.Request.Context(), customerID, strings.TrimSpace(req.ResourceName))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.notifier.Publish(domain.Notification{
-		Event:   "notification",
-		UserID:  customerID,
-		Message: fmt.Sprintf("added resource %s for customer with customerID %d", req.ResourceName, customerID),
-	}); err != nil {
-		log.Println("error publishing notification")
+	c.JSON(http.StatusOK, gin.H{"message": "Resources assigned successfully"})
+}
+
+// DELETE /customers/:id/resources/:resourceId
+func (h *ResourceHandler) RemoveCloudResource(c *gin.Context) {
+	customerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+	resourceID, err := strconv.ParseInt(c.Param("resourceId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resource id"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Resources assigned successfully"})
+	_, err = h.resourceUC.RemoveCloudResource(c.Request.Context(), customerID, resourceID)
+	respondResourceRemoved(c, err)
 }
 
-// GET /customers/:id/resources
+// DELETE /customers/:id/resources/by-name/:name
+func (h *ResourceHandler) RemoveCloudResourceByName(c *gin.Context) {
+	customerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	_, err = h.resourceUC.RemoveCloudResourceByName(c.Request.Context(), customerID, strings.TrimSpace(c.Param("name")))
+	respondResourceRemoved(c, err)
+}
+
+func respondResourceRemoved(c *gin.Context, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrCustomerNotFound), errors.Is(err, usecase.ErrResourceNotFound),
+			errors.Is(err, usecase.ErrResourceNotAssigned):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Resource removed successfully"})
+}
+
+// GET /customers/:id/resources?limit=&after=&type=&region=&name_prefix=&provider=&account_id=&native_id=&tag=&attribute=&sort=&as_of=
+//
+// as_of, an RFC 3339 timestamp, lists what the customer owned at that moment.
 func (h *ResourceHandler) GetResourcesByCustomer(c *gin.Context) {
 	customerIDParam := c.Param("id")
 	customerID, err := strconv.ParseInt(customerIDParam, 10, 64)
@@ -107,18 +185,24 @@ func (h *ResourceHandler) GetResourcesByCustomer(c *gin.Context) {
 		return
 	}
 
-	resources, err := h.resourceUC.GetResourcesByCustomer(customerID)
+	query, ok := bindResourceQuery(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.resourceUC.GetResourcesByCustomer(c.Request.Context(), customerID, query)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"data": resources,
-	})
+	c.JSON(http.StatusOK, resourcePageResponse(page))
 }
 
 // PUT /resources/:id
+//
+// Name, type and region are required. Provider, account_id, native_id, tags
+// and attributes are left unchanged when omitted.
 func (h *ResourceHandler) UpdateResource(c *gin.Context) {
 	resourceIDParam := c.Param("id")
 	resourceID, err := strconv.ParseInt(resourceIDParam, 10, 64)
@@ -128,16 +212,30 @@ func (h *ResourceHandler) UpdateResource(c *gin.Context) {
 	}
 
 	var req struct {
-		Name   string `json:"name" binding:"required"`
-		Type   string `json:"type" binding:"required"`
-		Region string `json:"region" binding:"required"`
+		Name       string            `json:"name" binding:"required"`
+		Type       string            `json:"type" binding:"required"`
+		Region     string            `json:"region" binding:"required"`
+		Provider   *string           `json:"provider"`
+		AccountID  *string           `json:"account_id"`
+		NativeID   *string           `json:"native_id"`
+		Tags       map[string]string `json:"tags"`
+		Attributes json.RawMessage   `json:"attributes"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	updatedRes, err := h.resourceUC.UpdateResource(resourceID, req.Name, req.Type, req.Region)
+	updatedRes, err := h.resourceUC.UpdateResource(c.Request.Context(), resourceID, domain.ResourceUpdate{
+		Name:       &req.Name,
+		Type:       &req.Type,
+		Region:     &req.Region,
+		Provider:   req.Provider,
+		AccountID:  req.AccountID,
+		NativeID:   req.NativeID,
+		Tags:       req.Tags,
+		Attributes: req.Attributes,
+	})
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -155,10 +253,173 @@ func (h *ResourceHandler) DeleteResource(c *gin.Context) {
 		return
 	}
 
-	if err := h.resourceUC.DeleteResource(resourceID); err != nil {
+	if err := h.resourceUC.DeleteResource(c.Request.Context(), resourceID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Resource deleted successfully"})
 }
+
+// POST /resources/:id/restore
+//
+// Undeletes a deleted resource and the assignments its deletion removed.
+func (h *ResourceHandler) RestoreResource(c *gin.Context) {
+	resourceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resource id"})
+		return
+	}
+
+	res, err := h.resourceUC.RestoreResource(c.Request.Context(), resourceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrResourceNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, usecase.ErrResourceNotDeleted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+// POST /resources/import?format=aws-config|gcp-asset|azure-graph
+//
+// The body is the export file as produced by the provider.
+func (h *ResourceHandler) ImportResources(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format is required, expected one of " + strings.Join(importer.Formats, ", ")})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	summary, err := h.resourceUC.ImportResources(c.Request.Context(), format, body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("import is larger than %d bytes", tooLarge.Limit)})
+		case errors.Is(err, usecase.ErrInvalidImport):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+// GET /resources/:id/history
+func (h *ResourceHandler) GetResourceHistory(c *gin.Context) {
+	resourceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resource id"})
+		return
+	}
+
+	versions, err := h.resourceUC.GetResourceHistory(c.Request.Context(), resourceID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrResourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// bindResourceQuery reads the listing parameters shared by the resource
+// list endpoints.
+func bindResourceQuery(c *gin.Context) (domain.ResourceQuery, bool) {
+	var req struct {
+		Limit      int      `form:"limit" binding:"omitempty,min=1"`
+		After      string   `form:"after"`
+		Type       string   `form:"type"`
+		Region     string   `form:"region"`
+		NamePrefix string   `form:"name_prefix"`
+		Provider   string   `form:"provider"`
+		AccountID  string   `form:"account_id"`
+		NativeID   string   `form:"native_id"`
+		Tags       []string `form:"tag"`
+		Attributes []string `form:"attribute"`
+		Sort       string   `form:"sort"`
+		AsOf       string   `form:"as_of"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return domain.ResourceQuery{}, false
+	}
+	asOf, err := parseTimeParam(req.AsOf)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be an RFC 3339 timestamp"})
+		return domain.ResourceQuery{}, false
+	}
+	tags, ok := parseKeyValues(req.Tags)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tag must be key=value"})
+		return domain.ResourceQuery{}, false
+	}
+	attributes, ok := parseKeyValues(req.Attributes)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "attribute must be key=value"})
+		return domain.ResourceQuery{}, false
+	}
+
+	return domain.ResourceQuery{
+		Type:       req.Type,
+		Region:     req.Region,
+		NamePrefix: req.NamePrefix,
+		Provider:   req.Provider,
+		AccountID:  req.AccountID,
+		NativeID:   req.NativeID,
+		Tags:       tags,
+		Attributes: attributes,
+		Sort:       req.Sort,
+		Limit:      req.Limit,
+		After:      req.After,
+		AsOf:       asOf,
+	}, true
+}
+
+// parseKeyValues splits each "key=value" pair at its first equals sign, so
+// keys may contain colons, as AWS tag keys do. It returns nil for no pairs
+// and false when a pair has no equals sign or an empty key.
+func parseKeyValues(pairs []string) (map[string]string, bool) {
+	if len(pairs) == 0 {
+		return nil, true
+	}
+	m := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, found := strings.Cut(pair, "=")
+		if !found || k == "" {
+			return nil, false
+		}
+		m[k] = v
+	}
+	return m, true
+}
+
+func isResourceQueryError(err error) bool {
+	return errors.Is(err, usecase.ErrInvalidCursor) || errors.Is(err, usecase.ErrInvalidSort) ||
+		errors.Is(err, usecase.ErrInvalidFilter)
+}
+
+func resourcePageResponse(page *domain.ResourcePage) gin.H {
+	resources := page.Resources
+	if resources == nil {
+		resources = []domain.Resource{}
+	}
+	var next interface{}
+	if page.NextCursor != "" {
+		next = page.NextCursor
+	}
+	return gin.H{
+		"data":        resources,
+		"next_cursor": next,
+	}
+}
@@ -2,11 +2,13 @@ package rest_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +17,7 @@ import (
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/transport/rest"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
 )
 
 func TestGetAllResourceHandler_IntegrationTest_OK(t *testing.T) {
@@ -28,11 +31,10 @@ func TestGetAllResourceHandler_IntegrationTest_OK(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	resource1 := seedResource1(t, db)
 	seedResource2(t, db)
@@ -58,8 +60,58 @@ func TestGetAllResourceHandler_IntegrationTest_OK(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, 2, len(resources))
 
-	mockNotifer.AssertExpectations(t)
+}
+
+func TestGetAllResourceHandler_IntegrationTest_MetadataFilters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	db, err := setUpTestDB(t, "testdb", "testuser", "testpassword")
+	defer db.Close()
+	assert.NoError(t, err)
+
+	r := gin.Default()
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
+
+	resource := seedResource1(t, db)
+	seedResource2(t, db)
+
+	provider, accountID := "aws", "123456789012"
+	_, err = resourceUC.UpdateResource(context.Background(), resource.ID, domain.ResourceUpdate{
+		Provider:   &provider,
+		AccountID:  &accountID,
+		Tags:       map[string]string{"env": "prod"},
+		Attributes: json.RawMessage(`{"cidr": "10.0.0.0/16"}`),
+	})
+	assert.NoError(t, err)
 
+	r.GET("/resources", handler.GetAllAvailableResources)
+
+	for query, want := range map[string]int{
+		"provider=aws":                   1,
+		"account_id=123456789012":        1,
+		"tag=env=prod":                   1,
+		"tag=env=dev":                    0,
+		"attribute=cidr=10.0.0.0/16":     1,
+		"provider=aws&tag=env=prod":      1,
+		"provider=gcp&account_id=123456": 0,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/resources?"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+
+		// Assertions
+		assert.Equal(t, http.StatusOK, w.Code, query)
+		assert.Len(t, response["data"], want, query)
+	}
 }
 
 func TestAddCloudResourceHandler_IntegrationTest_OK(t *testing.T) {
@@ -73,20 +125,14 @@ func TestAddCloudResourceHandler_IntegrationTest_OK(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	cust := seedCustomer(t, db)
 	resource1 := seedResource1(t, db)
 	seedResource2(t, db)
-	mockNotifer.On("Publish", domain.Notification{
-		Event:   "notification",
-		UserID:  cust.ID,
-		Message: fmt.Sprintf("added resource %s for customer with customerID %d", resource1.Name, cust.ID),
-	}).Return(nil)
 
 	r.POST("/customers/:id/resources", handler.AddCloudResource)
 
@@ -111,12 +157,14 @@ func TestAddCloudResourceHandler_IntegrationTest_OK(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "Resources assigned successfully", response["message"])
 
-	ok, err := resourceRepo.DoesCustomerHaveResource(cust.ID, resource1.Name)
+	ok, err := resourceRepo.DoesCustomerHaveResource(context.Background(), cust.ID, resource1.Name)
 	assert.NoError(t, err)
 	assert.True(t, ok)
 
-	mockNotifer.AssertExpectations(t)
-
+	// The notification is queued in the outbox with the assignment
+	assert.Equal(t, []string{
+		fmt.Sprintf("added resource %s for customer with customerID %d", resource1.Name, cust.ID),
+	}, pendingOutboxMessages(t, db))
 }
 
 func TestAddCloudResourceHandler_IntegrationTest_ResourceAlreadyExist(t *testing.T) {
@@ -130,16 +178,15 @@ func TestAddCloudResourceHandler_IntegrationTest_ResourceAlreadyExist(t *testing
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	cust := seedCustomer(t, db)
 	resource1 := seedResource1(t, db)
 
-	resourceRepo.AddResourceToCustomer(resource1.Name, cust.ID)
+	resourceRepo.AddResourceToCustomer(context.Background(), resource1.Name, cust.ID)
 
 	r.POST("/customers/:id/resources", handler.AddCloudResource)
 
@@ -164,8 +211,6 @@ func TestAddCloudResourceHandler_IntegrationTest_ResourceAlreadyExist(t *testing
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Equal(t, fmt.Sprintf("customer already has %s resource", resource1.Name), response["error"])
 
-	mockNotifer.AssertExpectations(t)
-
 }
 
 func TestAddCloudResourceHandler_IntegrationTest_InvalidCustomerID(t *testing.T) {
@@ -179,11 +224,10 @@ func TestAddCloudResourceHandler_IntegrationTest_InvalidCustomerID(t *testing.T)
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	resource1 := seedResource1(t, db)
 	seedResource2(t, db)
@@ -211,8 +255,6 @@ func TestAddCloudResourceHandler_IntegrationTest_InvalidCustomerID(t *testing.T)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Equal(t, "invalid customer id", response["error"])
 
-	mockNotifer.AssertExpectations(t)
-
 }
 
 func TestGetResourcesByCustomerHandler_IntegrationTest_OK(t *testing.T) {
@@ -226,17 +268,16 @@ func TestGetResourcesByCustomerHandler_IntegrationTest_OK(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	cust := seedCustomer(t, db)
 	resource1 := seedResource1(t, db)
 	resource2 := seedResource2(t, db)
-	resourceRepo.AddResourceToCustomer(resource1.Name, cust.ID)
-	resourceRepo.AddResourceToCustomer(resource2.Name, cust.ID)
+	resourceRepo.AddResourceToCustomer(context.Background(), resource1.Name, cust.ID)
+	resourceRepo.AddResourceToCustomer(context.Background(), resource2.Name, cust.ID)
 
 	r.GET("/customers/:id/resources", handler.GetResourcesByCustomer)
 
@@ -259,8 +300,54 @@ func TestGetResourcesByCustomerHandler_IntegrationTest_OK(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, 2, len(resources))
 
-	mockNotifer.AssertExpectations(t)
+}
 
+func TestGetResourcesByCustomerHandler_IntegrationTest_AsOf(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	db, err := setUpTestDB(t, "testdb", "testuser", "testpassword")
+	defer db.Close()
+	assert.NoError(t, err)
+
+	r := gin.Default()
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
+
+	cust := seedCustomer(t, db)
+	resource1 := seedResource1(t, db)
+	resource2 := seedResource2(t, db)
+	assert.NoError(t, resourceRepo.AddResourceToCustomer(context.Background(), resource1.Name, cust.ID))
+
+	// Take the moment from the database clock, which stamps the versions
+	var asOf time.Time
+	assert.NoError(t, db.QueryRow(`SELECT NOW()`).Scan(&asOf))
+	time.Sleep(10 * time.Millisecond)
+
+	assert.NoError(t, resourceRepo.AddResourceToCustomer(context.Background(), resource2.Name, cust.ID))
+	assert.NoError(t, resourceRepo.RemoveResourceFromCustomer(context.Background(), cust.ID, resource1.ID))
+
+	r.GET("/customers/:id/resources", handler.GetResourcesByCustomer)
+
+	url := fmt.Sprintf("/customers/%d/resources?as_of=%s", cust.ID, asOf.UTC().Format(time.RFC3339Nano))
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	resources := response["data"].([]interface{})
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, len(resources))
+	assert.Equal(t, resource1.Name, resources[0].(map[string]interface{})["name"])
 }
 
 func TestGetResourcesByCustomerHandler_IntegrationTest_InvalidCustomerID(t *testing.T) {
@@ -274,11 +361,10 @@ func TestGetResourcesByCustomerHandler_IntegrationTest_InvalidCustomerID(t *test
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	r.GET("/customers/:id/resources", handler.GetResourcesByCustomer)
 
@@ -301,8 +387,6 @@ func TestGetResourcesByCustomerHandler_IntegrationTest_InvalidCustomerID(t *test
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Equal(t, "invalid customer id", response["error"])
 
-	mockNotifer.AssertExpectations(t)
-
 }
 
 func TestDeleteResourceHandler_IntegrationTest_OK(t *testing.T) {
@@ -316,11 +400,10 @@ func TestDeleteResourceHandler_IntegrationTest_OK(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	resource := seedResource1(t, db)
 	r.DELETE("/resources/:id", handler.DeleteResource)
@@ -344,12 +427,54 @@ func TestDeleteResourceHandler_IntegrationTest_OK(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "Resource deleted successfully", response["message"])
 
-	res, err := resourceRepo.GetByID(resource.ID)
+	res, err := resourceRepo.GetByID(context.Background(), resource.ID)
 	assert.Error(t, err)
 	assert.Nil(t, res)
 
-	mockNotifer.AssertExpectations(t)
+}
 
+func TestRestoreResourceHandler_IntegrationTest_OK(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	db, err := setUpTestDB(t, "testdb", "testuser", "testpassword")
+	defer db.Close()
+	assert.NoError(t, err)
+
+	r := gin.Default()
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
+
+	cust := seedCustomer(t, db)
+	resource := seedResource1(t, db)
+	assert.NoError(t, resourceRepo.AddResourceToCustomer(context.Background(), resource.Name, cust.ID))
+	assert.NoError(t, resourceRepo.Delete(context.Background(), resource.ID))
+
+	owned, err := resourceRepo.DoesCustomerHaveResource(context.Background(), cust.ID, resource.Name)
+	assert.NoError(t, err)
+	assert.False(t, owned)
+
+	r.POST("/resources/:id/restore", handler.RestoreResource)
+
+	url := fmt.Sprintf("/resources/%d/restore", resource.ID)
+	req, _ := http.NewRequest(http.MethodPost, url, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	owned, err = resourceRepo.DoesCustomerHaveResource(context.Background(), cust.ID, resource.Name)
+	assert.NoError(t, err)
+	assert.True(t, owned)
+
+	// A second restore finds nothing to undo
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
 }
 
 func TestDeleteResourceHandler_IntegrationTest_InvalidCustomerID(t *testing.T) {
@@ -363,11 +488,10 @@ func TestDeleteResourceHandler_IntegrationTest_InvalidCustomerID(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	r.DELETE("/resources/:id", handler.DeleteResource)
 
@@ -390,8 +514,6 @@ func TestDeleteResourceHandler_IntegrationTest_InvalidCustomerID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Equal(t, "invalid resource id", response["error"])
 
-	mockNotifer.AssertExpectations(t)
-
 }
 
 func TestUpdateResourceHandler_IntegrationTest_OK(t *testing.T) {
@@ -405,11 +527,10 @@ func TestUpdateResourceHandler_IntegrationTest_OK(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	resource := seedResource1(t, db)
 
@@ -438,15 +559,13 @@ func TestUpdateResourceHandler_IntegrationTest_OK(t *testing.T) {
 	assert.Equal(t, "new type", response.Type)
 	assert.Equal(t, "new region", response.Region)
 
-	updatedResource, err := resourceRepo.GetByID(resource.ID)
+	updatedResource, err := resourceRepo.GetByID(context.Background(), resource.ID)
 	assert.NoError(t, err)
 
 	assert.Equal(t, "new resource name", updatedResource.Name)
 	assert.Equal(t, "new type", updatedResource.Type)
 	assert.Equal(t, "new region", updatedResource.Region)
 
-	mockNotifer.AssertExpectations(t)
-
 }
 func TestUpdateResourceHandler_IntegrationTest_InvalidCustomerID(t *testing.T) {
 	if testing.Short() {
@@ -459,11 +578,10 @@ func TestUpdateResourceHandler_IntegrationTest_InvalidCustomerID(t *testing.T) {
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	resourceRepo := repository.NewResourceRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
-	mockNotifer := new(mockNotifier)
-	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo)
-	handler := rest.NewResourceHandler(resourceUC, mockNotifer)
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
 
 	r.PUT("/resources/:id", handler.UpdateResource)
 
@@ -488,6 +606,58 @@ func TestUpdateResourceHandler_IntegrationTest_InvalidCustomerID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Equal(t, "invalid resource id", response["error"])
 
-	mockNotifer.AssertExpectations(t)
+}
+
+func TestAddCloudResourcesBatchHandler_IntegrationTest_OK(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	db, err := setUpTestDB(t, "testdb", "testuser", "testpassword")
+	defer db.Close()
+	assert.NoError(t, err)
+
+	r := gin.Default()
+	resourceRepo := repository.NewResourceRepository(db, testTimeouts)
+	customerRepo := repository.NewCustomerRepository(db, testTimeouts)
+	resourceUC := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+	handler := rest.NewResourceHandler(resourceUC, logging.Discard())
+
+	cust := seedCustomer(t, db)
+	resource1 := seedResource1(t, db)
+	resource2 := seedResource2(t, db)
+	err = resourceRepo.AddResourceToCustomer(context.Background(), resource2.Name, cust.ID)
+	assert.NoError(t, err)
+
+	r.POST("/customers/:id/:method", handler.CustomerMethod)
+
+	// Perform the test request
+	requestBody := map[string][]string{"resource_names": {resource1.Name, resource2.Name, "unknown_resource"}}
+	body, _ := json.Marshal(&requestBody)
+
+	url := fmt.Sprintf("/customers/%d/resources:batch", cust.ID)
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := response["data"].([]interface{})
+	assert.Equal(t, "assigned", data[0].(map[string]interface{})["status"])
+	assert.Equal(t, "already_owned", data[1].(map[string]interface{})["status"])
+	assert.Equal(t, "unknown", data[2].(map[string]interface{})["status"])
+
+	ok, err := resourceRepo.DoesCustomerHaveResource(context.Background(), cust.ID, resource1.Name)
+	assert.NoError(t, err)
+	assert.True(t, ok)
 
+	// Only the newly assigned name ends up in the queued notification
+	messages := pendingOutboxMessages(t, db)
+	assert.Equal(t, fmt.Sprintf("added resources %s for customer with customerID %d", resource1.Name, cust.ID), messages[len(messages)-1])
 }
@@ -2,11 +2,14 @@ package rest_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -14,6 +17,8 @@ import (
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/transport/rest"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
 )
 
 // Mock ResourceUsecase
@@ -21,78 +26,100 @@ type mockResourceUsecase struct {
 	mock.Mock
 }
 
-func (m *mockResourceUsecase) GetAllAvailableResources() ([]domain.Resource, error) {
-	args := m.Called()
+func (m *mockResourceUsecase) GetAllAvailableResources(ctx context.Context, query domain.ResourceQuery) (*domain.ResourcePage, error) {
+	args := m.Called(query)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]domain.Resource), args.Error(1)
+	return args.Get(0).(*domain.ResourcePage), args.Error(1)
 }
 
-func (m *mockResourceUsecase) AddCloudResource(customerID int64, resourceName string) error {
+func (m *mockResourceUsecase) AddCloudResource(ctx context.Context, customerID int64, resourceName string) error {
 	args := m.Called(customerID, resourceName)
 	return args.Error(0)
 }
 
-func (m *mockResourceUsecase) AddCloudResources(customerID int64, resourceNames []string) error {
+func (m *mockResourceUsecase) AddCloudResources(ctx context.Context, customerID int64, resourceNames []string) ([]domain.ResourceAssignment, error) {
 	args := m.Called(customerID, resourceNames)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ResourceAssignment), args.Error(1)
 }
 
-func (m *mockResourceUsecase) GetResourcesByCustomer(customerID int64) ([]domain.Resource, error) {
-	args := m.Called(customerID)
+func (m *mockResourceUsecase) GetResourcesByCustomer(ctx context.Context, customerID int64, query domain.ResourceQuery) (*domain.ResourcePage, error) {
+	args := m.Called(customerID, query)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]domain.Resource), args.Error(1)
+	return args.Get(0).(*domain.ResourcePage), args.Error(1)
 }
 
-func (m *mockResourceUsecase) UpdateResource(resourceID int64, name, resourceType, region string) (*domain.Resource, error) {
-	args := m.Called(resourceID, name, resourceType, region)
+func (m *mockResourceUsecase) UpdateResource(ctx context.Context, resourceID int64, update domain.ResourceUpdate) (*domain.Resource, error) {
+	args := m.Called(resourceID, update)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.Resource), args.Error(1)
 }
 
-func (m *mockResourceUsecase) DeleteResource(resourceID int64) error {
+func (m *mockResourceUsecase) DeleteResource(ctx context.Context, resourceID int64) error {
 	args := m.Called(resourceID)
 	return args.Error(0)
 }
 
-type mockNotifier struct {
-	mock.Mock
+func (m *mockResourceUsecase) RemoveCloudResource(ctx context.Context, customerID, resourceID int64) (*domain.Resource, error) {
+	args := m.Called(customerID, resourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Resource), args.Error(1)
 }
 
-func (m *mockNotifier) Publish(message domain.Notification) error {
-	args := m.Called(message)
-	return args.Error(0)
+func (m *mockResourceUsecase) RemoveCloudResourceByName(ctx context.Context, customerID int64, resourceName string) (*domain.Resource, error) {
+	args := m.Called(customerID, resourceName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Resource), args.Error(1)
 }
-func (m *mockNotifier) Listen() error {
-	args := m.Called()
-	return args.Error(0)
+
+func (m *mockResourceUsecase) GetResourceHistory(ctx context.Context, resourceID int64) ([]domain.ResourceVersion, error) {
+	args := m.Called(resourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ResourceVersion), args.Error(1)
 }
-func (m *mockNotifier) Close() {
-	m.Called()
+
+func (m *mockResourceUsecase) RestoreResource(ctx context.Context, resourceID int64) (*domain.Resource, error) {
+	args := m.Called(resourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Resource), args.Error(1)
+}
+
+func (m *mockResourceUsecase) ImportResources(ctx context.Context, format string, r io.Reader) (*domain.ImportSummary, error) {
+	data, _ := io.ReadAll(r)
+	args := m.Called(format, string(data))
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ImportSummary), args.Error(1)
 }
 
 func TestAddCloudResourceHandler_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
 	r.POST("/customers/:id/resources", handler.AddCloudResource)
 
 	mockUC.On("AddCloudResource", int64(123), "aws_vpc_main").Return(nil)
-	mockNotify.On("Publish", domain.Notification{
-		Event:   "notification",
-		UserID:  int64(123),
-		Message: "added resource aws_vpc_main for customer with customerID 123",
-	}).Return(nil)
 
 	body := `{"resource_name":"aws_vpc_main"}`
 	req, _ := http.NewRequest("POST", "/customers/123/resources", bytes.NewBufferString(body))
@@ -114,8 +141,7 @@ func TestAddCloudResourceHandler_OK(t *testing.T) {
 func TestAddCloudResourceHandler_InvalidCustomerID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	r := gin.Default()
 	r.POST("/customers/:id/resources", handler.AddCloudResource)
@@ -137,8 +163,7 @@ func TestAddCloudResourcesHandler_CustomerNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -167,8 +192,7 @@ func TestAddCloudResourcesHandler_ResourceAlreadyExist(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -197,15 +221,14 @@ func TestGetResourcesByHandler_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
 	r.GET("/customers/:id/resources", handler.GetResourcesByCustomer)
 
-	mockUC.On("GetResourcesByCustomer", int64(1)).Return([]domain.Resource{
-		{ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1"}}, nil)
+	mockUC.On("GetResourcesByCustomer", int64(1), domain.ResourceQuery{}).Return(&domain.ResourcePage{Resources: []domain.Resource{
+		{ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1"}}}, nil)
 
 	req, _ := http.NewRequest("GET", "/customers/1/resources", nil)
 	req.Header.Set("Content-Type", "application/json")
@@ -231,11 +254,70 @@ func TestGetResourcesByHandler_OK(t *testing.T) {
 	mockUC.AssertExpectations(t)
 }
 
+func TestGetAllAvailableResourcesHandler_Paginated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.GET("/resources", handler.GetAllAvailableResources)
+
+	mockUC.On("GetAllAvailableResources", domain.ResourceQuery{
+		Region: "us-east-1", NamePrefix: "aws_", Sort: "-name", Limit: 1, After: "abc",
+	}).Return(&domain.ResourcePage{
+		Resources:  []domain.Resource{{ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1"}},
+		NextCursor: "def",
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/resources?region=us-east-1&name_prefix=aws_&sort=-name&limit=1&after=abc", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Len(t, resp["data"], 1)
+	assert.Equal(t, "def", resp["next_cursor"])
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestGetAllAvailableResourcesHandler_InvalidCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.GET("/resources", handler.GetAllAvailableResources)
+
+	mockUC.On("GetAllAvailableResources", domain.ResourceQuery{After: "bogus"}).Return(nil, usecase.ErrInvalidCursor)
+
+	req, _ := http.NewRequest("GET", "/resources?after=bogus", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "invalid cursor", resp["error"])
+
+	mockUC.AssertExpectations(t)
+}
+
 func TestGetResourcesByHandler_InvalidCustomerID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	r := gin.Default()
 	r.GET("/customers/:id/resources", handler.GetResourcesByCustomer)
@@ -257,14 +339,13 @@ func TestGetResourcesByHandler_CustomerNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
 	r.GET("/customers/:id/resources", handler.GetResourcesByCustomer)
 
-	mockUC.On("GetResourcesByCustomer", int64(1)).Return(nil, errors.New("customer not found"))
+	mockUC.On("GetResourcesByCustomer", int64(1), domain.ResourceQuery{}).Return(nil, errors.New("customer not found"))
 
 	req, _ := http.NewRequest("GET", "/customers/1/resources", nil)
 	req.Header.Set("Content-Type", "application/json")
@@ -286,17 +367,20 @@ func TestUpdateResourceHandler_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
 	r.PUT("/resources/:id", handler.UpdateResource)
 
-	mockUC.On("UpdateResource", int64(1), "aws_vpc_main", "VPC", "us-east-1").Return(&domain.Resource{
+	name, resourceType, region, provider := "aws_vpc_main", "VPC", "us-east-1", "aws"
+	mockUC.On("UpdateResource", int64(1), domain.ResourceUpdate{
+		Name: &name, Type: &resourceType, Region: &region, Provider: &provider,
+		Tags: map[string]string{"env": "prod"},
+	}).Return(&domain.Resource{
 		ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1"}, nil)
 
-	body := `{"name": "aws_vpc_main", "type": "VPC", "region": "us-east-1"}`
+	body := `{"name": "aws_vpc_main", "type": "VPC", "region": "us-east-1", "provider": "aws", "tags": {"env": "prod"}}`
 	req, _ := http.NewRequest("PUT", "/resources/1", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
@@ -316,8 +400,7 @@ func TestUpdateResourceHandler_InvalidResourceID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -345,8 +428,7 @@ func TestDeleteResourceHandler_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -373,8 +455,7 @@ func TestDeleteResourceHandler_InvalidResourceID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockResourceUsecase)
-	mockNotify := new(mockNotifier)
-	handler := rest.NewResourceHandler(mockUC, mockNotify)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -396,3 +477,314 @@ func TestDeleteResourceHandler_InvalidResourceID(t *testing.T) {
 
 	mockUC.AssertExpectations(t)
 }
+
+func TestRemoveCloudResourceHandler_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.DELETE("/customers/:id/resources/:resourceId", handler.RemoveCloudResource)
+
+	mockUC.On("RemoveCloudResource", int64(123), int64(1)).Return(&domain.Resource{ID: 1, Name: "aws_vpc_main"}, nil)
+
+	req, _ := http.NewRequest("DELETE", "/customers/123/resources/1", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "Resource removed successfully", resp["message"])
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestRemoveCloudResourceByNameHandler_NotAssigned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.DELETE("/customers/:id/resources/by-name/:name", handler.RemoveCloudResourceByName)
+
+	mockUC.On("RemoveCloudResourceByName", int64(123), "aws_vpc_main").Return(nil, usecase.ErrResourceNotAssigned)
+
+	req, _ := http.NewRequest("DELETE", "/customers/123/resources/by-name/aws_vpc_main", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestRemoveCloudResourceHandler_InvalidResourceID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.DELETE("/customers/:id/resources/:resourceId", handler.RemoveCloudResource)
+
+	req, _ := http.NewRequest("DELETE", "/customers/123/resources/abc", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "invalid resource id", resp["error"])
+}
+
+func TestAddCloudResourcesBatchHandler_OK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.POST("/customers/:id/resources", handler.AddCloudResource)
+	r.POST("/customers/:id/:method", handler.CustomerMethod)
+
+	names := []string{"aws_vpc_main", "gcp_vm_instance", "nope"}
+	mockUC.On("AddCloudResources", int64(123), names).Return([]domain.ResourceAssignment{
+		{Name: "aws_vpc_main", Status: domain.AssignmentAssigned},
+		{Name: "gcp_vm_instance", Status: domain.AssignmentAlreadyOwned},
+		{Name: "nope", Status: domain.AssignmentUnknown},
+	}, nil)
+
+	body := `{"resource_names":["aws_vpc_main","gcp_vm_instance","nope"]}`
+	req, _ := http.NewRequest("POST", "/customers/123/resources:batch", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "1 of 3 resources assigned", resp["message"])
+	data := resp["data"].([]interface{})
+	assert.Equal(t, "already_owned", data[1].(map[string]interface{})["status"])
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestAddCloudResourcesBatchHandler_NothingAssigned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.POST("/customers/:id/:method", handler.CustomerMethod)
+
+	mockUC.On("AddCloudResources", int64(123), []string{"nope"}).Return([]domain.ResourceAssignment{
+		{Name: "nope", Status: domain.AssignmentUnknown},
+	}, nil)
+
+	body := `{"resource_names":["nope"]}`
+	req, _ := http.NewRequest("POST", "/customers/123/resources:batch", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestCustomerMethodHandler_UnknownMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.POST("/customers/:id/:method", handler.CustomerMethod)
+
+	req, _ := http.NewRequest("POST", "/customers/123/resources:explode", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetResourcesByHandler_AsOf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.GET("/customers/:id/resources", handler.GetResourcesByCustomer)
+
+	asOf := time.Date(2024, 5, 7, 9, 30, 0, 0, time.FixedZone("", 2*60*60))
+	mockUC.On("GetResourcesByCustomer", int64(42), mock.MatchedBy(func(q domain.ResourceQuery) bool {
+		return q.AsOf.Equal(asOf)
+	})).Return(&domain.ResourcePage{}, nil)
+
+	req, _ := http.NewRequest("GET", "/customers/42/resources?as_of=2024-05-07T09:30:00%2B02:00", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/customers/42/resources?as_of=last-tuesday", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestGetAllResourcesHandler_MetadataFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.GET("/resources", handler.GetAllAvailableResources)
+
+	mockUC.On("GetAllAvailableResources", domain.ResourceQuery{
+		Provider:   "aws",
+		AccountID:  "123456789012",
+		Tags:       map[string]string{"env": "prod", "aws:cloudformation:stack-name": "core"},
+		Attributes: map[string]string{"instance_type": "t3.micro"},
+	}).Return(&domain.ResourcePage{}, nil)
+
+	req, _ := http.NewRequest("GET", "/resources?provider=aws&account_id=123456789012&tag=env=prod&tag=aws:cloudformation:stack-name=core&attribute=instance_type=t3.micro", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/resources?tag=env", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestGetResourceHistoryHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.GET("/resources/:id/history", handler.GetResourceHistory)
+
+	mockUC.On("GetResourceHistory", int64(1)).Return([]domain.ResourceVersion{
+		{ResourceID: 1, Name: "aws_vpc_main"},
+		{ResourceID: 1, Name: "aws_vpc_renamed"},
+	}, nil)
+	mockUC.On("GetResourceHistory", int64(2)).Return(nil, usecase.ErrResourceNotFound)
+
+	req, _ := http.NewRequest("GET", "/resources/1/history", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string][]domain.ResourceVersion
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Len(t, resp["data"], 2)
+
+	req, _ = http.NewRequest("GET", "/resources/2/history", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestRestoreResourceHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.POST("/resources/:id/restore", handler.RestoreResource)
+
+	mockUC.On("RestoreResource", int64(1)).Return(&domain.Resource{ID: 1, Name: "aws_vpc_main"}, nil)
+	mockUC.On("RestoreResource", int64(2)).Return(nil, usecase.ErrResourceNotDeleted)
+	mockUC.On("RestoreResource", int64(3)).Return(nil, usecase.ErrResourceNotFound)
+
+	for path, code := range map[string]int{
+		"/resources/1/restore":   http.StatusOK,
+		"/resources/2/restore":   http.StatusConflict,
+		"/resources/3/restore":   http.StatusNotFound,
+		"/resources/abc/restore": http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest("POST", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, path)
+	}
+
+	mockUC.AssertExpectations(t)
+}
+
+func TestImportResourcesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockResourceUsecase)
+	handler := rest.NewResourceHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.POST("/resources/import", handler.ImportResources)
+
+	mockUC.On("ImportResources", "aws-config", `{"configurationItems": []}`).Return(&domain.ImportSummary{Created: 2, Unchanged: 1}, nil)
+	mockUC.On("ImportResources", "aws-config", "{").Return(nil, usecase.ErrInvalidImport)
+
+	req, _ := http.NewRequest("POST", "/resources/import?format=aws-config", bytes.NewBufferString(`{"configurationItems": []}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data domain.ImportSummary `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, domain.ImportSummary{Created: 2, Unchanged: 1}, resp.Data)
+
+	req, _ = http.NewRequest("POST", "/resources/import?format=aws-config", bytes.NewBufferString("{"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest("POST", "/resources/import", bytes.NewBufferString("{}"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockUC.AssertExpectations(t)
+}
@@ -1,32 +1,99 @@
 package rest
 
 import (
+	"log/slog"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
-	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/service"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/health"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/metrics"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
 )
 
+// serviceName is reported as the server name on HTTP spans.
+const serviceName = "main-service"
+
 func NewRouter(
 	customerUC usecase.CustomerUsecase,
 	resourceUC usecase.ResourceUsecase,
-	notifier service.Notifier, // if you want to use it in the handlers
+	auditUC usecase.AuditUsecase,
+	inventoryUC usecase.InventoryUsecase,
+	checker *health.Checker,
+	authenticator *auth.Authenticator,
+	enforcer *auth.Enforcer,
+	logger *slog.Logger,
 ) *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(Recovery())
+
+	// Server spans, continuing the caller's trace
+	r.Use(tracing.GinMiddleware(serviceName))
+
+	// Request IDs and one log line per request
+	logging.Register(r, logger)
+
+	// Request metrics and GET /metrics
+	metrics.Register(r)
+
+	// Liveness and readiness probes
+	health.Register(r, checker)
 
-	apiRouter := r.Group("/api/v1/")
+	// Every API call needs an API key or a user token
+	apiRouter := r.Group("/api/v1/", auth.Middleware(authenticator))
+
+	// Operations spanning customers are reserved for services
+	serviceOnly := auth.RequireService()
+	// Users may only address their own customer
+	ownCustomer := auth.RequireCustomer("id")
+	// The caller's roles must allow the operation, see usecase.Policy
+	can := enforcer.Require
 
 	// Customer endpoints
-	customerHandler := NewCustomerHandler(customerUC)
-	apiRouter.POST("/customers", customerHandler.CreateCustomer)
-	apiRouter.GET("/customers/:id", customerHandler.GetCustomerByID)
+	customerHandler := NewCustomerHandler(customerUC, logger)
+	apiRouter.POST("/customers", serviceOnly, can(usecase.OpCreateCustomer), customerHandler.CreateCustomer)
+	apiRouter.GET("/customers", serviceOnly, can(usecase.OpListCustomers), customerHandler.ListCustomers)
+	apiRouter.GET("/customers/:id", ownCustomer, can(usecase.OpGetCustomer), customerHandler.GetCustomerByID)
+	apiRouter.PUT("/customers/:id", ownCustomer, can(usecase.OpUpdateCustomer), customerHandler.ReplaceCustomer)
+	apiRouter.PATCH("/customers/:id", ownCustomer, can(usecase.OpUpdateCustomer), customerHandler.PatchCustomer)
+	apiRouter.DELETE("/customers/:id", ownCustomer, can(usecase.OpDeleteCustomer), customerHandler.DeleteCustomer)
 
 	// Resource endpoints
-	resourceHandler := NewResourceHandler(resourceUC, notifier)
-	apiRouter.POST("/customers/:id/resources", resourceHandler.AddCloudResource)
-	apiRouter.GET("/customers/:id/resources", resourceHandler.GetResourcesByCustomer)
-	apiRouter.GET("/resources", resourceHandler.GetAllAvailableResources)
-	apiRouter.PUT("/resources/:id", resourceHandler.UpdateResource)
-	apiRouter.DELETE("/resources/:id", resourceHandler.DeleteResource)
+	resourceHandler := NewResourceHandler(resourceUC, logger)
+	apiRouter.POST("/customers/:id/resources", ownCustomer, can(usecase.OpAssignResource), resourceHandler.AddCloudResource)
+	apiRouter.POST("/customers/:id/:method", ownCustomer, can(usecase.OpAssignResource), resourceHandler.CustomerMethod) // resources:batch
+	apiRouter.GET("/customers/:id/resources", ownCustomer, can(usecase.OpListAssigned), resourceHandler.GetResourcesByCustomer)
+	apiRouter.DELETE("/customers/:id/resources/:resourceId", ownCustomer, can(usecase.OpRemoveResource), resourceHandler.RemoveCloudResource)
+	apiRouter.DELETE("/customers/:id/resources/by-name/:name", ownCustomer, can(usecase.OpRemoveResource), resourceHandler.RemoveCloudResourceByName)
+	apiRouter.GET("/resources", can(usecase.OpListResources), resourceHandler.GetAllAvailableResources)
+	apiRouter.POST("/resources/import", can(usecase.OpImportResources), resourceHandler.ImportResources)
+	apiRouter.GET("/resources/:id/history", can(usecase.OpListResources), resourceHandler.GetResourceHistory)
+	apiRouter.PUT("/resources/:id", can(usecase.OpUpdateResource), resourceHandler.UpdateResource)
+	apiRouter.DELETE("/resources/:id", can(usecase.OpDeleteResource), resourceHandler.DeleteResource)
+	apiRouter.POST("/resources/:id/restore", can(usecase.OpRestoreResource), resourceHandler.RestoreResource)
+
+	// Audit endpoints
+	auditHandler := NewAuditHandler(auditUC, logger)
+	apiRouter.GET("/audit", serviceOnly, can(usecase.OpReadAudit), auditHandler.ListAuditEvents)
+
+	// Export endpoints
+	inventoryHandler := NewInventoryHandler(inventoryUC, logger)
+	apiRouter.GET("/export", serviceOnly, can(usecase.OpExportInventory), inventoryHandler.Export)
 
 	return r
 }
+
+// Recovery is gin.Recovery, except that http.ErrAbortHandler is raised again
+// for net/http to cut the connection. Handlers streaming a response panic
+// with it when they fail half way, so that the client sees an incomplete
+// transfer rather than a short body that looks whole.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, err any) {
+		if err == http.ErrAbortHandler {
+			panic(err)
+		}
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
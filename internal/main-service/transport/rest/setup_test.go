@@ -10,9 +10,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/iBoBoTi/aqua-sec-inventory/cmd"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
 	_ "github.com/lib/pq"
 
+	dbpkg "github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -20,6 +23,9 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
+// testTimeouts disables per-operation deadlines for the integration tests.
+var testTimeouts = dbpkg.Timeouts{}
+
 func createPostgresContainer(t *testing.T, dbName, dbUser, dbPassword string, logger *slog.Logger) (string, string) {
 	t.Helper()
 	ctx := context.Background()
@@ -63,42 +69,11 @@ func setUpTestDB(t *testing.T, dbName, dbUser, dbPassword string) (*sql.DB, erro
 	db, err := sql.Open("postgres", dsn)
 	assert.NoError(t, err)
 
-	_, err = db.Exec(`
-CREATE TABLE IF NOT EXISTS customers (
-    id SERIAL PRIMARY KEY,
-    name VARCHAR(255) NOT NULL,
-    email VARCHAR(255) NOT NULL UNIQUE,
-    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS resources (
-    id SERIAL PRIMARY KEY,
-    name VARCHAR(255) NOT NULL UNIQUE,
-    type VARCHAR(100) NOT NULL,
-    region VARCHAR(100) NOT NULL,
-    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS customer_resource (
-    id SERIAL PRIMARY KEY, -- Optional auto-increment ID
-    customer_id INT NOT NULL,
-    resource_id INT NOT NULL,
-    created_at TIMESTAMP DEFAULT NOW(), -- Corrected default value
-    UNIQUE (customer_id, resource_id), -- Prevent duplicate relationships
-    FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
-    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE CASCADE
-);
-
-CREATE TABLE IF NOT EXISTS notifications (
-    id SERIAL PRIMARY KEY,
-    user_id INT NOT NULL,
-    message TEXT NOT NULL,
-    created_at TIMESTAMP NOT NULL DEFAULT NOW()
-);
-`)
-	assert.NoError(t, err)
+	// The schema comes from the migrations built into the binary
+	provider, err := dbpkg.NewMigrator(db, cmd.Migrations())
+	require.NoError(t, err)
+	_, err = provider.Up(context.Background())
+	require.NoError(t, err)
 
 	return db, nil
 }
@@ -147,3 +122,20 @@ func seedResource2(t *testing.T, db *sql.DB) domain.Resource {
 
 	return resource
 }
+
+func pendingOutboxMessages(t *testing.T, db *sql.DB) []string {
+
+	rows, err := db.Query(`SELECT payload->>'message' FROM outbox WHERE sent_at IS NULL ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var messages []string
+	for rows.Next() {
+		var message string
+		require.NoError(t, rows.Scan(&message))
+		messages = append(messages, message)
+	}
+	require.NoError(t, rows.Err())
+
+	return messages
+}
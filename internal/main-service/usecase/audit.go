@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+	// auditExportBatchSize is how many events ExportEvents reads per query.
+	auditExportBatchSize = 500
+)
+
+var ErrInvalidAuditFilter = errors.New("invalid audit filter")
+
+type AuditUsecase interface {
+	ListEvents(ctx context.Context, query domain.AuditQuery) (*domain.AuditPage, error)
+	// ExportEvents calls fn for every event matching query, newest first,
+	// reading them in batches so that large exports use bounded memory.
+	// Limit and After are ignored.
+	ExportEvents(ctx context.Context, query domain.AuditQuery, fn func(domain.AuditEvent) error) error
+}
+
+type auditUC struct {
+	auditRepo repository.AuditRepository
+	logger    *slog.Logger
+}
+
+func NewAuditUsecase(auditRepo repository.AuditRepository, logger *slog.Logger) AuditUsecase {
+	return &auditUC{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+func (uc *auditUC) ListEvents(ctx context.Context, query domain.AuditQuery) (*domain.AuditPage, error) {
+	filter, err := auditFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := query.Limit
+	if pageSize <= 0 {
+		pageSize = defaultAuditPageSize
+	}
+	if pageSize > maxAuditPageSize {
+		pageSize = maxAuditPageSize
+	}
+	if query.After != "" {
+		id, err := strconv.ParseInt(query.After, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		filter.BeforeID = id
+	}
+	// One extra row tells us whether another page follows
+	filter.Limit = pageSize + 1
+
+	events, err := uc.auditRepo.List(ctx, filter)
+	if err != nil {
+		uc.logger.ErrorContext(ctx, "error listing audit events", "error", err)
+		return nil, err
+	}
+
+	page := &domain.AuditPage{Events: events}
+	if len(events) > pageSize {
+		page.Events = events[:pageSize]
+		page.NextCursor = strconv.FormatInt(page.Events[pageSize-1].ID, 10)
+	}
+	return page, nil
+}
+
+func (uc *auditUC) ExportEvents(ctx context.Context, query domain.AuditQuery, fn func(domain.AuditEvent) error) error {
+	filter, err := auditFilter(query)
+	if err != nil {
+		return err
+	}
+	filter.Limit = auditExportBatchSize
+
+	for {
+		events, err := uc.auditRepo.List(ctx, filter)
+		if err != nil {
+			uc.logger.ErrorContext(ctx, "error exporting audit events", "error", err)
+			return err
+		}
+		for _, e := range events {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if len(events) < filter.Limit {
+			return nil
+		}
+		filter.BeforeID = events[len(events)-1].ID
+	}
+}
+
+// auditFilter validates the filters of an AuditQuery.
+func auditFilter(q domain.AuditQuery) (domain.AuditFilter, error) {
+	switch q.Action {
+	case "", domain.AuditActionCreate, domain.AuditActionUpdate, domain.AuditActionDelete,
+		domain.AuditActionRestore, domain.AuditActionPurge, domain.AuditActionAssign, domain.AuditActionUnassign:
+	default:
+		return domain.AuditFilter{}, fmt.Errorf("%w: unknown action %q", ErrInvalidAuditFilter, q.Action)
+	}
+	switch q.EntityType {
+	case "", domain.AuditEntityCustomer, domain.AuditEntityResource, domain.AuditEntityAssignment:
+	default:
+		return domain.AuditFilter{}, fmt.Errorf("%w: unknown entity type %q", ErrInvalidAuditFilter, q.EntityType)
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return domain.AuditFilter{}, fmt.Errorf("%w: since must be before until", ErrInvalidAuditFilter)
+	}
+
+	return domain.AuditFilter{
+		Actor:      q.Actor,
+		Action:     q.Action,
+		EntityType: q.EntityType,
+		EntityID:   q.EntityID,
+		CustomerID: q.CustomerID,
+		Since:      q.Since,
+		Until:      q.Until,
+	}, nil
+}
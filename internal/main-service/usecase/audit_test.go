@@ -0,0 +1,92 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+type mockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *mockAuditRepo) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AuditEvent), args.Error(1)
+}
+
+func auditEvents(ids ...int64) []domain.AuditEvent {
+	events := make([]domain.AuditEvent, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, domain.AuditEvent{ID: id})
+	}
+	return events
+}
+
+func TestListEvents_Paginates(t *testing.T) {
+	repo := new(mockAuditRepo)
+	uc := usecase.NewAuditUsecase(repo, logging.Discard())
+
+	repo.On("List", domain.AuditFilter{Actor: "alice", Limit: 3}).Return(auditEvents(9, 8, 7), nil)
+	repo.On("List", domain.AuditFilter{Actor: "alice", BeforeID: 8, Limit: 3}).Return(auditEvents(7), nil)
+
+	page, err := uc.ListEvents(context.Background(), domain.AuditQuery{Actor: "alice", Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Events, 2)
+	assert.Equal(t, "8", page.NextCursor)
+
+	page, err = uc.ListEvents(context.Background(), domain.AuditQuery{Actor: "alice", Limit: 2, After: page.NextCursor})
+	require.NoError(t, err)
+	assert.Len(t, page.Events, 1)
+	assert.Empty(t, page.NextCursor)
+
+	repo.AssertExpectations(t)
+}
+
+func TestListEvents_RejectsInvalidQuery(t *testing.T) {
+	repo := new(mockAuditRepo)
+	uc := usecase.NewAuditUsecase(repo, logging.Discard())
+
+	_, err := uc.ListEvents(context.Background(), domain.AuditQuery{Action: "rename"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidAuditFilter)
+
+	_, err = uc.ListEvents(context.Background(), domain.AuditQuery{EntityType: "user"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidAuditFilter)
+
+	_, err = uc.ListEvents(context.Background(), domain.AuditQuery{After: "abc"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidCursor)
+
+	repo.AssertExpectations(t)
+}
+
+func TestExportEvents_ReadsAllBatches(t *testing.T) {
+	repo := new(mockAuditRepo)
+	uc := usecase.NewAuditUsecase(repo, logging.Discard())
+
+	full := make([]int64, 500)
+	for i := range full {
+		full[i] = int64(1000 - i)
+	}
+	repo.On("List", domain.AuditFilter{Limit: 500}).Return(auditEvents(full...), nil)
+	repo.On("List", domain.AuditFilter{BeforeID: 501, Limit: 500}).Return(auditEvents(3, 2, 1), nil)
+
+	var seen int
+	err := uc.ExportEvents(context.Background(), domain.AuditQuery{Limit: 5, After: "ignored"}, func(domain.AuditEvent) error {
+		seen++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 503, seen)
+
+	repo.AssertExpectations(t)
+}
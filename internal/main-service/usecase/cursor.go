@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+)
+
+const (
+	defaultResourcePageSize = 50
+	maxResourcePageSize     = 200
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort option")
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+// resourceFilter validates a ResourceQuery and decodes its cursor. The limit
+// on the returned filter is one above the page size so the repository can
+// tell us whether another page follows.
+func resourceFilter(q domain.ResourceQuery) (domain.ResourceFilter, int, error) {
+	pageSize := q.Limit
+	if pageSize <= 0 {
+		pageSize = defaultResourcePageSize
+	}
+	if pageSize > maxResourcePageSize {
+		pageSize = maxResourcePageSize
+	}
+
+	sort := q.Sort
+	if sort == "" {
+		sort = domain.ResourceSortID
+	}
+	key := strings.TrimPrefix(sort, "-")
+	switch key {
+	case domain.ResourceSortID, domain.ResourceSortName, domain.ResourceSortCreatedAt:
+	default:
+		return domain.ResourceFilter{}, 0, ErrInvalidSort
+	}
+	provider := strings.TrimSpace(q.Provider)
+	if !validProvider(provider) {
+		return domain.ResourceFilter{}, 0, fmt.Errorf("%w: unknown provider %q", ErrInvalidFilter, q.Provider)
+	}
+
+	filter := domain.ResourceFilter{
+		Type:       strings.TrimSpace(q.Type),
+		Region:     strings.TrimSpace(q.Region),
+		NamePrefix: strings.TrimSpace(q.NamePrefix),
+		Provider:   provider,
+		AccountID:  strings.TrimSpace(q.AccountID),
+		NativeID:   strings.TrimSpace(q.NativeID),
+		Tags:       q.Tags,
+		Attributes: q.Attributes,
+		SortKey:    key,
+		Descending: strings.HasPrefix(sort, "-"),
+		Limit:      pageSize + 1,
+		// Timestamps are stored in UTC without a zone
+		AsOf: q.AsOf.UTC(),
+	}
+
+	if q.After != "" {
+		after, err := decodeResourceCursor(q.After)
+		if err != nil || after.Sort != sort {
+			return domain.ResourceFilter{}, 0, ErrInvalidCursor
+		}
+		filter.After = after
+	}
+	return filter, pageSize, nil
+}
+
+// resourcePage trims the look-ahead row and builds the cursor for the next
+// page from the last row kept.
+func resourcePage(resources []domain.Resource, pageSize int, sort string) *domain.ResourcePage {
+	page := &domain.ResourcePage{Resources: resources}
+	if len(resources) <= pageSize {
+		return page
+	}
+
+	page.Resources = resources[:pageSize]
+	last := page.Resources[pageSize-1]
+	if sort == "" {
+		sort = domain.ResourceSortID
+	}
+	cursor := domain.ResourceCursor{Sort: sort, ID: last.ID}
+	switch strings.TrimPrefix(sort, "-") {
+	case domain.ResourceSortName:
+		cursor.Name = last.Name
+	case domain.ResourceSortCreatedAt:
+		cursor.Time = last.CreatedAt
+	}
+	page.NextCursor = encodeResourceCursor(cursor)
+	return page
+}
+
+func encodeResourceCursor(c domain.ResourceCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeResourceCursor(s string) (*domain.ResourceCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c domain.ResourceCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -1,30 +1,47 @@
 package usecase
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"strings"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
 )
 
+const (
+	defaultCustomerPageSize = 20
+	maxCustomerPageSize     = 100
+)
+
+var (
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrEmailTaken       = errors.New("customer with this email already exists")
+	ErrInternal         = errors.New("internal server error")
+)
+
 type CustomerUsecase interface {
-	CreateCustomer(name, email string) (*domain.Customer, error)
-	GetCustomerByID(id int64) (*domain.Customer, error)
+	CreateCustomer(ctx context.Context, name, email string) (*domain.Customer, error)
+	GetCustomerByID(ctx context.Context, id int64) (*domain.Customer, error)
+	ListCustomers(ctx context.Context, filter domain.CustomerFilter) (*domain.CustomerPage, error)
+	UpdateCustomer(ctx context.Context, id int64, update domain.CustomerUpdate) (*domain.Customer, error)
+	DeleteCustomer(ctx context.Context, id int64) error
 }
 
 type customerUC struct {
 	customerRepo repository.CustomerRepository
+	logger       *slog.Logger
 }
 
-func NewCustomerUsecase(customerRepo repository.CustomerRepository) CustomerUsecase {
+func NewCustomerUsecase(customerRepo repository.CustomerRepository, logger *slog.Logger) CustomerUsecase {
 	return &customerUC{
 		customerRepo: customerRepo,
+		logger:       logger,
 	}
 }
 
-func (uc *customerUC) CreateCustomer(name, email string) (*domain.Customer, error) {
+func (uc *customerUC) CreateCustomer(ctx context.Context, name, email string) (*domain.Customer, error) {
 	// Basic validation
 	if strings.TrimSpace(name) == "" {
 		return nil, errors.New("name cannot be empty")
@@ -34,23 +51,96 @@ func (uc *customerUC) CreateCustomer(name, email string) (*domain.Customer, erro
 	}
 
 	// Check if email already exists
-	existing, _ := uc.customerRepo.GetByEmail(email)
+	existing, _ := uc.customerRepo.GetByEmail(ctx, email)
 	if existing != nil {
-		return nil, errors.New("customer with this email already exists")
+		return nil, ErrEmailTaken
 	}
 
 	c := &domain.Customer{
 		Name:  name,
 		Email: email,
 	}
-	if err := uc.customerRepo.Create(c); err != nil {
-		log.Println("Error creating customer: ", err)
-		return nil, errors.New("internal server error")
+	if err := uc.customerRepo.Create(ctx, c); err != nil {
+		uc.logger.ErrorContext(ctx, "error creating customer", "email", email, "error", err)
+		return nil, ErrInternal
+	}
+
+	uc.logger.InfoContext(ctx, "customer created", "customer_id", c.ID, "email", c.Email)
+	return c, nil
+}
+
+func (uc *customerUC) GetCustomerByID(ctx context.Context, id int64) (*domain.Customer, error) {
+	return uc.customerRepo.GetByID(ctx, id)
+}
+
+func (uc *customerUC) ListCustomers(ctx context.Context, filter domain.CustomerFilter) (*domain.CustomerPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultCustomerPageSize
+	}
+	if filter.Limit > maxCustomerPageSize {
+		filter.Limit = maxCustomerPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.Name = strings.TrimSpace(filter.Name)
+	filter.Email = strings.TrimSpace(filter.Email)
+
+	customers, total, err := uc.customerRepo.List(ctx, filter)
+	if err != nil {
+		uc.logger.ErrorContext(ctx, "error listing customers", "error", err)
+		return nil, ErrInternal
+	}
+	return &domain.CustomerPage{
+		Customers: customers,
+		Total:     total,
+		Limit:     filter.Limit,
+		Offset:    filter.Offset,
+	}, nil
+}
+
+func (uc *customerUC) UpdateCustomer(ctx context.Context, id int64, update domain.CustomerUpdate) (*domain.Customer, error) {
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+	if update.Email != nil && strings.TrimSpace(*update.Email) == "" {
+		return nil, errors.New("email cannot be empty")
+	}
+
+	c, err := uc.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrCustomerNotFound
 	}
 
+	if update.Email != nil && *update.Email != c.Email {
+		// Same uniqueness rule as CreateCustomer
+		existing, _ := uc.customerRepo.GetByEmail(ctx, *update.Email)
+		if existing != nil && existing.ID != id {
+			return nil, ErrEmailTaken
+		}
+		c.Email = *update.Email
+	}
+	if update.Name != nil {
+		c.Name = *update.Name
+	}
+
+	if err := uc.customerRepo.Update(ctx, c); err != nil {
+		uc.logger.ErrorContext(ctx, "error updating customer", "customer_id", id, "error", err)
+		return nil, ErrInternal
+	}
+	uc.logger.InfoContext(ctx, "customer updated", "customer_id", id)
 	return c, nil
 }
 
-func (uc *customerUC) GetCustomerByID(id int64) (*domain.Customer, error) {
-	return uc.customerRepo.GetByID(id)
+func (uc *customerUC) DeleteCustomer(ctx context.Context, id int64) error {
+	if _, err := uc.customerRepo.GetByID(ctx, id); err != nil {
+		return ErrCustomerNotFound
+	}
+
+	if err := uc.customerRepo.Delete(ctx, id); err != nil {
+		uc.logger.ErrorContext(ctx, "error deleting customer", "customer_id", id, "error", err)
+		return ErrInternal
+	}
+	uc.logger.InfoContext(ctx, "customer deleted", "customer_id", id)
+	return nil
 }
@@ -1,11 +1,14 @@
 package usecase_test
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -15,12 +18,12 @@ type mockCustomerRepo struct {
 	mock.Mock
 }
 
-func (m *mockCustomerRepo) Create(customer *domain.Customer) error {
+func (m *mockCustomerRepo) Create(ctx context.Context, customer *domain.Customer) error {
 	args := m.Called(customer)
 	return args.Error(0)
 }
 
-func (m *mockCustomerRepo) GetByID(id int64) (*domain.Customer, error) {
+func (m *mockCustomerRepo) GetByID(ctx context.Context, id int64) (*domain.Customer, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -28,7 +31,7 @@ func (m *mockCustomerRepo) GetByID(id int64) (*domain.Customer, error) {
 	return args.Get(0).(*domain.Customer), args.Error(1)
 }
 
-func (m *mockCustomerRepo) GetByEmail(email string) (*domain.Customer, error) {
+func (m *mockCustomerRepo) GetByEmail(ctx context.Context, email string) (*domain.Customer, error) {
 	args := m.Called(email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -36,14 +39,37 @@ func (m *mockCustomerRepo) GetByEmail(email string) (*domain.Customer, error) {
 	return args.Get(0).(*domain.Customer), args.Error(1)
 }
 
+func (m *mockCustomerRepo) List(ctx context.Context, filter domain.CustomerFilter) ([]domain.Customer, int64, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]domain.Customer), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockCustomerRepo) Update(ctx context.Context, customer *domain.Customer) error {
+	args := m.Called(customer)
+	return args.Error(0)
+}
+
+func (m *mockCustomerRepo) Delete(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockCustomerRepo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func TestCreateCustomer_OK(t *testing.T) {
 	repo := new(mockCustomerRepo)
-	uc := usecase.NewCustomerUsecase(repo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
 
 	repo.On("GetByEmail", "john@example.com").Return((*domain.Customer)(nil), errors.New("not found"))
 	repo.On("Create", mock.AnythingOfType("*domain.Customer")).Return(nil)
 
-	cust, err := uc.CreateCustomer("John", "john@example.com")
+	cust, err := uc.CreateCustomer(context.Background(), "John", "john@example.com")
 	assert.NoError(t, err)
 	assert.NotNil(t, cust)
 	assert.Equal(t, "John", cust.Name)
@@ -54,30 +80,30 @@ func TestCreateCustomer_OK(t *testing.T) {
 
 func TestCreateCustomer_DuplicateEmail(t *testing.T) {
 	repo := new(mockCustomerRepo)
-	uc := usecase.NewCustomerUsecase(repo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
 
 	existingCust := &domain.Customer{ID: 1, Name: "Existing", Email: "john@example.com"}
 	repo.On("GetByEmail", "john@example.com").Return(existingCust, nil)
 
-	cust, err := uc.CreateCustomer("John", "john@example.com")
+	cust, err := uc.CreateCustomer(context.Background(), "John", "john@example.com")
 	assert.Nil(t, cust)
 	assert.EqualError(t, err, "customer with this email already exists")
 }
 
 func TestCreateCustomer_EmptyName(t *testing.T) {
 	repo := new(mockCustomerRepo)
-	uc := usecase.NewCustomerUsecase(repo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
 
-	cust, err := uc.CreateCustomer("", "john@example.com")
+	cust, err := uc.CreateCustomer(context.Background(), "", "john@example.com")
 	assert.EqualError(t, err, "name cannot be empty")
 	assert.Nil(t, cust)
 }
 
 func TestCreateCustomer_EmptyEmail(t *testing.T) {
 	repo := new(mockCustomerRepo)
-	uc := usecase.NewCustomerUsecase(repo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
 
-	cust, err := uc.CreateCustomer("John", "")
+	cust, err := uc.CreateCustomer(context.Background(), "John", "")
 	assert.EqualError(t, err, "email cannot be empty")
 	assert.Nil(t, cust)
 
@@ -86,14 +112,101 @@ func TestCreateCustomer_EmptyEmail(t *testing.T) {
 func TestGetCustomerByIDUsecase_OK(t *testing.T) {
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewCustomerUsecase(customerRepo)
+	uc := usecase.NewCustomerUsecase(customerRepo, logging.Discard())
 
 	// Customer exists
 	customerRepo.On("GetByID", int64(123)).Return(&domain.Customer{ID: 123}, nil)
 
-	resource, err := uc.GetCustomerByID(123)
+	resource, err := uc.GetCustomerByID(context.Background(), 123)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, resource)
 
 	customerRepo.AssertExpectations(t)
 }
+
+func TestListCustomers_DefaultsAndClamp(t *testing.T) {
+	repo := new(mockCustomerRepo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
+
+	repo.On("List", domain.CustomerFilter{Name: "john", Limit: 100}).
+		Return([]domain.Customer{{ID: 1, Name: "John"}}, int64(1), nil)
+
+	page, err := uc.ListCustomers(context.Background(), domain.CustomerFilter{Name: " john ", Limit: 500, Offset: -3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), page.Total)
+	assert.Equal(t, 100, page.Limit)
+	assert.Len(t, page.Customers, 1)
+
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateCustomer_OK(t *testing.T) {
+	repo := new(mockCustomerRepo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
+
+	name := "Johnny"
+	email := "johnny@example.com"
+	repo.On("GetByID", int64(1)).Return(&domain.Customer{ID: 1, Name: "John", Email: "john@example.com"}, nil)
+	repo.On("GetByEmail", email).Return((*domain.Customer)(nil), errors.New("not found"))
+	repo.On("Update", &domain.Customer{ID: 1, Name: name, Email: email}).Return(nil)
+
+	cust, err := uc.UpdateCustomer(context.Background(), 1, domain.CustomerUpdate{Name: &name, Email: &email})
+	assert.NoError(t, err)
+	assert.Equal(t, name, cust.Name)
+	assert.Equal(t, email, cust.Email)
+
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateCustomer_DuplicateEmail(t *testing.T) {
+	repo := new(mockCustomerRepo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
+
+	email := "taken@example.com"
+	repo.On("GetByID", int64(1)).Return(&domain.Customer{ID: 1, Name: "John", Email: "john@example.com"}, nil)
+	repo.On("GetByEmail", email).Return(&domain.Customer{ID: 2, Email: email}, nil)
+
+	cust, err := uc.UpdateCustomer(context.Background(), 1, domain.CustomerUpdate{Email: &email})
+	assert.Nil(t, cust)
+	assert.ErrorIs(t, err, usecase.ErrEmailTaken)
+
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateCustomer_NotFound(t *testing.T) {
+	repo := new(mockCustomerRepo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
+
+	name := "Johnny"
+	repo.On("GetByID", int64(9)).Return((*domain.Customer)(nil), errors.New("no rows in result set"))
+
+	_, err := uc.UpdateCustomer(context.Background(), 9, domain.CustomerUpdate{Name: &name})
+	assert.ErrorIs(t, err, usecase.ErrCustomerNotFound)
+
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteCustomer_OK(t *testing.T) {
+	repo := new(mockCustomerRepo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
+
+	repo.On("GetByID", int64(1)).Return(&domain.Customer{ID: 1}, nil)
+	repo.On("Delete", int64(1)).Return(nil)
+
+	err := uc.DeleteCustomer(context.Background(), 1)
+	assert.NoError(t, err)
+
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteCustomer_NotFound(t *testing.T) {
+	repo := new(mockCustomerRepo)
+	uc := usecase.NewCustomerUsecase(repo, logging.Discard())
+
+	repo.On("GetByID", int64(9)).Return((*domain.Customer)(nil), errors.New("no rows in result set"))
+
+	err := uc.DeleteCustomer(context.Background(), 9)
+	assert.ErrorIs(t, err, usecase.ErrCustomerNotFound)
+
+	repo.AssertExpectations(t)
+}
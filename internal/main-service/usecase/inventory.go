@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
+)
+
+// inventoryExportBatchSize is how many rows Export reads per query.
+const inventoryExportBatchSize = 500
+
+type InventoryUsecase interface {
+	// Export calls fn for every customer and assigned resource matching
+	// query, by customer and then resource ID, reading them in batches so
+	// that large exports use bounded memory.
+	Export(ctx context.Context, query domain.InventoryQuery, fn func(domain.InventoryRow) error) error
+}
+
+type inventoryUC struct {
+	inventoryRepo repository.InventoryRepository
+	logger        *slog.Logger
+}
+
+func NewInventoryUsecase(inventoryRepo repository.InventoryRepository, logger *slog.Logger) InventoryUsecase {
+	return &inventoryUC{
+		inventoryRepo: inventoryRepo,
+		logger:        logger,
+	}
+}
+
+func (uc *inventoryUC) Export(ctx context.Context, query domain.InventoryQuery, fn func(domain.InventoryRow) error) error {
+	if !validProvider(query.Provider) {
+		return fmt.Errorf("%w: unknown provider %q", ErrInvalidFilter, query.Provider)
+	}
+	filter := domain.InventoryFilter{
+		CustomerID: query.CustomerID,
+		Provider:   query.Provider,
+		AccountID:  query.AccountID,
+		Type:       query.Type,
+		Region:     query.Region,
+		Tags:       query.Tags,
+		Limit:      inventoryExportBatchSize,
+	}
+
+	for {
+		rows, err := uc.inventoryRepo.List(ctx, filter)
+		if err != nil {
+			uc.logger.ErrorContext(ctx, "error exporting inventory", "error", err)
+			return err
+		}
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if len(rows) < filter.Limit {
+			return nil
+		}
+		last := rows[len(rows)-1]
+		filter.AfterCustomerID = last.Customer.ID
+		filter.AfterResourceID = 0
+		if last.Resource != nil {
+			filter.AfterResourceID = last.Resource.ID
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+type mockInventoryRepo struct {
+	mock.Mock
+}
+
+func (m *mockInventoryRepo) List(ctx context.Context, filter domain.InventoryFilter) ([]domain.InventoryRow, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.InventoryRow), args.Error(1)
+}
+
+// inventoryRows returns n rows of one customer with resources 1 to n,
+// followed by a customer without resources when empty is set.
+func inventoryRows(customerID int64, n int, empty bool) []domain.InventoryRow {
+	rows := make([]domain.InventoryRow, 0, n+1)
+	for i := 1; i <= n; i++ {
+		rows = append(rows, domain.InventoryRow{
+			Customer: domain.Customer{ID: customerID},
+			Resource: &domain.Resource{ID: int64(i)},
+		})
+	}
+	if empty {
+		rows = append(rows, domain.InventoryRow{Customer: domain.Customer{ID: customerID + 1}})
+	}
+	return rows
+}
+
+func TestExportInventory_ReadsInBatches(t *testing.T) {
+	repo := new(mockInventoryRepo)
+	uc := usecase.NewInventoryUsecase(repo, logging.Discard())
+
+	// A full batch ending on a customer without resources, then a short one
+	repo.On("List", domain.InventoryFilter{Provider: "aws", Limit: 500}).Return(inventoryRows(1, 499, true), nil)
+	repo.On("List", domain.InventoryFilter{Provider: "aws", AfterCustomerID: 2, AfterResourceID: 0, Limit: 500}).
+		Return(inventoryRows(3, 2, false), nil)
+
+	count := 0
+	err := uc.Export(context.Background(), domain.InventoryQuery{Provider: "aws"}, func(domain.InventoryRow) error {
+		count++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 502, count)
+
+	repo.AssertExpectations(t)
+}
+
+func TestExportInventory_UnknownProvider(t *testing.T) {
+	repo := new(mockInventoryRepo)
+	uc := usecase.NewInventoryUsecase(repo, logging.Discard())
+
+	err := uc.Export(context.Background(), domain.InventoryQuery{Provider: "ibm"}, func(domain.InventoryRow) error { return nil })
+	assert.ErrorIs(t, err, usecase.ErrInvalidFilter)
+	repo.AssertNotCalled(t, "List", mock.Anything)
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import "github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+
+// Operations on the usecases subject to role checks.
+const (
+	OpCreateCustomer  auth.Operation = "CustomerUsecase.CreateCustomer"
+	OpGetCustomer     auth.Operation = "CustomerUsecase.GetCustomerByID"
+	OpListCustomers   auth.Operation = "CustomerUsecase.ListCustomers"
+	OpUpdateCustomer  auth.Operation = "CustomerUsecase.UpdateCustomer"
+	OpDeleteCustomer  auth.Operation = "CustomerUsecase.DeleteCustomer"
+	OpListResources   auth.Operation = "ResourceUsecase.GetAllAvailableResources"
+	OpListAssigned    auth.Operation = "ResourceUsecase.GetResourcesByCustomer"
+	OpAssignResource  auth.Operation = "ResourceUsecase.AddCloudResource"
+	OpRemoveResource  auth.Operation = "ResourceUsecase.RemoveCloudResource"
+	OpUpdateResource  auth.Operation = "ResourceUsecase.UpdateResource"
+	OpDeleteResource  auth.Operation = "ResourceUsecase.DeleteResource"
+	OpRestoreResource auth.Operation = "ResourceUsecase.RestoreResource"
+	OpImportResources auth.Operation = "ResourceUsecase.ImportResources"
+	OpReadAudit       auth.Operation = "AuditUsecase.ListEvents"
+	OpExportInventory auth.Operation = "InventoryUsecase.Export"
+)
+
+var (
+	allRoles    = []auth.Role{auth.RoleAdmin, auth.RoleOperator, auth.RoleCustomerViewer}
+	writerRoles = []auth.Role{auth.RoleAdmin, auth.RoleOperator}
+	adminRoles  = []auth.Role{auth.RoleAdmin}
+)
+
+// Policy maps each operation to the roles allowed to perform it. Changes to
+// the resource catalogue affect every customer and are reserved for admins.
+var Policy = auth.Policy{
+	OpCreateCustomer:  writerRoles,
+	OpGetCustomer:     allRoles,
+	OpListCustomers:   allRoles,
+	OpUpdateCustomer:  writerRoles,
+	OpDeleteCustomer:  adminRoles,
+	OpListResources:   allRoles,
+	OpListAssigned:    allRoles,
+	OpAssignResource:  writerRoles,
+	OpRemoveResource:  writerRoles,
+	OpUpdateResource:  adminRoles,
+	OpDeleteResource:  adminRoles,
+	OpRestoreResource: adminRoles,
+	OpImportResources: adminRoles,
+	OpReadAudit:       adminRoles,
+	OpExportInventory: allRoles,
+}
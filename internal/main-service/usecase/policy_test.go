@@ -0,0 +1,38 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+)
+
+func TestPolicy_CatalogMutationsNeedAdmin(t *testing.T) {
+	for _, op := range []auth.Operation{usecase.OpUpdateResource, usecase.OpDeleteResource, usecase.OpRestoreResource, usecase.OpImportResources} {
+		assert.True(t, usecase.Policy.Allows([]auth.Role{auth.RoleAdmin}, op), op)
+		assert.False(t, usecase.Policy.Allows([]auth.Role{auth.RoleOperator}, op), op)
+		assert.False(t, usecase.Policy.Allows([]auth.Role{auth.RoleCustomerViewer}, op), op)
+	}
+}
+
+func TestPolicy_ViewerIsReadOnly(t *testing.T) {
+	viewer := []auth.Role{auth.RoleCustomerViewer}
+
+	for _, op := range []auth.Operation{usecase.OpGetCustomer, usecase.OpListCustomers, usecase.OpListResources, usecase.OpListAssigned, usecase.OpExportInventory} {
+		assert.True(t, usecase.Policy.Allows(viewer, op), op)
+	}
+	for _, op := range []auth.Operation{usecase.OpCreateCustomer, usecase.OpUpdateCustomer, usecase.OpDeleteCustomer, usecase.OpAssignResource, usecase.OpRemoveResource} {
+		assert.False(t, usecase.Policy.Allows(viewer, op), op)
+	}
+}
+
+func TestPolicy_OperatorManagesAssignments(t *testing.T) {
+	operator := []auth.Role{auth.RoleOperator}
+
+	assert.True(t, usecase.Policy.Allows(operator, usecase.OpAssignResource))
+	assert.True(t, usecase.Policy.Allows(operator, usecase.OpRemoveResource))
+	assert.True(t, usecase.Policy.Allows(operator, usecase.OpUpdateCustomer))
+	assert.False(t, usecase.Policy.Allows(operator, usecase.OpDeleteCustomer))
+}
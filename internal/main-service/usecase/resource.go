@@ -1,57 +1,110 @@
 package usecase
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"strings"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/importer"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/repository"
 )
 
 type ResourceUsecase interface {
-	GetAllAvailableResources() ([]domain.Resource, error)
-	AddCloudResources(customerID int64, resourceNames []string) error
-	GetResourcesByCustomer(customerID int64) ([]domain.Resource, error)
-	UpdateResource(resourceID int64, name, resourceType, region string) (*domain.Resource, error)
-	DeleteResource(resourceID int64) error
-	AddCloudResource(customerID int64, resourceName string) error
+	GetAllAvailableResources(ctx context.Context, query domain.ResourceQuery) (*domain.ResourcePage, error)
+	AddCloudResources(ctx context.Context, customerID int64, resourceNames []string) ([]domain.ResourceAssignment, error)
+	GetResourcesByCustomer(ctx context.Context, customerID int64, query domain.ResourceQuery) (*domain.ResourcePage, error)
+	UpdateResource(ctx context.Context, resourceID int64, update domain.ResourceUpdate) (*domain.Resource, error)
+	DeleteResource(ctx context.Context, resourceID int64) error
+	RestoreResource(ctx context.Context, resourceID int64) (*domain.Resource, error)
+	AddCloudResource(ctx context.Context, customerID int64, resourceName string) error
+	RemoveCloudResource(ctx context.Context, customerID, resourceID int64) (*domain.Resource, error)
+	RemoveCloudResourceByName(ctx context.Context, customerID int64, resourceName string) (*domain.Resource, error)
+	GetResourceHistory(ctx context.Context, resourceID int64) ([]domain.ResourceVersion, error)
+	ImportResources(ctx context.Context, format string, r io.Reader) (*domain.ImportSummary, error)
 }
 
+const maxBatchAssignSize = 100
+
+var (
+	ErrResourceNotFound    = errors.New("resource not found")
+	ErrResourceNotAssigned = errors.New("customer does not have this resource")
+	ErrResourceNotDeleted  = errors.New("resource is not deleted")
+	ErrInvalidImport       = errors.New("invalid import")
+)
+
 type resourceUC struct {
 	resourceRepo repository.ResourceRepository
 	customerRepo repository.CustomerRepository
+	logger       *slog.Logger
 }
 
-func NewResourceUsecase(resourceRepo repository.ResourceRepository, customerRepo repository.CustomerRepository) ResourceUsecase {
+func NewResourceUsecase(resourceRepo repository.ResourceRepository, customerRepo repository.CustomerRepository, logger *slog.Logger) ResourceUsecase {
 	return &resourceUC{
 		resourceRepo: resourceRepo,
 		customerRepo: customerRepo,
+		logger:       logger,
 	}
 }
 
-func (uc *resourceUC) GetAllAvailableResources() ([]domain.Resource, error) {
-	return uc.resourceRepo.GetAll()
+func (uc *resourceUC) GetAllAvailableResources(ctx context.Context, query domain.ResourceQuery) (*domain.ResourcePage, error) {
+	filter, pageSize, err := resourceFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := uc.resourceRepo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return resourcePage(resources, pageSize, query.Sort), nil
 }
 
-func (uc *resourceUC) AddCloudResources(customerID int64, resourceNames []string) error {
+// AddCloudResources assigns a batch of resources to a customer in one
+// transaction. Blank and duplicate names are dropped before the lookup.
+func (uc *resourceUC) AddCloudResources(ctx context.Context, customerID int64, resourceNames []string) ([]domain.ResourceAssignment, error) {
 	// Check if customer exists
-	_, err := uc.customerRepo.GetByID(customerID)
+	_, err := uc.customerRepo.GetByID(ctx, customerID)
 	if err != nil {
-		return errors.New("customer not found")
+		return nil, ErrCustomerNotFound
+	}
+
+	names := make([]string, 0, len(resourceNames))
+	seen := make(map[string]bool, len(resourceNames))
+	for _, name := range resourceNames {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
 	}
 
 	// Validate resourceNames
-	if len(resourceNames) == 0 {
-		return errors.New("no resource names provided")
+	if len(names) == 0 {
+		return nil, errors.New("no resource names provided")
+	}
+	if len(names) > maxBatchAssignSize {
+		return nil, fmt.Errorf("cannot assign more than %d resources at once", maxBatchAssignSize)
 	}
 
-	return uc.resourceRepo.AddResourcesToCustomer(resourceNames, customerID)
+	results, err := uc.resourceRepo.AddResourcesToCustomer(ctx, names, customerID)
+	if err != nil {
+		uc.logger.ErrorContext(ctx, "error assigning resources", "customer_id", customerID, "error", err)
+		return nil, err
+	}
+	uc.logger.InfoContext(ctx, "resources assigned", "customer_id", customerID, "requested", len(names))
+	return results, nil
 }
 
-func (uc *resourceUC) AddCloudResource(customerID int64, resourceName string) error {
+func (uc *resourceUC) AddCloudResource(ctx context.Context, customerID int64, resourceName string) error {
 	// Check if customer exists
-	_, err := uc.customerRepo.GetByID(customerID)
+	_, err := uc.customerRepo.GetByID(ctx, customerID)
 	if err != nil {
 		return errors.New("customer not found")
 	}
@@ -61,7 +114,7 @@ func (uc *resourceUC) AddCloudResource(customerID int64, resourceName string) er
 		return errors.New("no resource name provided")
 	}
 	// Get customer resource by name if it exist send resource already exist error
-	exist, err := uc.resourceRepo.DoesCustomerHaveResource(customerID, resourceName)
+	exist, err := uc.resourceRepo.DoesCustomerHaveResource(ctx, customerID, resourceName)
 	if err != nil {
 		return err
 	}
@@ -70,54 +123,223 @@ func (uc *resourceUC) AddCloudResource(customerID int64, resourceName string) er
 		return fmt.Errorf("customer already has %s resource", resourceName)
 	}
 
-	return uc.resourceRepo.AddResourceToCustomer(resourceName, customerID)
+	if err := uc.resourceRepo.AddResourceToCustomer(ctx, resourceName, customerID); err != nil {
+		uc.logger.ErrorContext(ctx, "error assigning resource", "customer_id", customerID, "resource", resourceName, "error", err)
+		return err
+	}
+	uc.logger.InfoContext(ctx, "resource assigned", "customer_id", customerID, "resource", resourceName)
+	return nil
 }
 
-func (uc *resourceUC) GetResourcesByCustomer(customerID int64) ([]domain.Resource, error) {
+func (uc *resourceUC) GetResourcesByCustomer(ctx context.Context, customerID int64, query domain.ResourceQuery) (*domain.ResourcePage, error) {
 	// Check if customer exists
-	_, err := uc.customerRepo.GetByID(customerID)
+	_, err := uc.customerRepo.GetByID(ctx, customerID)
 	if err != nil {
 		return nil, errors.New("customer not found")
 	}
 
-	return uc.resourceRepo.GetResourcesByCustomer(customerID)
+	filter, pageSize, err := resourceFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := uc.resourceRepo.GetResourcesByCustomer(ctx, customerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return resourcePage(resources, pageSize, query.Sort), nil
 }
 
-func (uc *resourceUC) UpdateResource(resourceID int64, name, resourceType, region string) (*domain.Resource, error) {
+func (uc *resourceUC) UpdateResource(ctx context.Context, resourceID int64, update domain.ResourceUpdate) (*domain.Resource, error) {
 	// Basic validations
-	if strings.TrimSpace(name) == "" {
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
 		return nil, errors.New("name cannot be empty")
 	}
-	if strings.TrimSpace(resourceType) == "" {
+	if update.Type != nil && strings.TrimSpace(*update.Type) == "" {
 		return nil, errors.New("type cannot be empty")
 	}
-	if strings.TrimSpace(region) == "" {
+	if update.Region != nil && strings.TrimSpace(*update.Region) == "" {
 		return nil, errors.New("region cannot be empty")
 	}
+	if update.Provider != nil && !validProvider(*update.Provider) {
+		return nil, fmt.Errorf("unknown provider %q", *update.Provider)
+	}
+	if err := validateResourceMetadata(update.Tags, update.Attributes); err != nil {
+		return nil, err
+	}
 
 	// Check if resource exists
-	res, err := uc.resourceRepo.GetByID(resourceID)
+	res, err := uc.resourceRepo.GetByID(ctx, resourceID)
 	if err != nil {
 		return nil, errors.New("resource not found")
 	}
 
 	// Update resource
-	res.Name = name
-	res.Type = resourceType
-	res.Region = region
+	if update.Name != nil {
+		res.Name = *update.Name
+	}
+	if update.Type != nil {
+		res.Type = *update.Type
+	}
+	if update.Region != nil {
+		res.Region = *update.Region
+	}
+	if update.Provider != nil {
+		res.Provider = *update.Provider
+	}
+	if update.AccountID != nil {
+		res.AccountID = *update.AccountID
+	}
+	if update.NativeID != nil {
+		res.NativeID = *update.NativeID
+	}
+	if update.Tags != nil {
+		res.Tags = update.Tags
+	}
+	if update.Attributes != nil {
+		res.Attributes = update.Attributes
+	}
 
-	if err := uc.resourceRepo.Update(res); err != nil {
+	if err := uc.resourceRepo.Update(ctx, res); err != nil {
+		uc.logger.ErrorContext(ctx, "error updating resource", "resource_id", resourceID, "error", err)
 		return nil, err
 	}
+	uc.logger.InfoContext(ctx, "resource updated", "resource_id", resourceID)
 	return res, nil
 }
 
-func (uc *resourceUC) DeleteResource(resourceID int64) error {
+func (uc *resourceUC) DeleteResource(ctx context.Context, resourceID int64) error {
 	// Check if resource exists
-	_, err := uc.resourceRepo.GetByID(resourceID)
+	_, err := uc.resourceRepo.GetByID(ctx, resourceID)
 	if err != nil {
 		return errors.New("resource not found")
 	}
 
-	return uc.resourceRepo.Delete(resourceID)
+	if err := uc.resourceRepo.Delete(ctx, resourceID); err != nil {
+		uc.logger.ErrorContext(ctx, "error deleting resource", "resource_id", resourceID, "error", err)
+		return err
+	}
+	uc.logger.InfoContext(ctx, "resource deleted", "resource_id", resourceID)
+	return nil
+}
+
+// RestoreResource undeletes a deleted resource together with the
+// assignments its deletion removed.
+func (uc *resourceUC) RestoreResource(ctx context.Context, resourceID int64) (*domain.Resource, error) {
+	res, err := uc.resourceRepo.Restore(ctx, resourceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, errGet := uc.resourceRepo.GetByID(ctx, resourceID); errGet == nil {
+				return nil, ErrResourceNotDeleted
+			}
+			return nil, ErrResourceNotFound
+		}
+		uc.logger.ErrorContext(ctx, "error restoring resource", "resource_id", resourceID, "error", err)
+		return nil, err
+	}
+	uc.logger.InfoContext(ctx, "resource restored", "resource_id", resourceID)
+	return res, nil
+}
+
+func (uc *resourceUC) RemoveCloudResource(ctx context.Context, customerID, resourceID int64) (*domain.Resource, error) {
+	res, err := uc.resourceRepo.GetByID(ctx, resourceID)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+	return uc.removeCloudResource(ctx, customerID, res)
+}
+
+func (uc *resourceUC) RemoveCloudResourceByName(ctx context.Context, customerID int64, resourceName string) (*domain.Resource, error) {
+	if strings.TrimSpace(resourceName) == "" {
+		return nil, errors.New("no resource name provided")
+	}
+
+	res, err := uc.resourceRepo.GetByName(ctx, resourceName)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+	return uc.removeCloudResource(ctx, customerID, res)
+}
+
+func (uc *resourceUC) removeCloudResource(ctx context.Context, customerID int64, res *domain.Resource) (*domain.Resource, error) {
+	// Check if customer exists
+	if _, err := uc.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, ErrCustomerNotFound
+	}
+
+	if err := uc.resourceRepo.RemoveResourceFromCustomer(ctx, customerID, res.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrResourceNotAssigned
+		}
+		uc.logger.ErrorContext(ctx, "error removing resource", "customer_id", customerID, "resource_id", res.ID, "error", err)
+		return nil, err
+	}
+	uc.logger.InfoContext(ctx, "resource removed", "customer_id", customerID, "resource_id", res.ID)
+	return res, nil
+}
+
+// GetResourceHistory returns every recorded version of the resource, oldest
+// first. The history outlives the resource, so it is available after a
+// deletion too.
+func (uc *resourceUC) GetResourceHistory(ctx context.Context, resourceID int64) ([]domain.ResourceVersion, error) {
+	versions, err := uc.resourceRepo.GetHistory(ctx, resourceID)
+	if err != nil {
+		uc.logger.ErrorContext(ctx, "error reading resource history", "resource_id", resourceID, "error", err)
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrResourceNotFound
+	}
+	return versions, nil
+}
+
+// ImportResources reads a provider inventory export in the given format and
+// upserts its resources into the catalogue in batches. Running the same
+// import twice leaves everything unchanged the second time, so a failed
+// import can be retried.
+func (uc *resourceUC) ImportResources(ctx context.Context, format string, r io.Reader) (*domain.ImportSummary, error) {
+	resources, err := importer.Parse(format, r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+	if len(resources) == 0 {
+		return &domain.ImportSummary{}, nil
+	}
+
+	summary, err := uc.resourceRepo.Import(ctx, resources)
+	if err != nil {
+		uc.logger.ErrorContext(ctx, "error importing resources", "format", format, "created", summary.Created,
+			"updated", summary.Updated, "unchanged", summary.Unchanged, "skipped", summary.Skipped, "error", err)
+		return nil, err
+	}
+	uc.logger.InfoContext(ctx, "resources imported", "format", format, "created", summary.Created,
+		"updated", summary.Updated, "unchanged", summary.Unchanged, "skipped", summary.Skipped)
+	return &summary, nil
+}
+
+// validProvider reports whether p is a known cloud provider. An empty
+// provider is allowed for resources not tied to one.
+func validProvider(p string) bool {
+	switch p {
+	case "", domain.ProviderAWS, domain.ProviderGCP, domain.ProviderAzure:
+		return true
+	}
+	return false
+}
+
+// validateResourceMetadata checks that tag keys are not blank and that the
+// attributes, when given, are a JSON object.
+func validateResourceMetadata(tags map[string]string, attributes json.RawMessage) error {
+	for k := range tags {
+		if strings.TrimSpace(k) == "" {
+			return errors.New("tag keys cannot be empty")
+		}
+	}
+	if attributes != nil {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(attributes, &obj); err != nil || obj == nil {
+			return errors.New("attributes must be a JSON object")
+		}
+	}
+	return nil
 }
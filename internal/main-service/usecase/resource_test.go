@@ -1,14 +1,19 @@
 package usecase_test
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/main-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
 )
 
 // Mock for ResourceRepository
@@ -16,20 +21,23 @@ type mockResourceRepo struct {
 	mock.Mock
 }
 
-func (m *mockResourceRepo) AddResourcesToCustomer(resourceNames []string, customerID int64) error {
+func (m *mockResourceRepo) AddResourcesToCustomer(ctx context.Context, resourceNames []string, customerID int64) ([]domain.ResourceAssignment, error) {
 	args := m.Called(resourceNames, customerID)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ResourceAssignment), args.Error(1)
 }
 
-func (m *mockResourceRepo) GetResourcesByCustomer(customerID int64) ([]domain.Resource, error) {
-	args := m.Called(customerID)
+func (m *mockResourceRepo) GetResourcesByCustomer(ctx context.Context, customerID int64, filter domain.ResourceFilter) ([]domain.Resource, error) {
+	args := m.Called(customerID, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]domain.Resource), args.Error(1)
 }
 
-func (m *mockResourceRepo) GetByID(resourceID int64) (*domain.Resource, error) {
+func (m *mockResourceRepo) GetByID(ctx context.Context, resourceID int64) (*domain.Resource, error) {
 	args := m.Called(resourceID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -37,25 +45,25 @@ func (m *mockResourceRepo) GetByID(resourceID int64) (*domain.Resource, error) {
 	return args.Get(0).(*domain.Resource), args.Error(1)
 }
 
-func (m *mockResourceRepo) Update(r *domain.Resource) error {
+func (m *mockResourceRepo) Update(ctx context.Context, r *domain.Resource) error {
 	args := m.Called(r)
 	return args.Error(0)
 }
 
-func (m *mockResourceRepo) Delete(resourceID int64) error {
+func (m *mockResourceRepo) Delete(ctx context.Context, resourceID int64) error {
 	args := m.Called(resourceID)
 	return args.Error(0)
 }
 
-func (m *mockResourceRepo) GetAll() ([]domain.Resource, error) {
-	args := m.Called()
+func (m *mockResourceRepo) GetAll(ctx context.Context, filter domain.ResourceFilter) ([]domain.Resource, error) {
+	args := m.Called(filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]domain.Resource), args.Error(1)
 }
 
-func (m *mockResourceRepo) GetByName(name string) (*domain.Resource, error) {
+func (m *mockResourceRepo) GetByName(ctx context.Context, name string) (*domain.Resource, error) {
 	args := m.Called(name)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -63,12 +71,12 @@ func (m *mockResourceRepo) GetByName(name string) (*domain.Resource, error) {
 	return args.Get(0).(*domain.Resource), args.Error(1)
 }
 
-func (m *mockResourceRepo) AddResourceToCustomer(resourceName string, customerID int64) error {
+func (m *mockResourceRepo) AddResourceToCustomer(ctx context.Context, resourceName string, customerID int64) error {
 	args := m.Called(resourceName, customerID)
 	return args.Error(0)
 }
 
-func (m *mockResourceRepo) GetCustomerResourceByResourceName(customerID int64, resourceName string) (*domain.Resource, error) {
+func (m *mockResourceRepo) GetCustomerResourceByResourceName(ctx context.Context, customerID int64, resourceName string) (*domain.Resource, error) {
 
 	args := m.Called(customerID, resourceName)
 	if args.Get(0) == nil {
@@ -77,7 +85,7 @@ func (m *mockResourceRepo) GetCustomerResourceByResourceName(customerID int64, r
 	return args.Get(0).(*domain.Resource), args.Error(1)
 }
 
-func (m *mockResourceRepo) DoesCustomerHaveResource(customerID int64, resourceName string) (bool, error) {
+func (m *mockResourceRepo) DoesCustomerHaveResource(ctx context.Context, customerID int64, resourceName string) (bool, error) {
 	args := m.Called(customerID, resourceName)
 	if args.Get(0) == nil {
 		return false, args.Error(1)
@@ -85,45 +93,144 @@ func (m *mockResourceRepo) DoesCustomerHaveResource(customerID int64, resourceNa
 	return args.Get(0).(bool), args.Error(1)
 }
 
+func (m *mockResourceRepo) RemoveResourceFromCustomer(ctx context.Context, customerID, resourceID int64) error {
+	args := m.Called(customerID, resourceID)
+	return args.Error(0)
+}
+
+func (m *mockResourceRepo) GetHistory(ctx context.Context, resourceID int64) ([]domain.ResourceVersion, error) {
+	args := m.Called(resourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ResourceVersion), args.Error(1)
+}
+
+func (m *mockResourceRepo) Restore(ctx context.Context, resourceID int64) (*domain.Resource, error) {
+	args := m.Called(resourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Resource), args.Error(1)
+}
+
+func (m *mockResourceRepo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockResourceRepo) Import(ctx context.Context, resources []domain.Resource) (domain.ImportSummary, error) {
+	args := m.Called(resources)
+	return args.Get(0).(domain.ImportSummary), args.Error(1)
+}
+
 // Mock for CustomerRepository
 type mockCustomerRepo2 struct {
 	mock.Mock
 }
 
-func (m *mockCustomerRepo2) Create(customer *domain.Customer) error { return nil }
-func (m *mockCustomerRepo2) GetByID(id int64) (*domain.Customer, error) {
+func (m *mockCustomerRepo2) Create(ctx context.Context, customer *domain.Customer) error { return nil }
+func (m *mockCustomerRepo2) GetByID(ctx context.Context, id int64) (*domain.Customer, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.Customer), args.Error(1)
 }
-func (m *mockCustomerRepo2) GetByEmail(email string) (*domain.Customer, error) {
+func (m *mockCustomerRepo2) GetByEmail(ctx context.Context, email string) (*domain.Customer, error) {
 	return nil, nil
 }
+func (m *mockCustomerRepo2) List(ctx context.Context, filter domain.CustomerFilter) ([]domain.Customer, int64, error) {
+	return nil, 0, nil
+}
+func (m *mockCustomerRepo2) Update(ctx context.Context, customer *domain.Customer) error { return nil }
+func (m *mockCustomerRepo2) Delete(ctx context.Context, id int64) error                  { return nil }
+func (m *mockCustomerRepo2) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return 0, nil
+}
 
 func TestGetAllAvailableResourcesUsecase_OK(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
-	resourceRepo.On("GetAll").Return([]domain.Resource{
+	resourceRepo.On("GetAll", domain.ResourceFilter{SortKey: "id", Limit: 51}).Return([]domain.Resource{
 		{ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1"},
 	}, nil)
 
-	resources, err := uc.GetAllAvailableResources()
+	page, err := uc.GetAllAvailableResources(context.Background(), domain.ResourceQuery{})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, resources)
+	assert.NotEmpty(t, page.Resources)
+	assert.Empty(t, page.NextCursor)
 
 	resourceRepo.AssertExpectations(t)
 }
 
+func TestGetAllAvailableResourcesUsecase_NextCursor(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	// Three rows back for a page of two means there is a next page
+	resourceRepo.On("GetAll", domain.ResourceFilter{Type: "VPC", SortKey: "name", Limit: 3}).Return([]domain.Resource{
+		{ID: 4, Name: "aws_vpc_a", Type: "VPC"},
+		{ID: 2, Name: "aws_vpc_b", Type: "VPC"},
+		{ID: 9, Name: "aws_vpc_c", Type: "VPC"},
+	}, nil).Once()
+
+	page, err := uc.GetAllAvailableResources(context.Background(), domain.ResourceQuery{Type: "VPC", Sort: "name", Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Resources, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// Following the cursor resumes after the last row of the first page
+	resourceRepo.On("GetAll", domain.ResourceFilter{
+		Type: "VPC", SortKey: "name", Limit: 3,
+		After: &domain.ResourceCursor{Sort: "name", Name: "aws_vpc_b", ID: 2},
+	}).Return([]domain.Resource{{ID: 9, Name: "aws_vpc_c", Type: "VPC"}}, nil).Once()
+
+	page, err = uc.GetAllAvailableResources(context.Background(), domain.ResourceQuery{Type: "VPC", Sort: "name", Limit: 2, After: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, page.Resources, 1)
+	assert.Empty(t, page.NextCursor)
+
+	resourceRepo.AssertExpectations(t)
+}
+
+func TestGetAllAvailableResourcesUsecase_CursorSortMismatch(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	resourceRepo.On("GetAll", domain.ResourceFilter{SortKey: "id", Limit: 2}).Return([]domain.Resource{
+		{ID: 1}, {ID: 2},
+	}, nil)
+
+	page, err := uc.GetAllAvailableResources(context.Background(), domain.ResourceQuery{Limit: 1})
+	assert.NoError(t, err)
+
+	_, err = uc.GetAllAvailableResources(context.Background(), domain.ResourceQuery{Limit: 1, Sort: "-name", After: page.NextCursor})
+	assert.ErrorIs(t, err, usecase.ErrInvalidCursor)
+}
+
+func TestGetAllAvailableResourcesUsecase_InvalidSort(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	_, err := uc.GetAllAvailableResources(context.Background(), domain.ResourceQuery{Sort: "region"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidSort)
+}
+
 func TestAddCloudResourceUsecase_OK(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
 	// Customer exists
 	customerRepo.On("GetByID", int64(123)).Return(&domain.Customer{ID: 123}, nil)
@@ -135,7 +242,7 @@ func TestAddCloudResourceUsecase_OK(t *testing.T) {
 	resourceRepo.On("DoesCustomerHaveResource", int64(123), "aws_vpc_main").
 		Return(false, nil)
 
-	err := uc.AddCloudResource(123, "aws_vpc_main")
+	err := uc.AddCloudResource(context.Background(), 123, "aws_vpc_main")
 	assert.NoError(t, err)
 
 	resourceRepo.AssertExpectations(t)
@@ -145,13 +252,13 @@ func TestAddCloudResourceUsecase_OK(t *testing.T) {
 func TestAddCloudResourceUsecase_CustomerNotFound(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
 	// Customer doesn't exist
 	customerRepo.On("GetByID", int64(999)).
 		Return((*domain.Customer)(nil), errors.New("no rows in result set"))
 
-	err := uc.AddCloudResource(999, "resource1")
+	err := uc.AddCloudResource(context.Background(), 999, "resource1")
 	assert.EqualError(t, err, "customer not found")
 
 	customerRepo.AssertExpectations(t)
@@ -160,11 +267,11 @@ func TestAddCloudResourceUsecase_CustomerNotFound(t *testing.T) {
 func TestAddCloudResourceUsecase_NoResourceNames(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
 	customerRepo.On("GetByID", int64(123)).Return(&domain.Customer{ID: 123}, nil)
 
-	err := uc.AddCloudResource(123, "")
+	err := uc.AddCloudResource(context.Background(), 123, "")
 	assert.EqualError(t, err, "no resource name provided")
 
 	resourceRepo.AssertExpectations(t)
@@ -175,44 +282,156 @@ func TestGetResourcesByCustomerUsecase_OK(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
 	// Customer exists
 	customerRepo.On("GetByID", int64(123)).Return(&domain.Customer{ID: 123}, nil)
 
 	// Resource assignment
-	resourceRepo.On("GetResourcesByCustomer", int64(123)).
+	resourceRepo.On("GetResourcesByCustomer", int64(123), domain.ResourceFilter{SortKey: "id", Limit: 51}).
 		Return([]domain.Resource{
 			{ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1"}}, nil)
 
-	resource, err := uc.GetResourcesByCustomer(123)
+	page, err := uc.GetResourcesByCustomer(context.Background(), 123, domain.ResourceQuery{})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, resource)
+	assert.NotEmpty(t, page.Resources)
 
 	resourceRepo.AssertExpectations(t)
 	customerRepo.AssertExpectations(t)
 }
 
+func TestGetResourcesByCustomerUsecase_AsOfInUTC(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	customerRepo.On("GetByID", int64(42)).Return(&domain.Customer{ID: 42}, nil)
+
+	asOf := time.Date(2024, 5, 7, 11, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	resourceRepo.On("GetResourcesByCustomer", int64(42), domain.ResourceFilter{
+		SortKey: "id", Limit: 51, AsOf: time.Date(2024, 5, 7, 9, 30, 0, 0, time.UTC),
+	}).Return([]domain.Resource{}, nil)
+
+	_, err := uc.GetResourcesByCustomer(context.Background(), 42, domain.ResourceQuery{AsOf: asOf})
+	assert.NoError(t, err)
+
+	resourceRepo.AssertExpectations(t)
+	customerRepo.AssertExpectations(t)
+}
+
+func TestGetAllAvailableResourcesUsecase_UnknownProvider(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	_, err := uc.GetAllAvailableResources(context.Background(), domain.ResourceQuery{Provider: "oracle"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidFilter)
+
+	resourceRepo.AssertNotCalled(t, "GetAll", mock.Anything)
+}
+
+func TestGetResourceHistoryUsecase(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	resourceRepo.On("GetHistory", int64(1)).Return([]domain.ResourceVersion{{ResourceID: 1, Name: "aws_vpc_main"}}, nil)
+	resourceRepo.On("GetHistory", int64(2)).Return(nil, nil)
+
+	versions, err := uc.GetResourceHistory(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 1)
+
+	_, err = uc.GetResourceHistory(context.Background(), 2)
+	assert.ErrorIs(t, err, usecase.ErrResourceNotFound)
+
+	resourceRepo.AssertExpectations(t)
+}
+
+func TestRestoreResourceUsecase(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	// 1 is deleted, 2 is live and 3 does not exist
+	resourceRepo.On("Restore", int64(1)).Return(&domain.Resource{ID: 1, Name: "aws_vpc_main"}, nil)
+	resourceRepo.On("Restore", int64(2)).Return(nil, sql.ErrNoRows)
+	resourceRepo.On("GetByID", int64(2)).Return(&domain.Resource{ID: 2}, nil)
+	resourceRepo.On("Restore", int64(3)).Return(nil, sql.ErrNoRows)
+	resourceRepo.On("GetByID", int64(3)).Return(nil, sql.ErrNoRows)
+
+	res, err := uc.RestoreResource(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "aws_vpc_main", res.Name)
+
+	_, err = uc.RestoreResource(context.Background(), 2)
+	assert.ErrorIs(t, err, usecase.ErrResourceNotDeleted)
+
+	_, err = uc.RestoreResource(context.Background(), 3)
+	assert.ErrorIs(t, err, usecase.ErrResourceNotFound)
+
+	resourceRepo.AssertExpectations(t)
+}
+
+func TestImportResourcesUsecase(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	export := `{"data": [{"id": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet-main",
+		"name": "vnet-main", "type": "microsoft.network/virtualnetworks", "location": "westeurope", "subscriptionId": "sub-1"}]}`
+	resourceRepo.On("Import", mock.MatchedBy(func(resources []domain.Resource) bool {
+		return len(resources) == 1 && resources[0].Provider == domain.ProviderAzure && resources[0].Name == "vnet-main"
+	})).Return(domain.ImportSummary{Created: 1}, nil)
+
+	summary, err := uc.ImportResources(context.Background(), "azure-graph", strings.NewReader(export))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Created)
+
+	_, err = uc.ImportResources(context.Background(), "csv", strings.NewReader(export))
+	assert.ErrorIs(t, err, usecase.ErrInvalidImport)
+
+	_, err = uc.ImportResources(context.Background(), "azure-graph", strings.NewReader("{not json"))
+	assert.ErrorIs(t, err, usecase.ErrInvalidImport)
+
+	// An empty export does not reach the repository
+	summary, err = uc.ImportResources(context.Background(), "azure-graph", strings.NewReader(`{"data": []}`))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ImportSummary{}, *summary)
+
+	resourceRepo.AssertExpectations(t)
+}
+
 func TestGetResourcesByCustomerUsecase_CustomerNotFound(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
 	// Customer exists
 	customerRepo.On("GetByID", int64(999)).
 		Return((*domain.Customer)(nil), errors.New("no rows in result set"))
 
-	_, err := uc.GetResourcesByCustomer(999)
+	_, err := uc.GetResourcesByCustomer(context.Background(), 999, domain.ResourceQuery{})
 	assert.EqualError(t, err, "customer not found")
 	customerRepo.AssertExpectations(t)
 }
 
+// resourceUpdate builds a full update of the required fields, as PUT does.
+func resourceUpdate(name, resourceType, region string) domain.ResourceUpdate {
+	return domain.ResourceUpdate{Name: &name, Type: &resourceType, Region: &region}
+}
+
 func TestUpdateResourceUsecase_OK(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
 	resourceRepo.On("GetByID", int64(1)).Return(&domain.Resource{
 		ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1",
@@ -223,7 +442,7 @@ func TestUpdateResourceUsecase_OK(t *testing.T) {
 		ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1",
 	}).Return(nil)
 
-	_, err := uc.UpdateResource(1, "aws_vpc_main", "VPC", "us-east-1")
+	_, err := uc.UpdateResource(context.Background(), 1, resourceUpdate("aws_vpc_main", "VPC", "us-east-1"))
 	assert.NoError(t, err)
 
 	resourceRepo.AssertExpectations(t)
@@ -234,9 +453,9 @@ func TestUpdateResourceUsecase_EmptyRegion(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
-	_, err := uc.UpdateResource(1, "aws_vpc_main", "VPC", "")
+	_, err := uc.UpdateResource(context.Background(), 1, resourceUpdate("aws_vpc_main", "VPC", ""))
 	assert.EqualError(t, err, "region cannot be empty")
 
 }
@@ -245,9 +464,9 @@ func TestUpdateResourceUsecase_EmptyType(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
-	_, err := uc.UpdateResource(1, "aws_vpc_main", "", "us-east-1")
+	_, err := uc.UpdateResource(context.Background(), 1, resourceUpdate("aws_vpc_main", "", "us-east-1"))
 	assert.EqualError(t, err, "type cannot be empty")
 
 }
@@ -256,22 +475,54 @@ func TestUpdateResourceUsecase_EmptyName(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
-	_, err := uc.UpdateResource(1, "", "VPC", "us-east-1")
+	_, err := uc.UpdateResource(context.Background(), 1, resourceUpdate("", "VPC", "us-east-1"))
 	assert.EqualError(t, err, "name cannot be empty")
 
 }
 
+func TestUpdateResourceUsecase_Metadata(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	resourceRepo.On("GetByID", int64(1)).Return(&domain.Resource{
+		ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1", Provider: "aws", AccountID: "123456789012",
+	}, nil)
+	resourceRepo.On("Update", mock.AnythingOfType("*domain.Resource")).Return(nil)
+
+	// Omitted fields are kept
+	update := resourceUpdate("aws_vpc_main", "VPC", "us-east-1")
+	update.Tags = map[string]string{"env": "prod"}
+	update.Attributes = []byte(`{"cidr": "10.0.0.0/16"}`)
+	res, err := uc.UpdateResource(context.Background(), 1, update)
+	assert.NoError(t, err)
+	assert.Equal(t, "aws", res.Provider)
+	assert.Equal(t, "123456789012", res.AccountID)
+	assert.Equal(t, map[string]string{"env": "prod"}, res.Tags)
+
+	provider := "oracle"
+	_, err = uc.UpdateResource(context.Background(), 1, domain.ResourceUpdate{Provider: &provider})
+	assert.EqualError(t, err, `unknown provider "oracle"`)
+
+	_, err = uc.UpdateResource(context.Background(), 1, domain.ResourceUpdate{Attributes: []byte(`[1, 2]`)})
+	assert.EqualError(t, err, "attributes must be a JSON object")
+
+	_, err = uc.UpdateResource(context.Background(), 1, domain.ResourceUpdate{Tags: map[string]string{" ": "x"}})
+	assert.EqualError(t, err, "tag keys cannot be empty")
+}
+
 func TestUpdateResourceUsecase_ResourceNotFound(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
 	resourceRepo.On("GetByID", int64(1)).Return((*domain.Resource)(nil), errors.New("no rows in result set"))
 
-	_, err := uc.UpdateResource(1, "aws_vpc_main", "VPC", "us-east-1")
+	_, err := uc.UpdateResource(context.Background(), 1, resourceUpdate("aws_vpc_main", "VPC", "us-east-1"))
 	assert.EqualError(t, err, "resource not found")
 
 	resourceRepo.AssertExpectations(t)
@@ -281,7 +532,7 @@ func TestDeleteResourceUsecase_OK(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
 	resourceRepo.On("GetByID", int64(1)).Return(&domain.Resource{
 		ID: 1, Name: "aws_vpc_main", Type: "VPC", Region: "us-east-1",
@@ -289,7 +540,7 @@ func TestDeleteResourceUsecase_OK(t *testing.T) {
 
 	resourceRepo.On("Delete", int64(1)).Return(nil)
 
-	err := uc.DeleteResource(1)
+	err := uc.DeleteResource(context.Background(), 1)
 	assert.NoError(t, err)
 
 	resourceRepo.AssertExpectations(t)
@@ -299,12 +550,98 @@ func TestDeleteResourceUsecase_ResourceNotFound(t *testing.T) {
 	resourceRepo := new(mockResourceRepo)
 	customerRepo := new(mockCustomerRepo2)
 
-	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo)
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
 
 	resourceRepo.On("GetByID", int64(1)).Return((*domain.Resource)(nil), errors.New("no rows in result set"))
 
-	err := uc.DeleteResource(1)
+	err := uc.DeleteResource(context.Background(), 1)
 	assert.EqualError(t, err, "resource not found")
 
 	resourceRepo.AssertExpectations(t)
 }
+
+func TestRemoveCloudResourceUsecase_OK(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	resourceRepo.On("GetByID", int64(1)).Return(&domain.Resource{ID: 1, Name: "aws_vpc_main"}, nil)
+	customerRepo.On("GetByID", int64(123)).Return(&domain.Customer{ID: 123}, nil)
+	resourceRepo.On("RemoveResourceFromCustomer", int64(123), int64(1)).Return(nil)
+
+	res, err := uc.RemoveCloudResource(context.Background(), 123, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "aws_vpc_main", res.Name)
+
+	resourceRepo.AssertExpectations(t)
+	customerRepo.AssertExpectations(t)
+}
+
+func TestRemoveCloudResourceByNameUsecase_NotAssigned(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	resourceRepo.On("GetByName", "aws_vpc_main").Return(&domain.Resource{ID: 1, Name: "aws_vpc_main"}, nil)
+	customerRepo.On("GetByID", int64(123)).Return(&domain.Customer{ID: 123}, nil)
+	resourceRepo.On("RemoveResourceFromCustomer", int64(123), int64(1)).Return(sql.ErrNoRows)
+
+	_, err := uc.RemoveCloudResourceByName(context.Background(), 123, "aws_vpc_main")
+	assert.ErrorIs(t, err, usecase.ErrResourceNotAssigned)
+
+	resourceRepo.AssertExpectations(t)
+	customerRepo.AssertExpectations(t)
+}
+
+func TestRemoveCloudResourceUsecase_ResourceNotFound(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	resourceRepo.On("GetByID", int64(1)).Return((*domain.Resource)(nil), errors.New("no rows in result set"))
+
+	_, err := uc.RemoveCloudResource(context.Background(), 123, 1)
+	assert.ErrorIs(t, err, usecase.ErrResourceNotFound)
+
+	resourceRepo.AssertExpectations(t)
+}
+
+func TestAddCloudResourcesUsecase_OK(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	customerRepo.On("GetByID", int64(123)).Return(&domain.Customer{ID: 123}, nil)
+
+	// Names are trimmed and de-duplicated before reaching the repository
+	resourceRepo.On("AddResourcesToCustomer", []string{"aws_vpc_main", "nope"}, int64(123)).Return([]domain.ResourceAssignment{
+		{Name: "aws_vpc_main", Status: domain.AssignmentAssigned},
+		{Name: "nope", Status: domain.AssignmentUnknown},
+	}, nil)
+
+	results, err := uc.AddCloudResources(context.Background(), 123, []string{" aws_vpc_main", "aws_vpc_main", "", "nope"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	resourceRepo.AssertExpectations(t)
+	customerRepo.AssertExpectations(t)
+}
+
+func TestAddCloudResourcesUsecase_NoResourceNames(t *testing.T) {
+	resourceRepo := new(mockResourceRepo)
+	customerRepo := new(mockCustomerRepo2)
+
+	uc := usecase.NewResourceUsecase(resourceRepo, customerRepo, logging.Discard())
+
+	customerRepo.On("GetByID", int64(123)).Return(&domain.Customer{ID: 123}, nil)
+
+	_, err := uc.AddCloudResources(context.Background(), 123, []string{" ", ""})
+	assert.EqualError(t, err, "no resource names provided")
+
+	resourceRepo.AssertExpectations(t)
+	customerRepo.AssertExpectations(t)
+}
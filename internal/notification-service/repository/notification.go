@@ -1,34 +1,44 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
 )
 
 type NotificationRepository interface {
-	Create(notification *domain.Notification) error
-	GetAllByUserID(userID int64) ([]domain.Notification, error)
-	DeleteByID(notificationID int64) error
-	DeleteAllByUserID(userID int64) error
+	Create(ctx context.Context, notification *domain.Notification) error
+	GetAllByUserID(ctx context.Context, userID int64) ([]domain.Notification, error)
+	GetByID(ctx context.Context, notificationID int64) (*domain.Notification, error)
+	DeleteByID(ctx context.Context, notificationID int64) error
+	DeleteAllByUserID(ctx context.Context, userID int64) error
 }
 
 type notificationRepo struct {
-	db *sql.DB
+	db       *sql.DB
+	timeouts db.Timeouts
 }
 
-func NewNotificationRepository(db *sql.DB) NotificationRepository {
-	return &notificationRepo{db: db}
+func NewNotificationRepository(conn *sql.DB, timeouts db.Timeouts) NotificationRepository {
+	return tracedNotificationRepo{next: &notificationRepo{db: conn, timeouts: timeouts}}
 }
 
-func (r *notificationRepo) Create(n *domain.Notification) error {
+func (r *notificationRepo) Create(ctx context.Context, n *domain.Notification) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
 	query := `INSERT INTO notifications (user_id, message, created_at) VALUES ($1, $2, NOW()) RETURNING id`
-	return r.db.QueryRow(query, n.UserID, n.Message).Scan(&n.ID)
+	return r.db.QueryRowContext(ctx, query, n.UserID, n.Message).Scan(&n.ID)
 }
 
-func (r *notificationRepo) GetAllByUserID(userID int64) ([]domain.Notification, error) {
+func (r *notificationRepo) GetAllByUserID(ctx context.Context, userID int64) ([]domain.Notification, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
 	query := `SELECT id, user_id, message, created_at FROM notifications WHERE user_id = $1`
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -42,17 +52,37 @@ func (r *notificationRepo) GetAllByUserID(userID int64) ([]domain.Notification,
 		}
 		notifs = append(notifs, n)
 	}
-	return notifs, nil
+	return notifs, rows.Err()
+}
+
+// GetByID returns sql.ErrNoRows when the notification does not exist.
+func (r *notificationRepo) GetByID(ctx context.Context, notificationID int64) (*domain.Notification, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	var n domain.Notification
+	query := `SELECT id, user_id, message, created_at FROM notifications WHERE id = $1`
+	err := r.db.QueryRowContext(ctx, query, notificationID).Scan(&n.ID, &n.UserID, &n.Message, &n.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
 }
 
-func (r *notificationRepo) DeleteByID(notificationID int64) error {
+func (r *notificationRepo) DeleteByID(ctx context.Context, notificationID int64) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
 	query := `DELETE FROM notifications WHERE id = $1`
-	_, err := r.db.Exec(query, notificationID)
+	_, err := r.db.ExecContext(ctx, query, notificationID)
 	return err
 }
 
-func (r *notificationRepo) DeleteAllByUserID(userID int64) error {
+func (r *notificationRepo) DeleteAllByUserID(ctx context.Context, userID int64) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
 	query := `DELETE FROM notifications WHERE user_id = $1`
-	_, err := r.db.Exec(query, userID)
+	_, err := r.db.ExecContext(ctx, query, userID)
 	return err
 }
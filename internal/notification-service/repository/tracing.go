@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/domain"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
+)
+
+// tracedNotificationRepo wraps every repository call in a span, so that the
+// database time shows up under the request or message that caused it.
+type tracedNotificationRepo struct {
+	next NotificationRepository
+}
+
+func (r tracedNotificationRepo) Create(ctx context.Context, notification *domain.Notification) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "notifications.Create")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.Create(ctx, notification)
+}
+
+func (r tracedNotificationRepo) GetAllByUserID(ctx context.Context, userID int64) (_ []domain.Notification, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "notifications.GetAllByUserID")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetAllByUserID(ctx, userID)
+}
+
+func (r tracedNotificationRepo) GetByID(ctx context.Context, notificationID int64) (_ *domain.Notification, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "notifications.GetByID")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.GetByID(ctx, notificationID)
+}
+
+func (r tracedNotificationRepo) DeleteByID(ctx context.Context, notificationID int64) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "notifications.DeleteByID")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.DeleteByID(ctx, notificationID)
+}
+
+func (r tracedNotificationRepo) DeleteAllByUserID(ctx context.Context, userID int64) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "notifications.DeleteAllByUserID")
+	defer func() { tracing.EndSpan(span, err) }()
+	return r.next.DeleteAllByUserID(ctx, userID)
+}
@@ -1,122 +1,218 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/repository"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/metrics"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/rabbitmq"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type Notifier interface {
-	Publish(message domain.Notification) error
-	Listen() error
+	Publish(ctx context.Context, message domain.Notification) error
+	// Listen consumes notifications until ctx is cancelled.
+	Listen(ctx context.Context) error
 	Close()
 }
 
 type RabbitMQNotifier struct {
-	conn             *amqp.Connection
-	channel          *amqp.Channel
-	queue            amqp.Queue
+	conn             *rabbitmq.Connection
+	publisher        *rabbitmq.Publisher
 	notificationRepo repository.NotificationRepository
+	cfg              config.RabbitMQConfig
+	logger           *slog.Logger
 }
 
-func NewRabbitMQNotifier(amqpURL string, notificationRepo repository.NotificationRepository) (*RabbitMQNotifier, error) {
-	conn, err := amqp.Dial(amqpURL)
+func NewRabbitMQNotifier(conn *rabbitmq.Connection, cfg config.RabbitMQConfig, notificationRepo repository.NotificationRepository, logger *slog.Logger) *RabbitMQNotifier {
+	return &RabbitMQNotifier{
+		conn:             conn,
+		publisher:        rabbitmq.NewPublisher(conn, cfg, logger),
+		notificationRepo: notificationRepo,
+		cfg:              cfg,
+		logger:           logger,
+	}
+}
+
+func (n *RabbitMQNotifier) Publish(ctx context.Context, payload domain.Notification) error {
+	body, err := json.Marshal(&payload)
 	if err != nil {
-		return nil, fmt.Errorf("error dailing RabbitMQ server: %w", err)
+		n.logger.ErrorContext(ctx, "error marshalling notification", "error", err)
+		return err
 	}
-	ch, err := conn.Channel()
+
+	headers := amqp.Table{}
+	logging.InjectAMQP(ctx, headers)
+	span := tracing.StartPublishSpan(ctx, rabbitmq.NotificationsQueue, headers)
+
+	// Delivery failures are logged by the publisher
+	err = n.publisher.Publish("", rabbitmq.NotificationsQueue, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  "text/plain",
+		DeliveryMode: amqp.Persistent,
+		Body:         body,
+	}, func(err error) {
+		tracing.EndSpan(span, err)
+		countPublished(err)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error opening rabbitmq channel: %w", err)
+		tracing.EndSpan(span, err)
+		countPublished(err)
 	}
-	q, err := ch.QueueDeclare(
-		"notifications",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	return err
+}
+
+func countPublished(err error) {
 	if err != nil {
-		return nil, fmt.Errorf("error declaring queue: %w", err)
+		metrics.NotificationsFailed.WithLabelValues(metrics.StagePublish).Inc()
+		return
 	}
+	metrics.NotificationsPublished.Inc()
+}
 
-	return &RabbitMQNotifier{
-		conn:             conn,
-		channel:          ch,
-		queue:            q,
-		notificationRepo: notificationRepo,
-	}, nil
+// PublishStats reports the delivery counters of the background publisher.
+func (n *RabbitMQNotifier) PublishStats() rabbitmq.PublisherStats {
+	return n.publisher.Stats()
 }
 
-func (n *RabbitMQNotifier) Publish(payload domain.Notification) error {
-	body, err := json.Marshal(&payload)
-	if err != nil {
-		log.Println(fmt.Errorf("error marshalling payload: %v", err))
-		return err
+// Listen consumes the notifications queue with manual acknowledgements.
+// Messages that cannot be decoded are dead-lettered straight away; messages
+// that fail to persist are retried with backoff through the retry queue and
+// dead-lettered once MaxRetries is exhausted.
+//
+// Consumption resumes by itself after the connection to the broker is
+// re-established. Listen returns once ctx is cancelled and the message being
+// handled is finished.
+func (n *RabbitMQNotifier) Listen(ctx context.Context) error {
+	n.logger.Info("listening for notifications", "queue", rabbitmq.NotificationsQueue)
+	return n.conn.Consume(ctx, rabbitmq.NotificationsQueue, n.cfg.Prefetch, n.handleDelivery)
+}
+
+func (n *RabbitMQNotifier) handleDelivery(d amqp.Delivery) {
+	metrics.NotificationsConsumed.Inc()
+
+	// Continue the trace and keep the request ID of the request that caused
+	// the notification
+	ctx := logging.ExtractAMQP(context.Background(), d.Headers)
+	ctx, span := tracing.StartConsumeSpan(ctx, rabbitmq.NotificationsQueue, d.Headers)
+	defer span.End()
+
+	n.logger.DebugContext(ctx, "received notification",
+		"delivery_tag", d.DeliveryTag, "redelivered", d.Redelivered, "retry", rabbitmq.RetryCount(d.Headers))
+
+	var payload domain.Notification
+	if err := json.Unmarshal(d.Body, &payload); err != nil {
+		n.logger.ErrorContext(ctx, "failed to decode notification", "error", err)
+		metrics.NotificationsFailed.WithLabelValues(metrics.StageDecode).Inc()
+		n.deadLetter(ctx, d, fmt.Errorf("decode: %w", err))
+		return
+	}
+	if payload.Event != "notification" {
+		// Not ours to handle
+		_ = d.Ack(false)
+		return
+	}
+	if payload.UserID == 0 || payload.Message == "" {
+		metrics.NotificationsFailed.WithLabelValues(metrics.StageValidate).Inc()
+		n.deadLetter(ctx, d, fmt.Errorf("invalid notification: missing user_id or message"))
+		return
 	}
 
-	return n.channel.Publish(
-		"",
-		n.queue.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        body,
-		},
-	)
+	if err := n.notificationRepo.Create(ctx, &payload); err != nil {
+		n.logger.ErrorContext(ctx, "error storing notification", "user_id", payload.UserID, "error", err)
+		span.RecordError(err)
+		metrics.NotificationsFailed.WithLabelValues(metrics.StagePersist).Inc()
+		n.retry(ctx, d, err)
+		return
+	}
+	n.logger.InfoContext(ctx, "stored notification", "notification_id", payload.ID, "user_id", payload.UserID)
+	metrics.NotificationsPersisted.Inc()
+	_ = d.Ack(false)
 }
 
-func (n *RabbitMQNotifier) Listen() error {
-	msgs, err := n.channel.Consume(
-		n.queue.Name,
-		"",
-		true,  // auto-ack
-		false, // exclusive
-		false, // noLocal
-		false, // noWait
-		nil,
-	)
+// retry sends d through the retry queue with an exponential delay, or
+// dead-letters it once it has been retried MaxRetries times.
+func (n *RabbitMQNotifier) retry(ctx context.Context, d amqp.Delivery, cause error) {
+	attempt := rabbitmq.RetryCount(d.Headers) + 1
+	if attempt > n.cfg.MaxRetries {
+		n.deadLetter(ctx, d, fmt.Errorf("giving up after %d retries: %w", n.cfg.MaxRetries, cause))
+		return
+	}
+
+	headers := rabbitmq.CopyHeaders(d.Headers)
+	headers[rabbitmq.RetryCountHeader] = int32(attempt)
+	delay := rabbitmq.Backoff(attempt, n.cfg.RetryBaseDelay, n.cfg.RetryMaxDelay)
+
+	// The original is only acknowledged once the broker confirmed the copy
+	err := n.publisher.Publish("", rabbitmq.RetryQueue, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Expiration:   strconv.FormatInt(delay.Milliseconds(), 10),
+		Body:         d.Body,
+	}, func(err error) {
+		if err != nil {
+			// Let the broker redeliver it instead
+			n.logger.ErrorContext(ctx, "error scheduling retry", "error", err)
+			_ = d.Nack(false, true)
+			return
+		}
+		n.logger.WarnContext(ctx, "retry scheduled", "attempt", attempt, "max_retries", n.cfg.MaxRetries, "delay", delay)
+		_ = d.Ack(false)
+	})
 	if err != nil {
-		return err
+		n.logger.ErrorContext(ctx, "error scheduling retry", "error", err)
+		_ = d.Nack(false, true)
 	}
+}
 
-	forever := make(chan bool)
-
-	go func() {
-		for d := range msgs {
-			log.Printf("[NotificationService] Received: %s", d.Body)
-			var payload domain.Notification
-			err := json.Unmarshal(d.Body, &payload)
-
-			if err != nil {
-				log.Printf("Failed to decode message: %s", err)
-				continue
-			}
-			if payload.Event == "notification" && payload.UserID != 0 && payload.Message != "" {
-				log.Println("notification payload: ", payload)
-				if err := n.notificationRepo.Create(&payload); err != nil {
-					log.Println("error creating notification: ", err)
-				}
-			}
+// deadLetter moves d to the dead-letter queue, recording cause in its
+// headers so the dlq command can show why it failed.
+func (n *RabbitMQNotifier) deadLetter(ctx context.Context, d amqp.Delivery, cause error) {
+	headers := rabbitmq.CopyHeaders(d.Headers)
+	headers[rabbitmq.LastErrorHeader] = cause.Error()
 
+	err := n.publisher.Publish(rabbitmq.DeadLetterExchange, rabbitmq.DeadLetterQueue, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Body:         d.Body,
+	}, func(err error) {
+		if err != nil {
+			// The queue's own dead-letter exchange still catches the
+			// rejection, only without the error header
+			n.logger.ErrorContext(ctx, "error publishing to dead-letter queue", "error", err)
+			_ = d.Nack(false, false)
+			return
 		}
-	}()
+		n.logger.WarnContext(ctx, "dead-lettered notification", "cause", cause)
+		_ = d.Ack(false)
+	})
+	if err != nil {
+		n.logger.ErrorContext(ctx, "error publishing to dead-letter queue", "error", err)
+		_ = d.Nack(false, false)
+	}
+}
 
-	fmt.Println("[NotificationService] Listening for messages... Press CTRL+C to exit.")
-	<-forever
-	return nil
+// Shutdown waits, at most until ctx ends, for buffered messages to be
+// confirmed and then closes the connection.
+func (n *RabbitMQNotifier) Shutdown(ctx context.Context) error {
+	err := n.publisher.Shutdown(ctx)
+	n.conn.Close()
+	return err
 }
 
+// Close waits for buffered messages to be confirmed before closing the
+// connection.
 func (n *RabbitMQNotifier) Close() {
-	if n.channel != nil {
-		_ = n.channel.Close()
-	}
-	if n.conn != nil {
-		_ = n.conn.Close()
-	}
+	n.publisher.Close()
+	n.conn.Close()
 }
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/domain"
@@ -32,7 +33,7 @@ func (s *NotificationGRPCService) GetAllNotifications(
 		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
 	}
 
-	notifs, err := s.notificationUC.GetAllNotifications(req.UserId)
+	notifs, err := s.notificationUC.GetAllNotifications(ctx, req.UserId)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get notifications: %v", err)
 	}
@@ -57,8 +58,11 @@ func (s *NotificationGRPCService) ClearSingleNotification(
 		return nil, status.Error(codes.InvalidArgument, "invalid notification_id")
 	}
 
-	err := s.notificationUC.ClearNotification(req.NotificationId)
+	err := s.notificationUC.ClearNotification(ctx, req.NotificationId)
 	if err != nil {
+		if errors.Is(err, usecase.ErrNotificationNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "failed to clear notification: %v", err)
 	}
 
@@ -75,7 +79,7 @@ func (s *NotificationGRPCService) ClearAllNotifications(
 		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
 	}
 
-	err := s.notificationUC.ClearAllNotifications(req.UserId)
+	err := s.notificationUC.ClearAllNotifications(ctx, req.UserId)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to clear all notifications: %v", err)
 	}
@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -10,10 +12,11 @@ import (
 
 type NotificationHandler struct {
 	notificationUC usecase.NotificationUsecase
+	logger         *slog.Logger
 }
 
-func NewNotificationHandler(notificationUC usecase.NotificationUsecase) *NotificationHandler {
-	return &NotificationHandler{notificationUC: notificationUC}
+func NewNotificationHandler(notificationUC usecase.NotificationUsecase, logger *slog.Logger) *NotificationHandler {
+	return &NotificationHandler{notificationUC: notificationUC, logger: logger}
 }
 
 // GET /users/:id/notifications
@@ -25,8 +28,9 @@ func (h *NotificationHandler) GetAllUsersNotifications(c *gin.Context) {
 		return
 	}
 
-	notifications, err := h.notificationUC.GetAllNotifications(userID)
+	notifications, err := h.notificationUC.GetAllNotifications(c.Request.Context(), userID)
 	if err != nil {
+		h.logger.WarnContext(c.Request.Context(), "error listing notifications", "user_id", userID, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -43,7 +47,7 @@ func (h *NotificationHandler) ClearAllUsersNotifications(c *gin.Context) {
 		return
 	}
 
-	err = h.notificationUC.ClearAllNotifications(userID)
+	err = h.notificationUC.ClearAllNotifications(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -61,8 +65,12 @@ func (h *NotificationHandler) ClearSingleNotification(c *gin.Context) {
 		return
 	}
 
-	err = h.notificationUC.ClearNotification(notificationID)
+	err = h.notificationUC.ClearNotification(c.Request.Context(), notificationID)
 	if err != nil {
+		if errors.Is(err, usecase.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -1,6 +1,7 @@
 package rest_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/repository"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/transport/rest"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
 )
 
 func TestGetAllUsersNotificationsHandler_IntegrationTest_OK(t *testing.T) {
@@ -26,9 +28,9 @@ func TestGetAllUsersNotificationsHandler_IntegrationTest_OK(t *testing.T) {
 	createdNotification := seedNotification(t, db)
 
 	r := gin.Default()
-	repo := repository.NewNotificationRepository(db)
-	resourceUC := usecase.NewNotificationUsecase(repo)
-	handler := rest.NewNotificationHandler(resourceUC)
+	repo := repository.NewNotificationRepository(db, testTimeouts)
+	resourceUC := usecase.NewNotificationUsecase(repo, logging.Discard())
+	handler := rest.NewNotificationHandler(resourceUC, logging.Discard())
 
 	r.GET("/users/:id/notifications", handler.GetAllUsersNotifications)
 
@@ -61,9 +63,9 @@ func TestGetAllUsersNotificationsHandler_IntegrationTest_InvalidUserID(t *testin
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	repo := repository.NewNotificationRepository(db)
-	resourceUC := usecase.NewNotificationUsecase(repo)
-	handler := rest.NewNotificationHandler(resourceUC)
+	repo := repository.NewNotificationRepository(db, testTimeouts)
+	resourceUC := usecase.NewNotificationUsecase(repo, logging.Discard())
+	handler := rest.NewNotificationHandler(resourceUC, logging.Discard())
 
 	r.GET("/users/:id/notifications", handler.GetAllUsersNotifications)
 
@@ -96,9 +98,9 @@ func TestClearAllUsersNotificationsHandler_IntegrationTest_OK(t *testing.T) {
 	createdNotification := seedNotification(t, db)
 
 	r := gin.Default()
-	repo := repository.NewNotificationRepository(db)
-	resourceUC := usecase.NewNotificationUsecase(repo)
-	handler := rest.NewNotificationHandler(resourceUC)
+	repo := repository.NewNotificationRepository(db, testTimeouts)
+	resourceUC := usecase.NewNotificationUsecase(repo, logging.Discard())
+	handler := rest.NewNotificationHandler(resourceUC, logging.Discard())
 
 	r.DELETE("/users/:id/notifications", handler.ClearAllUsersNotifications)
 
@@ -118,7 +120,7 @@ func TestClearAllUsersNotificationsHandler_IntegrationTest_OK(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "All notifications cleared", response["message"])
 
-	notifs, err := repo.GetAllByUserID(createdNotification.UserID)
+	notifs, err := repo.GetAllByUserID(context.Background(), createdNotification.UserID)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(notifs))
 
@@ -134,9 +136,9 @@ func TestClearAllUsersNotificationsHandler_IntegrationTest_InvalidUserID(t *test
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	repo := repository.NewNotificationRepository(db)
-	resourceUC := usecase.NewNotificationUsecase(repo)
-	handler := rest.NewNotificationHandler(resourceUC)
+	repo := repository.NewNotificationRepository(db, testTimeouts)
+	resourceUC := usecase.NewNotificationUsecase(repo, logging.Discard())
+	handler := rest.NewNotificationHandler(resourceUC, logging.Discard())
 
 	r.DELETE("/users/:id/notifications", handler.ClearAllUsersNotifications)
 
@@ -167,9 +169,9 @@ func TestClearSingleNotificationsHandler_IntegrationTest__OK(t *testing.T) {
 	createdNotification := seedNotification(t, db)
 
 	r := gin.Default()
-	repo := repository.NewNotificationRepository(db)
-	resourceUC := usecase.NewNotificationUsecase(repo)
-	handler := rest.NewNotificationHandler(resourceUC)
+	repo := repository.NewNotificationRepository(db, testTimeouts)
+	resourceUC := usecase.NewNotificationUsecase(repo, logging.Discard())
+	handler := rest.NewNotificationHandler(resourceUC, logging.Discard())
 
 	r.DELETE("/notifications/:id", handler.ClearSingleNotification)
 
@@ -200,9 +202,9 @@ func TestClearSingleNotificationsHandler_IntegrationTest_InvalidUserID(t *testin
 	assert.NoError(t, err)
 
 	r := gin.Default()
-	repo := repository.NewNotificationRepository(db)
-	resourceUC := usecase.NewNotificationUsecase(repo)
-	handler := rest.NewNotificationHandler(resourceUC)
+	repo := repository.NewNotificationRepository(db, testTimeouts)
+	resourceUC := usecase.NewNotificationUsecase(repo, logging.Discard())
+	handler := rest.NewNotificationHandler(resourceUC, logging.Discard())
 
 	r.DELETE("/notifications/:id", handler.ClearSingleNotification)
 
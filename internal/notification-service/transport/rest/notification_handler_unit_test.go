@@ -1,6 +1,7 @@
 package rest_test
 
 import (
+	"context"
 	//"bytes"
 	"encoding/json"
 	//"errors"
@@ -14,6 +15,8 @@ import (
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/transport/rest"
+	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
 )
 
 // Mock NotifiicationUsecase
@@ -21,25 +24,25 @@ type mockNotificationUsecase struct {
 	mock.Mock
 }
 
-func (m *mockNotificationUsecase) CreateNotification(userID int64, message string) (*domain.Notification, error) {
+func (m *mockNotificationUsecase) CreateNotification(ctx context.Context, userID int64, message string) (*domain.Notification, error) {
 	args := m.Called(userID, message)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.Notification), args.Error(1)
 }
-func (m *mockNotificationUsecase) GetAllNotifications(userID int64) ([]domain.Notification, error) {
+func (m *mockNotificationUsecase) GetAllNotifications(ctx context.Context, userID int64) ([]domain.Notification, error) {
 	args := m.Called(userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]domain.Notification), args.Error(1)
 }
-func (m *mockNotificationUsecase) ClearNotification(notificationID int64) error {
+func (m *mockNotificationUsecase) ClearNotification(ctx context.Context, notificationID int64) error {
 	args := m.Called(notificationID)
 	return args.Error(0)
 }
-func (m *mockNotificationUsecase) ClearAllNotifications(userID int64) error {
+func (m *mockNotificationUsecase) ClearAllNotifications(ctx context.Context, userID int64) error {
 	args := m.Called(userID)
 	return args.Error(0)
 }
@@ -48,7 +51,7 @@ func TestGetAllUsersNotificationsHandler_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockNotificationUsecase)
-	handler := rest.NewNotificationHandler(mockUC)
+	handler := rest.NewNotificationHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -83,7 +86,7 @@ func TestGetAllUsersNotificationsHandler_InvalidUserID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockNotificationUsecase)
-	handler := rest.NewNotificationHandler(mockUC)
+	handler := rest.NewNotificationHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -109,7 +112,7 @@ func TestClearSingleNotificationHandler_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockNotificationUsecase)
-	handler := rest.NewNotificationHandler(mockUC)
+	handler := rest.NewNotificationHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -137,7 +140,7 @@ func TestClearSingleNotificationHandler_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockNotificationUsecase)
-	handler := rest.NewNotificationHandler(mockUC)
+	handler := rest.NewNotificationHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -159,11 +162,38 @@ func TestClearSingleNotificationHandler_InvalidID(t *testing.T) {
 	mockUC.AssertExpectations(t)
 }
 
+func TestClearSingleNotificationHandler_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUC := new(mockNotificationUsecase)
+	handler := rest.NewNotificationHandler(mockUC, logging.Discard())
+
+	// Setup Gin
+	r := gin.Default()
+	r.DELETE("/notifications/:id", handler.ClearSingleNotification)
+
+	mockUC.On("ClearNotification", int64(2)).Return(usecase.ErrNotificationNotFound)
+
+	req, _ := http.NewRequest("DELETE", "/notifications/2", nil)
+	w := httptest.NewRecorder()
+
+	// Perform request
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "notification not found", resp["error"])
+
+	mockUC.AssertExpectations(t)
+}
+
 func TestClearAllUserNotificationsHandler_OK(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockNotificationUsecase)
-	handler := rest.NewNotificationHandler(mockUC)
+	handler := rest.NewNotificationHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
@@ -191,7 +221,7 @@ func TestClearAllUserNotificationsHandler_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockUC := new(mockNotificationUsecase)
-	handler := rest.NewNotificationHandler(mockUC)
+	handler := rest.NewNotificationHandler(mockUC, logging.Discard())
 
 	// Setup Gin
 	r := gin.Default()
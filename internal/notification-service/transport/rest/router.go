@@ -1,23 +1,54 @@
 package rest
 
 import (
+	"log/slog"
+
 	"github.com/gin-gonic/gin"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/service"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/health"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/metrics"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
 )
 
+// serviceName is reported as the server name on HTTP spans.
+const serviceName = "notification-service"
+
 func NewRouter(
 	notificationUC usecase.NotificationUsecase,
 	notifier service.Notifier, // if you want to use it in the handlers
+	checker *health.Checker,
+	authenticator *auth.Authenticator,
+	logger *slog.Logger,
 ) *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// Server spans, continuing the caller's trace
+	r.Use(tracing.GinMiddleware(serviceName))
+
+	// Request IDs and one log line per request
+	logging.Register(r, logger)
+
+	// Request metrics and GET /metrics
+	metrics.Register(r)
+
+	// Liveness and readiness probes
+	health.Register(r, checker)
+
+	// Every API call needs an API key or a user token
+	apiRouter := r.Group("/api/v1/", auth.Middleware(authenticator))
 
-	apiRouter := r.Group("/api/v1/")
+	// Users may only address their own notifications; a notification's
+	// owner is checked by the usecase
+	ownUser := auth.RequireCustomer("id")
 
 	// Notification endpoints
-	notificationHandler := NewNotificationHandler(notificationUC)
-	apiRouter.GET("/users/:id/notifications", notificationHandler.GetAllUsersNotifications)
-	apiRouter.DELETE("/users/:id/notifications", notificationHandler.ClearAllUsersNotifications)
+	notificationHandler := NewNotificationHandler(notificationUC, logger)
+	apiRouter.GET("/users/:id/notifications", ownUser, notificationHandler.GetAllUsersNotifications)
+	apiRouter.DELETE("/users/:id/notifications", ownUser, notificationHandler.ClearAllUsersNotifications)
 	apiRouter.DELETE("/notifications/:id", notificationHandler.ClearSingleNotification)
 
 	return r
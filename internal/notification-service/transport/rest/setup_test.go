@@ -13,6 +13,8 @@ import (
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/domain"
 	_ "github.com/lib/pq"
 
+	dbpkg "github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -20,6 +22,9 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
+// testTimeouts disables per-operation deadlines for the integration tests.
+var testTimeouts = dbpkg.Timeouts{}
+
 func createPostgresContainer(t *testing.T, dbName, dbUser, dbPassword string, logger *slog.Logger) (string, string) {
 	t.Helper()
 	ctx := context.Background()
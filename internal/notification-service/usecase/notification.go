@@ -1,30 +1,40 @@
 package usecase
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"log/slog"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/repository"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
 )
 
+// ErrNotificationNotFound is also returned for notifications that belong to
+// another user, so callers cannot probe for their existence.
+var ErrNotificationNotFound = errors.New("notification not found")
+
 type NotificationUsecase interface {
-	CreateNotification(userID int64, message string) (*domain.Notification, error)
-	GetAllNotifications(userID int64) ([]domain.Notification, error)
-	ClearNotification(notificationID int64) error
-	ClearAllNotifications(userID int64) error
+	CreateNotification(ctx context.Context, userID int64, message string) (*domain.Notification, error)
+	GetAllNotifications(ctx context.Context, userID int64) ([]domain.Notification, error)
+	ClearNotification(ctx context.Context, notificationID int64) error
+	ClearAllNotifications(ctx context.Context, userID int64) error
 }
 
 type notificationUC struct {
 	notificationRepo repository.NotificationRepository
+	logger           *slog.Logger
 }
 
-func NewNotificationUsecase(notificationRepo repository.NotificationRepository) NotificationUsecase {
+func NewNotificationUsecase(notificationRepo repository.NotificationRepository, logger *slog.Logger) NotificationUsecase {
 	return &notificationUC{
 		notificationRepo: notificationRepo,
+		logger:           logger,
 	}
 }
 
-func (uc *notificationUC) CreateNotification(userID int64, message string) (*domain.Notification, error) {
+func (uc *notificationUC) CreateNotification(ctx context.Context, userID int64, message string) (*domain.Notification, error) {
 	if userID <= 0 {
 		return nil, errors.New("invalid user id")
 	}
@@ -36,29 +46,54 @@ func (uc *notificationUC) CreateNotification(userID int64, message string) (*dom
 		UserID:  userID,
 		Message: message,
 	}
-	if err := uc.notificationRepo.Create(n); err != nil {
+	if err := uc.notificationRepo.Create(ctx, n); err != nil {
+		uc.logger.ErrorContext(ctx, "error creating notification", "user_id", userID, "error", err)
 		return nil, err
 	}
 	return n, nil
 }
 
-func (uc *notificationUC) GetAllNotifications(userID int64) ([]domain.Notification, error) {
+func (uc *notificationUC) GetAllNotifications(ctx context.Context, userID int64) ([]domain.Notification, error) {
 	if userID <= 0 {
 		return nil, errors.New("invalid user id")
 	}
-	return uc.notificationRepo.GetAllByUserID(userID)
+	return uc.notificationRepo.GetAllByUserID(ctx, userID)
 }
 
-func (uc *notificationUC) ClearNotification(notificationID int64) error {
+func (uc *notificationUC) ClearNotification(ctx context.Context, notificationID int64) error {
 	if notificationID <= 0 {
 		return errors.New("invalid notification id")
 	}
-	return uc.notificationRepo.DeleteByID(notificationID)
+
+	n, err := uc.notificationRepo.GetByID(ctx, notificationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotificationNotFound
+		}
+		uc.logger.ErrorContext(ctx, "error fetching notification", "notification_id", notificationID, "error", err)
+		return err
+	}
+	if err := auth.AuthorizeCustomer(ctx, n.UserID); err != nil {
+		uc.logger.WarnContext(ctx, "notification owned by another user", "notification_id", notificationID)
+		return ErrNotificationNotFound
+	}
+
+	if err := uc.notificationRepo.DeleteByID(ctx, notificationID); err != nil {
+		uc.logger.ErrorContext(ctx, "error clearing notification", "notification_id", notificationID, "error", err)
+		return err
+	}
+	uc.logger.InfoContext(ctx, "notification cleared", "notification_id", notificationID)
+	return nil
 }
 
-func (uc *notificationUC) ClearAllNotifications(userID int64) error {
+func (uc *notificationUC) ClearAllNotifications(ctx context.Context, userID int64) error {
 	if userID <= 0 {
 		return errors.New("invalid user id")
 	}
-	return uc.notificationRepo.DeleteAllByUserID(userID)
+	if err := uc.notificationRepo.DeleteAllByUserID(ctx, userID); err != nil {
+		uc.logger.ErrorContext(ctx, "error clearing notifications", "user_id", userID, "error", err)
+		return err
+	}
+	uc.logger.InfoContext(ctx, "notifications cleared", "user_id", userID)
+	return nil
 }
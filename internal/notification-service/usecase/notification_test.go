@@ -1,11 +1,15 @@
 package usecase_test
 
 import (
+	"context"
+	"database/sql"
 	// "errors"
 	"testing"
 
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/domain"
 	"github.com/iBoBoTi/aqua-sec-inventory/internal/notification-service/usecase"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -15,33 +19,40 @@ type mockNotificationRepo struct {
 	mock.Mock
 }
 
-func (m *mockNotificationRepo) Create(notification *domain.Notification) error {
+func (m *mockNotificationRepo) Create(ctx context.Context, notification *domain.Notification) error {
 	args := m.Called(notification)
 	return args.Error(0)
 }
-func (m *mockNotificationRepo) GetAllByUserID(userID int64) ([]domain.Notification, error) {
+func (m *mockNotificationRepo) GetAllByUserID(ctx context.Context, userID int64) ([]domain.Notification, error) {
 	args := m.Called(userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]domain.Notification), args.Error(1)
 }
-func (m *mockNotificationRepo) DeleteByID(notificationID int64) error {
+func (m *mockNotificationRepo) GetByID(ctx context.Context, notificationID int64) (*domain.Notification, error) {
+	args := m.Called(notificationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+func (m *mockNotificationRepo) DeleteByID(ctx context.Context, notificationID int64) error {
 	args := m.Called(notificationID)
 	return args.Error(0)
 }
-func (m *mockNotificationRepo) DeleteAllByUserID(userID int64) error {
+func (m *mockNotificationRepo) DeleteAllByUserID(ctx context.Context, userID int64) error {
 	args := m.Called(userID)
 	return args.Error(0)
 }
 
 func TestCreateNotification_OK(t *testing.T) {
 	repo := new(mockNotificationRepo)
-	uc := usecase.NewNotificationUsecase(repo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
 
 	repo.On("Create", mock.AnythingOfType("*domain.Notification")).Return(nil)
 
-	cust, err := uc.CreateNotification(int64(2), "test message")
+	cust, err := uc.CreateNotification(context.Background(), int64(2), "test message")
 	assert.NoError(t, err)
 	assert.NotNil(t, cust)
 
@@ -50,9 +61,9 @@ func TestCreateNotification_OK(t *testing.T) {
 
 func TestCreateNotification_InvalidID(t *testing.T) {
 	repo := new(mockNotificationRepo)
-	uc := usecase.NewNotificationUsecase(repo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
 
-	cust, err := uc.CreateNotification(int64(0), "test message")
+	cust, err := uc.CreateNotification(context.Background(), int64(0), "test message")
 	assert.EqualError(t, err, "invalid user id")
 	assert.Nil(t, cust)
 
@@ -61,9 +72,9 @@ func TestCreateNotification_InvalidID(t *testing.T) {
 
 func TestCreateNotification_EmptyMessage(t *testing.T) {
 	repo := new(mockNotificationRepo)
-	uc := usecase.NewNotificationUsecase(repo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
 
-	cust, err := uc.CreateNotification(int64(2), "")
+	cust, err := uc.CreateNotification(context.Background(), int64(2), "")
 	assert.EqualError(t, err, "empty notification message")
 	assert.Nil(t, cust)
 
@@ -72,7 +83,7 @@ func TestCreateNotification_EmptyMessage(t *testing.T) {
 
 func TestGetAllNotifications_OK(t *testing.T) {
 	repo := new(mockNotificationRepo)
-	uc := usecase.NewNotificationUsecase(repo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
 
 	repo.On("GetAllByUserID", int64(1)).Return([]domain.Notification{
 		{
@@ -80,7 +91,7 @@ func TestGetAllNotifications_OK(t *testing.T) {
 		},
 	}, nil)
 
-	notification, err := uc.GetAllNotifications(int64(1))
+	notification, err := uc.GetAllNotifications(context.Background(), int64(1))
 	assert.NoError(t, err)
 	assert.NotNil(t, notification)
 
@@ -89,9 +100,9 @@ func TestGetAllNotifications_OK(t *testing.T) {
 
 func TestGetAllNotifications_InvalidID(t *testing.T) {
 	repo := new(mockNotificationRepo)
-	uc := usecase.NewNotificationUsecase(repo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
 
-	cust, err := uc.GetAllNotifications(int64(0))
+	cust, err := uc.GetAllNotifications(context.Background(), int64(0))
 	assert.EqualError(t, err, "invalid user id")
 	assert.Nil(t, cust)
 
@@ -100,11 +111,52 @@ func TestGetAllNotifications_InvalidID(t *testing.T) {
 
 func TestClearNotification_OK(t *testing.T) {
 	repo := new(mockNotificationRepo)
-	uc := usecase.NewNotificationUsecase(repo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
+
+	repo.On("GetByID", int64(1)).Return(&domain.Notification{ID: 1, UserID: 7}, nil)
+	repo.On("DeleteByID", int64(1)).Return(nil)
+
+	err := uc.ClearNotification(context.Background(), int64(1))
+	assert.NoError(t, err)
+
+	repo.AssertExpectations(t)
+}
+
+func TestClearNotification_NotFound(t *testing.T) {
+	repo := new(mockNotificationRepo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
+
+	repo.On("GetByID", int64(1)).Return(nil, sql.ErrNoRows)
+
+	err := uc.ClearNotification(context.Background(), int64(1))
+	assert.ErrorIs(t, err, usecase.ErrNotificationNotFound)
+
+	repo.AssertExpectations(t)
+}
+
+func TestClearNotification_OtherUsersNotification(t *testing.T) {
+	repo := new(mockNotificationRepo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
+
+	repo.On("GetByID", int64(1)).Return(&domain.Notification{ID: 1, UserID: 7}, nil)
+
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{Kind: auth.KindUser, Subject: "jane", CustomerID: 8})
+	err := uc.ClearNotification(ctx, int64(1))
+	assert.ErrorIs(t, err, usecase.ErrNotificationNotFound)
+
+	repo.AssertNotCalled(t, "DeleteByID", int64(1))
+	repo.AssertExpectations(t)
+}
+
+func TestClearNotification_OwnNotification(t *testing.T) {
+	repo := new(mockNotificationRepo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
 
+	repo.On("GetByID", int64(1)).Return(&domain.Notification{ID: 1, UserID: 7}, nil)
 	repo.On("DeleteByID", int64(1)).Return(nil)
 
-	err := uc.ClearNotification(int64(1))
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{Kind: auth.KindUser, Subject: "jane", CustomerID: 7})
+	err := uc.ClearNotification(ctx, int64(1))
 	assert.NoError(t, err)
 
 	repo.AssertExpectations(t)
@@ -112,9 +164,9 @@ func TestClearNotification_OK(t *testing.T) {
 
 func TestClearNotification_InvalidID(t *testing.T) {
 	repo := new(mockNotificationRepo)
-	uc := usecase.NewNotificationUsecase(repo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
 
-	err := uc.ClearNotification(int64(0))
+	err := uc.ClearNotification(context.Background(), int64(0))
 	assert.EqualError(t, err, "invalid notification id")
 
 	repo.AssertExpectations(t)
@@ -122,11 +174,11 @@ func TestClearNotification_InvalidID(t *testing.T) {
 
 func TestClearAllNotifications_OK(t *testing.T) {
 	repo := new(mockNotificationRepo)
-	uc := usecase.NewNotificationUsecase(repo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
 
 	repo.On("DeleteAllByUserID", int64(1)).Return(nil)
 
-	err := uc.ClearAllNotifications(int64(1))
+	err := uc.ClearAllNotifications(context.Background(), int64(1))
 	assert.NoError(t, err)
 
 	repo.AssertExpectations(t)
@@ -134,9 +186,9 @@ func TestClearAllNotifications_OK(t *testing.T) {
 
 func TestClearAllNotifications_InvalidID(t *testing.T) {
 	repo := new(mockNotificationRepo)
-	uc := usecase.NewNotificationUsecase(repo)
+	uc := usecase.NewNotificationUsecase(repo, logging.Discard())
 
-	err := uc.ClearAllNotifications(int64(0))
+	err := uc.ClearAllNotifications(context.Background(), int64(0))
 	assert.EqualError(t, err, "invalid user id")
 
 	repo.AssertExpectations(t)
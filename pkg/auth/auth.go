@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrUnauthenticated is returned when a request carries no valid
+	// credentials.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when the caller may not access the requested
+	// customer's data.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Kind tells service callers, which act on behalf of any customer, from
+// users, who are bound to one customer.
+type Kind string
+
+const (
+	KindService Kind = "service"
+	KindUser    Kind = "user"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Kind Kind
+	// Subject is the service name for API keys and the sub claim for JWTs.
+	Subject string
+	// CustomerID is the customer a user belongs to; zero for services.
+	CustomerID int64
+}
+
+// CanAccessCustomer reports whether the principal may read or change the
+// data of the given customer.
+func (p Principal) CanAccessCustomer(customerID int64) bool {
+	if p.Kind == KindService {
+		return true
+	}
+	return p.Kind == KindUser && p.CustomerID != 0 && p.CustomerID == customerID
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored by the authentication middleware.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// AuthorizeCustomer returns ErrForbidden unless the caller in ctx may access
+// customerID. Contexts without a principal come from inside the process, such
+// as the notification consumer or the CLI, and are allowed.
+func AuthorizeCustomer(ctx context.Context, customerID int64) error {
+	p, ok := FromContext(ctx)
+	if !ok || p.CanAccessCustomer(customerID) {
+		return nil
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,193 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+	pb "github.com/iBoBoTi/aqua-sec-inventory/proto/notification"
+)
+
+var testConfig = config.AuthConfig{
+	APIKeys:     map[string]string{"service-key": "main-service"},
+	JWTSecret:   "test-secret",
+	JWTIssuer:   "inventory",
+	JWTAudience: "inventory-api",
+}
+
+func newAuthenticator(t *testing.T) *auth.Authenticator {
+	t.Helper()
+	a, err := auth.NewAuthenticator(testConfig)
+	require.NoError(t, err)
+	return a
+}
+
+func userToken(t *testing.T, customerID int64) string {
+	t.Helper()
+	token, err := auth.IssueToken(testConfig, "jane", customerID, time.Minute)
+	require.NoError(t, err)
+	return token
+}
+
+func TestNewAuthenticator_RequiresCredentials(t *testing.T) {
+	_, err := auth.NewAuthenticator(config.AuthConfig{})
+	assert.Error(t, err)
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	a := newAuthenticator(t)
+
+	p, err := a.Authenticate("service-key", "")
+	require.NoError(t, err)
+	assert.Equal(t, auth.Principal{Kind: auth.KindService, Subject: "main-service"}, p)
+
+	_, err = a.Authenticate("wrong-key", "")
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+}
+
+func TestAuthenticate_JWT(t *testing.T) {
+	a := newAuthenticator(t)
+
+	p, err := a.Authenticate("", "Bearer "+userToken(t, 7))
+	require.NoError(t, err)
+	assert.Equal(t, auth.Principal{Kind: auth.KindUser, Subject: "jane", CustomerID: 7}, p)
+}
+
+func TestAuthenticate_RejectsBadTokens(t *testing.T) {
+	a := newAuthenticator(t)
+
+	expired, err := auth.IssueToken(testConfig, "jane", 7, -time.Hour)
+	require.NoError(t, err)
+
+	otherSecret := testConfig
+	otherSecret.JWTSecret = "other-secret"
+	forged, err := auth.IssueToken(otherSecret, "jane", 7, time.Minute)
+	require.NoError(t, err)
+
+	otherIssuer := testConfig
+	otherIssuer.JWTIssuer = "someone-else"
+	wrongIssuer, err := auth.IssueToken(otherIssuer, "jane", 7, time.Minute)
+	require.NoError(t, err)
+
+	noCustomer, err := auth.IssueToken(testConfig, "jane", 0, time.Minute)
+	require.NoError(t, err)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+		"sub": "jane", "customer_id": 7, "iss": "inventory", "aud": "inventory-api",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	for name, header := range map[string]string{
+		"missing":      "",
+		"not bearer":   "Basic " + userToken(t, 7),
+		"garbage":      "Bearer not-a-jwt",
+		"expired":      "Bearer " + expired,
+		"forged":       "Bearer " + forged,
+		"wrong issuer": "Bearer " + wrongIssuer,
+		"no customer":  "Bearer " + noCustomer,
+		"alg none":     "Bearer " + unsigned,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := a.Authenticate("", header)
+			assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+		})
+	}
+}
+
+func TestAuthorizeCustomer(t *testing.T) {
+	user := auth.WithPrincipal(context.Background(), auth.Principal{Kind: auth.KindUser, Subject: "jane", CustomerID: 7})
+	service := auth.WithPrincipal(context.Background(), auth.Principal{Kind: auth.KindService, Subject: "main-service"})
+
+	assert.NoError(t, auth.AuthorizeCustomer(user, 7))
+	assert.ErrorIs(t, auth.AuthorizeCustomer(user, 8), auth.ErrForbidden)
+	assert.NoError(t, auth.AuthorizeCustomer(service, 8))
+	assert.NoError(t, auth.AuthorizeCustomer(context.Background(), 8))
+}
+
+func newTestRouter(a *auth.Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/", auth.Middleware(a))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	api.DELETE("/users/:id/notifications", auth.RequireCustomer("id"), ok)
+	api.GET("/customers", auth.RequireService(), ok)
+	return r
+}
+
+func TestGinMiddleware(t *testing.T) {
+	r := newTestRouter(newAuthenticator(t))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"no credentials", http.MethodDelete, "/users/7/notifications", "", "", http.StatusUnauthorized},
+		{"own customer", http.MethodDelete, "/users/7/notifications", "Authorization", "Bearer " + userToken(t, 7), http.StatusOK},
+		{"other customer", http.MethodDelete, "/users/8/notifications", "Authorization", "Bearer " + userToken(t, 7), http.StatusForbidden},
+		{"service on any customer", http.MethodDelete, "/users/8/notifications", auth.APIKeyHeader, "service-key", http.StatusOK},
+		{"user on service route", http.MethodGet, "/customers", "Authorization", "Bearer " + userToken(t, 7), http.StatusForbidden},
+		{"service on service route", http.MethodGet, "/customers", auth.APIKeyHeader, "service-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := auth.UnaryServerInterceptor(newAuthenticator(t))
+	info := &grpc.UnaryServerInfo{FullMethod: "/notification.NotificationService/ClearAllNotifications"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, ok := auth.FromContext(ctx)
+		assert.True(t, ok)
+		return "ok", nil
+	}
+	call := func(md metadata.MD, userID int64) error {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		_, err := interceptor(ctx, &pb.ClearAllNotificationsRequest{UserId: userID}, info, handler)
+		return err
+	}
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(call(metadata.MD{}, 7)))
+	assert.NoError(t, call(metadata.Pairs("authorization", "Bearer "+userToken(t, 7)), 7))
+	assert.Equal(t, codes.PermissionDenied, status.Code(call(metadata.Pairs("authorization", "Bearer "+userToken(t, 7)), 8)))
+	assert.NoError(t, call(metadata.Pairs("x-api-key", "service-key"), 8))
+}
+
+func TestUnaryServerInterceptor_SkipsHealth(t *testing.T) {
+	interceptor := auth.UnaryServerInterceptor(newAuthenticator(t))
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethodPrefixes are served without credentials so that probes and
+// tooling keep working.
+var publicMethodPrefixes = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+// customerScoped is implemented by requests that address one customer's
+// data, such as the notification requests keyed by user ID.
+type customerScoped interface {
+	GetUserId() int64
+}
+
+// UnaryServerInterceptor authenticates calls from the x-api-key or
+// authorization metadata and, for requests addressing a customer, checks the
+// caller may access it.
+func UnaryServerInterceptor(a *Authenticator) grpc.UnaryServerInterceptor {
+	apiKeyKey := strings.ToLower(APIKeyHeader)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for _, prefix := range publicMethodPrefixes {
+			if strings.HasPrefix(info.FullMethod, prefix) {
+				return handler(ctx, req)
+			}
+		}
+
+		var apiKey, authorization string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if vals := md.Get(apiKeyKey); len(vals) > 0 {
+				apiKey = vals[0]
+			}
+			if vals := md.Get("authorization"); len(vals) > 0 {
+				authorization = vals[0]
+			}
+		}
+		p, err := a.Authenticate(apiKey, authorization)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+		}
+		ctx = WithPrincipal(ctx, p)
+
+		if scoped, ok := req.(customerScoped); ok {
+			if err := AuthorizeCustomer(ctx, scoped.GetUserId()); err != nil {
+				return nil, status.Error(codes.PermissionDenied, "permission denied")
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
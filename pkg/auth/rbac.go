@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Role is a named set of permissions granted to a principal.
+type Role string
+
+const (
+	// RoleAdmin may perform every operation, including changes to the
+	// shared resource catalogue.
+	RoleAdmin Role = "admin"
+	// RoleOperator manages customers and their resource assignments.
+	RoleOperator Role = "operator"
+	// RoleCustomerViewer may only read.
+	RoleCustomerViewer Role = "customer-viewer"
+)
+
+// Roles lists every known role.
+var Roles = []Role{RoleAdmin, RoleOperator, RoleCustomerViewer}
+
+// ParseRole validates a role name.
+func ParseRole(s string) (Role, error) {
+	for _, r := range Roles {
+		if string(r) == s {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("unknown role %q, expected one of %v", s, Roles)
+}
+
+// Operation names an action subject to authorization, such as a usecase
+// method.
+type Operation string
+
+// Policy maps each operation to the roles allowed to perform it. Operations
+// missing from the policy are denied.
+type Policy map[Operation][]Role
+
+// Allows reports whether any of roles may perform op.
+func (p Policy) Allows(roles []Role, op Operation) bool {
+	for _, allowed := range p[op] {
+		for _, r := range roles {
+			if r == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RoleStore returns the roles granted to a principal.
+type RoleStore interface {
+	RolesFor(ctx context.Context, kind Kind, subject string) ([]Role, error)
+}
+
+// Enforcer checks callers against a policy, looking up their roles on each
+// call so that grants and revocations apply immediately.
+type Enforcer struct {
+	policy Policy
+	store  RoleStore
+}
+
+func NewEnforcer(policy Policy, store RoleStore) *Enforcer {
+	return &Enforcer{policy: policy, store: store}
+}
+
+// Authorize returns ErrForbidden unless the caller in ctx holds a role
+// allowed to perform op. As with AuthorizeCustomer, contexts without a
+// principal are internal and allowed.
+func (e *Enforcer) Authorize(ctx context.Context, op Operation) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	roles, err := e.store.RolesFor(ctx, p.Kind, p.Subject)
+	if err != nil {
+		return fmt.Errorf("looking up roles: %w", err)
+	}
+	if !e.policy.Allows(roles, op) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Require only lets through callers allowed to perform op.
+func (e *Enforcer) Require(op Operation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := e.Authorize(c.Request.Context(), op)
+		switch {
+		case err == nil:
+			c.Next()
+		case errors.Is(err, ErrForbidden):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		default:
+			_ = c.Error(err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+	}
+}
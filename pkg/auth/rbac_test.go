@@ -0,0 +1,90 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/auth"
+)
+
+const opUpdate auth.Operation = "ResourceUsecase.UpdateResource"
+
+var testPolicy = auth.Policy{opUpdate: {auth.RoleAdmin}}
+
+// fakeRoleStore keys roles by "kind/subject".
+type fakeRoleStore map[string][]auth.Role
+
+func (s fakeRoleStore) RolesFor(ctx context.Context, kind auth.Kind, subject string) ([]auth.Role, error) {
+	if subject == "broken" {
+		return nil, errors.New("connection refused")
+	}
+	return s[string(kind)+"/"+subject], nil
+}
+
+func TestParseRole(t *testing.T) {
+	role, err := auth.ParseRole("customer-viewer")
+	require.NoError(t, err)
+	assert.Equal(t, auth.RoleCustomerViewer, role)
+
+	_, err = auth.ParseRole("root")
+	assert.Error(t, err)
+}
+
+func TestPolicy_Allows(t *testing.T) {
+	assert.True(t, testPolicy.Allows([]auth.Role{auth.RoleOperator, auth.RoleAdmin}, opUpdate))
+	assert.False(t, testPolicy.Allows([]auth.Role{auth.RoleOperator}, opUpdate))
+	assert.False(t, testPolicy.Allows(nil, opUpdate))
+	assert.False(t, testPolicy.Allows([]auth.Role{auth.RoleAdmin}, "Unknown.Operation"))
+}
+
+func TestEnforcer_Authorize(t *testing.T) {
+	e := auth.NewEnforcer(testPolicy, fakeRoleStore{
+		"user/alice":    {auth.RoleAdmin},
+		"user/bob":      {auth.RoleOperator},
+		"service/alice": nil,
+	})
+	as := func(kind auth.Kind, subject string) context.Context {
+		return auth.WithPrincipal(context.Background(), auth.Principal{Kind: kind, Subject: subject})
+	}
+
+	assert.NoError(t, e.Authorize(as(auth.KindUser, "alice"), opUpdate))
+	assert.ErrorIs(t, e.Authorize(as(auth.KindUser, "bob"), opUpdate), auth.ErrForbidden)
+	// Bindings are per principal kind
+	assert.ErrorIs(t, e.Authorize(as(auth.KindService, "alice"), opUpdate), auth.ErrForbidden)
+	assert.NoError(t, e.Authorize(context.Background(), opUpdate))
+
+	err := e.Authorize(as(auth.KindUser, "broken"), opUpdate)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, auth.ErrForbidden)
+}
+
+func TestEnforcer_Require(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := auth.NewEnforcer(testPolicy, fakeRoleStore{"user/alice": {auth.RoleAdmin}})
+
+	for subject, want := range map[string]int{
+		"alice":  http.StatusOK,
+		"bob":    http.StatusForbidden,
+		"broken": http.StatusInternalServerError,
+	} {
+		t.Run(subject, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				p := auth.Principal{Kind: auth.KindUser, Subject: subject, CustomerID: 1}
+				c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+			})
+			r.PUT("/resources/:id", e.Require(opUpdate), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/resources/1", nil))
+			assert.Equal(t, want, w.Code)
+		})
+	}
+}
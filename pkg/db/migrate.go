@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// ErrSchemaVersion is returned when the database schema is not at the
+// version the migrations expect.
+var ErrSchemaVersion = errors.New("unexpected database schema version")
+
+// NewMigrator returns a goose provider for the migrations in fsys. Every
+// migration it runs holds a Postgres advisory lock, so replicas migrating
+// at the same time take turns instead of racing each other.
+//
+// Closing the provider closes conn.
+func NewMigrator(conn *sql.DB, fsys fs.FS) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, conn, fsys, goose.WithSessionLocker(locker))
+}
+
+// PrepareSchema makes sure conn has exactly the migrations in fsys applied.
+// With migrate set, pending migrations are applied first; otherwise they
+// are left to the migrate command and reported as ErrSchemaVersion.
+func PrepareSchema(ctx context.Context, conn *sql.DB, fsys fs.FS, migrate bool, logger *slog.Logger) error {
+	// The provider is not closed, as that would close conn
+	provider, err := NewMigrator(conn, fsys)
+	if err != nil {
+		return err
+	}
+	if migrate {
+		results, err := provider.Up(ctx)
+		var partial *goose.PartialError
+		if errors.As(err, &partial) {
+			results = partial.Applied
+		}
+		for _, result := range results {
+			logger.Info("applied migration", "migration", filepath.Base(result.Source.Path), "duration", result.Duration)
+		}
+		if err != nil {
+			return fmt.Errorf("apply migrations: %w", err)
+		}
+	}
+	return CheckSchema(ctx, provider)
+}
+
+// CheckSchema returns ErrSchemaVersion unless every migration known to
+// provider has been applied, and nothing newer. The check waits for
+// migrations another replica is running to finish.
+func CheckSchema(ctx context.Context, provider *goose.Provider) error {
+	sources := provider.ListSources()
+	expected := sources[len(sources)-1].Version
+
+	// Unlike HasPending, GetDBVersion takes the advisory lock
+	current, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("get schema version: %w", err)
+	}
+	if current > expected {
+		return fmt.Errorf("%w: database is at version %d but this binary expects %d; deploy a newer release or roll back with 'migrate to %d'",
+			ErrSchemaVersion, current, expected, expected)
+	}
+	pending, err := provider.HasPending(ctx)
+	if err != nil {
+		return fmt.Errorf("check pending migrations: %w", err)
+	}
+	if pending {
+		return fmt.Errorf("%w: database is at version %d but this binary expects %d; run 'migrate up' or start with --migrate",
+			ErrSchemaVersion, current, expected)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package db_test
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/cmd"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/db"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+// migrationsUpTo returns the built-in migrations up to and including version.
+func migrationsUpTo(t *testing.T, version int64) fs.FS {
+	t.Helper()
+	entries, err := fs.ReadDir(cmd.Migrations(), ".")
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{}
+	for _, entry := range entries {
+		v, err := goose.NumericComponent(entry.Name())
+		require.NoError(t, err)
+		if v > version {
+			continue
+		}
+		data, err := fs.ReadFile(cmd.Migrations(), entry.Name())
+		require.NoError(t, err)
+		fsys[entry.Name()] = &fstest.MapFile{Data: data}
+	}
+	return fsys
+}
+
+func latestVersion(t *testing.T, provider *goose.Provider) int64 {
+	t.Helper()
+	sources := provider.ListSources()
+	require.NotEmpty(t, sources)
+	return sources[len(sources)-1].Version
+}
+
+func TestMigrations_IntegrationTest_RoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	conn := setUpTestDB(t)
+	ctx := context.Background()
+
+	provider, err := db.NewMigrator(conn, cmd.Migrations())
+	require.NoError(t, err)
+	sources := provider.ListSources()
+	latest := latestVersion(t, provider)
+
+	_, err = provider.Up(ctx)
+	require.NoError(t, err)
+	migrated := tables(t, conn)
+	assert.Contains(t, migrated, "audit_events")
+
+	// Every down migration runs and removes what its up migration created
+	results, err := provider.DownTo(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, results, len(sources))
+	assert.Empty(t, tables(t, conn))
+	version, err := provider.GetDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), version)
+
+	// and the schema can be built again from scratch
+	_, err = provider.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrated, tables(t, conn))
+	version, err = provider.GetDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+
+	// The append-only trigger is back
+	_, err = conn.Exec(`INSERT INTO audit_events (actor_kind, actor, action, entity_type, entity_id) VALUES ('system', 'test', 'create', 'customer', 1)`)
+	require.NoError(t, err)
+	_, err = conn.Exec(`DELETE FROM audit_events`)
+	assert.ErrorContains(t, err, "append-only")
+}
+
+func TestPrepareSchema_IntegrationTest_Behind(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	conn := setUpTestDB(t)
+	ctx := context.Background()
+
+	provider, err := db.NewMigrator(conn, cmd.Migrations())
+	require.NoError(t, err)
+	latest := latestVersion(t, provider)
+	_, err = provider.UpTo(ctx, latest-1)
+	require.NoError(t, err)
+
+	// Without migrate the pending migration is reported, not applied
+	err = db.PrepareSchema(ctx, conn, cmd.Migrations(), false, logging.Discard())
+	assert.ErrorIs(t, err, db.ErrSchemaVersion)
+	assert.ErrorContains(t, err, "migrate up")
+	version, err := provider.GetDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest-1, version)
+
+	// With migrate it is applied
+	require.NoError(t, db.PrepareSchema(ctx, conn, cmd.Migrations(), true, logging.Discard()))
+	version, err = provider.GetDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+}
+
+func TestPrepareSchema_IntegrationTest_Empty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	conn := setUpTestDB(t)
+	ctx := context.Background()
+
+	err := db.PrepareSchema(ctx, conn, cmd.Migrations(), false, logging.Discard())
+	assert.ErrorIs(t, err, db.ErrSchemaVersion)
+	assert.Empty(t, tables(t, conn))
+
+	require.NoError(t, db.PrepareSchema(ctx, conn, cmd.Migrations(), true, logging.Discard()))
+	assert.Contains(t, tables(t, conn), "audit_events")
+}
+
+func TestCheckSchema_IntegrationTest_Ahead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	conn := setUpTestDB(t)
+	ctx := context.Background()
+
+	provider, err := db.NewMigrator(conn, cmd.Migrations())
+	require.NoError(t, err)
+	latest := latestVersion(t, provider)
+	_, err = provider.Up(ctx)
+	require.NoError(t, err)
+
+	// An older binary knows one migration fewer than the database has
+	older, err := db.NewMigrator(conn, migrationsUpTo(t, latest-1))
+	require.NoError(t, err)
+	err = db.CheckSchema(ctx, older)
+	assert.ErrorIs(t, err, db.ErrSchemaVersion)
+	assert.ErrorContains(t, err, "roll back")
+
+	// and migrate does not help it
+	err = db.PrepareSchema(ctx, conn, migrationsUpTo(t, latest-1), true, logging.Discard())
+	assert.ErrorIs(t, err, db.ErrSchemaVersion)
+}
+
+func TestCheckSchema_IntegrationTest_Current(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	conn := setUpTestDB(t)
+	ctx := context.Background()
+
+	provider, err := db.NewMigrator(conn, cmd.Migrations())
+	require.NoError(t, err)
+	_, err = provider.Up(ctx)
+	require.NoError(t, err)
+
+	assert.NoError(t, db.CheckSchema(ctx, provider))
+	assert.NoError(t, db.PrepareSchema(ctx, conn, cmd.Migrations(), false, logging.Discard()))
+	assert.NoError(t, db.PrepareSchema(ctx, conn, cmd.Migrations(), true, logging.Discard()))
+}
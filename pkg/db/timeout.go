@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+)
+
+// Timeouts holds the per-operation deadlines applied by the repositories.
+// A zero duration leaves the caller's context untouched.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+func NewTimeouts(cfg config.DBConfig) Timeouts {
+	return Timeouts{
+		Read:  cfg.ReadTimeout,
+		Write: cfg.WriteTimeout,
+	}
+}
+
+// ReadContext derives a context bounded by the read timeout.
+func (t Timeouts) ReadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Read)
+}
+
+// WriteContext derives a context bounded by the write timeout.
+func (t Timeouts) WriteContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Write)
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
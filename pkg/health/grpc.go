@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// SyncGRPC mirrors the checker's state into the standard grpc.health.v1
+// server every interval, for the overall server ("") and each named
+// service, until ctx is cancelled.
+func SyncGRPC(ctx context.Context, c *Checker, srv *grpchealth.Server, interval time.Duration, services ...string) {
+	set := func() {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !c.Check(ctx).OK() {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		srv.SetServingStatus("", status)
+		for _, service := range services {
+			srv.SetServingStatus(service, status)
+		}
+	}
+
+	set()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			set()
+		}
+	}
+}
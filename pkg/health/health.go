@@ -0,0 +1,120 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkTimeout bounds a single dependency probe.
+const checkTimeout = 2 * time.Second
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// CheckFunc probes one dependency and returns nil when it is usable.
+type CheckFunc func(ctx context.Context) error
+
+// Report is the outcome of running every check.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// OK reports whether every check passed.
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs the dependency checks of a service.
+type Checker struct {
+	mu     sync.RWMutex
+	names  []string
+	checks map[string]CheckFunc
+
+	draining atomic.Bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]CheckFunc)}
+}
+
+// Add registers a named dependency check.
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Drain marks the service as shutting down so that readiness fails while
+// in-flight work completes.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Check runs every registered check concurrently.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	names := append([]string(nil), c.names...)
+	checks := make([]CheckFunc, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.RUnlock()
+
+	results := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			results[i] = check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(names))}
+	for i, name := range names {
+		if results[i] != nil {
+			report.Status = StatusUnavailable
+			report.Checks[name] = results[i].Error()
+			continue
+		}
+		report.Checks[name] = StatusOK
+	}
+	if c.draining.Load() {
+		report.Status = StatusUnavailable
+	}
+	return report
+}
+
+// Register adds the probe endpoints to r:
+//
+//	GET /healthz - liveness; always 200 while the process can serve HTTP,
+//	               with the dependency report for information
+//	GET /readyz  - readiness; 503 while a dependency is down or the
+//	               service is shutting down
+func Register(r gin.IRoutes, c *Checker) {
+	r.GET("/healthz", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, c.Check(ctx.Request.Context()))
+	})
+	r.GET("/readyz", func(ctx *gin.Context) {
+		report := c.Check(ctx.Request.Context())
+		if !report.OK() {
+			ctx.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+		ctx.JSON(http.StatusOK, report)
+	})
+}
@@ -0,0 +1,74 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/health"
+)
+
+func okCheck(ctx context.Context) error { return nil }
+
+func downCheck(ctx context.Context) error { return errors.New("connection refused") }
+
+func serve(t *testing.T, c *health.Checker, path string) (int, health.Report) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	health.Register(r, c)
+
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func TestReadyz_OK(t *testing.T) {
+	c := health.NewChecker()
+	c.Add("postgres", okCheck)
+	c.Add("rabbitmq", okCheck)
+
+	code, report := serve(t, c, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, map[string]string{"postgres": "ok", "rabbitmq": "ok"}, report.Checks)
+}
+
+func TestReadyz_DependencyDown(t *testing.T) {
+	c := health.NewChecker()
+	c.Add("postgres", okCheck)
+	c.Add("rabbitmq", downCheck)
+
+	code, report := serve(t, c, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.Equal(t, "connection refused", report.Checks["rabbitmq"])
+}
+
+func TestHealthz_DependencyDownStillLive(t *testing.T) {
+	c := health.NewChecker()
+	c.Add("rabbitmq", downCheck)
+
+	code, report := serve(t, c, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+}
+
+func TestReadyz_Draining(t *testing.T) {
+	c := health.NewChecker()
+	c.Add("postgres", okCheck)
+	c.Drain()
+
+	code, _ := serve(t, c, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quietPaths are polled by orchestrators and scrapers; they are logged at
+// debug level only.
+var quietPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// RequestIDMiddleware takes the request ID from the X-Request-ID header, or
+// generates one, stores it in the request context and echoes it in the
+// response.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = NewRequestID()
+		}
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GinMiddleware logs one line per request once it is handled.
+func GinMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		case quietPaths[c.Request.URL.Path]:
+			level = slog.LevelDebug
+		}
+		logger.Log(c.Request.Context(), level, "http request",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// Register adds the request ID and request logging middleware to r.
+func Register(r *gin.Engine, logger *slog.Logger) {
+	r.Use(RequestIDMiddleware(), GinMiddleware(logger))
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor takes the request ID from the x-request-id
+// metadata, or generates one, and logs one line per call.
+func UnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	key := strings.ToLower(RequestIDHeader)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if vals := md.Get(key); len(vals) > 0 {
+				id = vals[0]
+			}
+		}
+		if !validRequestID(id) {
+			id = NewRequestID()
+		}
+		ctx = WithRequestID(ctx, id)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(key, id))
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		level := slog.LevelInfo
+		if err != nil {
+			level = slog.LevelWarn
+		}
+		logger.Log(ctx, level, "grpc request",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"duration", time.Since(start),
+		)
+		return resp, err
+	}
+}
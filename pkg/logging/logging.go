@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+)
+
+// Formats accepted in config.LoggingConfig.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New builds the service logger writing to w. Every record logged with a
+// context carries the request ID and, when the context is traced, the trace
+// and span IDs. Email addresses are redacted from all values.
+func New(cfg config.LoggingConfig, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+	}
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case FormatJSON, "":
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected %s or %s", cfg.Format, FormatJSON, FormatText)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Discard returns a logger that drops everything, for tests and tools that
+// do not care about the service logs.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// contextHandler adds the correlation IDs found in the record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
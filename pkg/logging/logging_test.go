@@ -0,0 +1,196 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestNew_RejectsInvalidConfig(t *testing.T) {
+	_, err := logging.New(config.LoggingConfig{Level: "loud", Format: "json"}, &bytes.Buffer{})
+	assert.Error(t, err)
+
+	_, err = logging.New(config.LoggingConfig{Level: "info", Format: "xml"}, &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestNew_RespectsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LoggingConfig{Level: "warn", Format: "json"}, &buf)
+	require.NoError(t, err)
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	records := decodeLines(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "shown", records[0]["msg"])
+}
+
+func TestLogger_AddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LoggingConfig{Level: "info", Format: "json"}, &buf)
+	require.NoError(t, err)
+
+	ctx := logging.WithRequestID(context.Background(), "req-123")
+	logger.InfoContext(ctx, "handled")
+
+	records := decodeLines(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "req-123", records[0]["request_id"])
+}
+
+func TestLogger_RedactsEmails(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LoggingConfig{Level: "info", Format: "json"}, &buf)
+	require.NoError(t, err)
+
+	logger.Info("created ebuka@gmail.com",
+		"email", "ebuka@gmail.com",
+		"error", errors.New(`duplicate key: Key (email)=(jane.doe@example.com) already exists`),
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "ebuka@gmail.com")
+	assert.NotContains(t, out, "jane.doe@example.com")
+	assert.Contains(t, out, "e***@gmail.com")
+	assert.Contains(t, out, "j***@example.com")
+}
+
+type customer struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type contact struct{ email string }
+
+func (c contact) String() string { return "contact " + c.email }
+
+type account struct{ owner string }
+
+func (a account) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("owner", a.owner), slog.Any("contact", contact{a.owner}))
+}
+
+func TestLogger_RedactsStructuredValues(t *testing.T) {
+	for _, format := range []string{logging.FormatJSON, logging.FormatText} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := logging.New(config.LoggingConfig{Level: "info", Format: format}, &buf)
+			require.NoError(t, err)
+
+			logger.Info("updated",
+				"customer", customer{ID: 1, Name: "ebuka", Email: "ebuka@gmail.com"},
+				"emails", map[string]string{"old": "jane.doe@example.com"},
+				"contact", contact{"ada@example.com"},
+				slog.Group("request", "actor", "grace@example.com", "customer", &customer{Email: "linus@example.com"}),
+				"account", account{"ken@example.com"},
+			)
+
+			out := buf.String()
+			for _, email := range []string{"ebuka@gmail.com", "jane.doe@example.com", "ada@example.com", "grace@example.com", "linus@example.com", "ken@example.com"} {
+				assert.NotContains(t, out, email)
+			}
+			for _, masked := range []string{"e***@gmail.com", "j***@example.com", "a***@example.com", "g***@example.com", "l***@example.com", "k***@example.com"} {
+				assert.Contains(t, out, masked)
+			}
+		})
+	}
+}
+
+func TestLogger_RedactedStructsStayObjects(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LoggingConfig{Level: "info", Format: "json"}, &buf)
+	require.NoError(t, err)
+
+	logger.Info("updated",
+		"customer", customer{ID: 1, Name: "ebuka", Email: "ebuka@gmail.com"},
+		"tags", map[string]string{"env": "prod"},
+	)
+
+	records := decodeLines(t, &buf)
+	require.Len(t, records, 1)
+	// Still logged as objects
+	assert.Equal(t, map[string]interface{}{"id": float64(1), "name": "ebuka", "email": "e***@gmail.com"}, records[0]["customer"])
+	assert.Equal(t, map[string]interface{}{"env": "prod"}, records[0]["tags"])
+}
+
+func TestRedactEmail(t *testing.T) {
+	assert.Equal(t, "j***@example.com", logging.RedactEmail("jane.doe@example.com"))
+	assert.Equal(t, "***", logging.RedactEmail("not-an-email"))
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LoggingConfig{Level: "info", Format: "json"}, &buf)
+	require.NoError(t, err)
+
+	r := gin.New()
+	logging.Register(r, logger)
+	var seen string
+	r.GET("/ping", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	// A well-formed incoming ID is kept
+	req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(logging.RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "abc-123", seen)
+	assert.Equal(t, "abc-123", w.Header().Get(logging.RequestIDHeader))
+
+	records := decodeLines(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "abc-123", records[0]["request_id"])
+	assert.Equal(t, "/ping", records[0]["route"])
+
+	// A missing or malformed one is replaced
+	req, _ = http.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(logging.RequestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, w.Header().Get(logging.RequestIDHeader))
+}
+
+func TestAMQP_RoundTrip(t *testing.T) {
+	headers := amqp.Table{}
+	logging.InjectAMQP(context.Background(), headers)
+	assert.Empty(t, headers)
+
+	logging.InjectAMQP(logging.WithRequestID(context.Background(), "req-1"), headers)
+	assert.Equal(t, "req-1", logging.RequestID(logging.ExtractAMQP(context.Background(), headers)))
+	assert.Empty(t, logging.RequestID(logging.ExtractAMQP(context.Background(), nil)))
+}
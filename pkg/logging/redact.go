@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// RedactEmail masks the local part of an email address, keeping its first
+// character and the domain: "jane.doe@example.com" becomes
+// "j***@example.com".
+func RedactEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// RedactEmails masks every email address found in s.
+func RedactEmails(s string) string {
+	return emailPattern.ReplaceAllStringFunc(s, RedactEmail)
+}
+
+// redactAttr is the slog ReplaceAttr hook that keeps customer emails out of
+// the logs, whether they are logged directly, as part of a struct, map or
+// group, or end up inside a message or an error such as a unique constraint
+// violation.
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	a.Value = redactValue(a.Value)
+	return a
+}
+
+func redactValue(v slog.Value) slog.Value {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		if s := v.String(); strings.Contains(s, "@") {
+			return slog.StringValue(RedactEmails(s))
+		}
+	case slog.KindGroup:
+		attrs := v.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			redacted[i] = slog.Attr{Key: a.Key, Value: redactValue(a.Value)}
+		}
+		return slog.GroupValue(redacted...)
+	case slog.KindAny:
+		x := v.Any()
+		if err, ok := x.(error); ok {
+			return slog.StringValue(RedactEmails(err.Error()))
+		}
+		if str, ok := x.(fmt.Stringer); ok {
+			if s := str.String(); strings.Contains(s, "@") {
+				return slog.StringValue(RedactEmails(s))
+			}
+		}
+		// Structs and maps are written as JSON by the JSON handler and with
+		// %+v by the text handler, so both forms are checked
+		if data, err := json.Marshal(x); err == nil && bytes.Contains(data, []byte("@")) {
+			return slog.AnyValue(json.RawMessage(RedactEmails(string(data))))
+		}
+		if s := fmt.Sprintf("%+v", x); strings.Contains(s, "@") {
+			return slog.StringValue(RedactEmails(s))
+		}
+	}
+	return v
+}
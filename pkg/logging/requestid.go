@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RequestIDHeader is the HTTP header, and the gRPC metadata key in lower
+// case, that carries the request ID between services.
+const RequestIDHeader = "X-Request-ID"
+
+// AMQPRequestIDHeader carries the request ID in the headers of messages
+// published on behalf of a request.
+const AMQPRequestIDHeader = "x-request-id"
+
+// maxRequestIDLength bounds client-supplied IDs so they cannot bloat every
+// log line.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying id. An empty id leaves ctx unchanged.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random 128-bit ID in hex.
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID accepts client-supplied IDs of printable ASCII only, so
+// they can be logged and forwarded as headers safely.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// InjectAMQP copies the request ID of ctx, if any, into headers, which must
+// not be nil.
+func InjectAMQP(ctx context.Context, headers amqp.Table) {
+	if id := RequestID(ctx); id != "" {
+		headers[AMQPRequestIDHeader] = id
+	}
+}
+
+// ExtractAMQP returns ctx carrying the request ID found in headers, if any.
+func ExtractAMQP(ctx context.Context, headers amqp.Table) context.Context {
+	id, _ := headers[AMQPRequestIDHeader].(string)
+	return WithRequestID(ctx, id)
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that did not match any route, so that
+// arbitrary paths cannot blow up the label cardinality.
+const unmatchedRoute = "unmatched"
+
+// GinMiddleware records HTTP request latency by route template.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// Register adds the metrics middleware and GET /metrics to r.
+func Register(r *gin.Engine) {
+	r.Use(GinMiddleware())
+	r.GET("/metrics", gin.WrapH(Handler()))
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor records the latency and status code of unary gRPC
+// calls.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		GRPCRequestDuration.
+			WithLabelValues(info.FullMethod, status.Code(err).String()).
+			Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Failure stages recorded by NotificationsFailed.
+const (
+	StagePublish  = "publish"
+	StageDecode   = "decode"
+	StageValidate = "validate"
+	StagePersist  = "persist"
+)
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	GRPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Latency of unary gRPC calls by method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	NotificationsPublished = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notifications_published_total",
+		Help: "Notifications confirmed by the broker.",
+	})
+
+	NotificationsConsumed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notifications_consumed_total",
+		Help: "Notifications received from the broker.",
+	})
+
+	NotificationsPersisted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notifications_persisted_total",
+		Help: "Notifications stored in the database.",
+	})
+
+	NotificationsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_failed_total",
+		Help: "Notifications that failed, by the stage they failed in.",
+	}, []string{"stage"})
+)
+
+// RegisterDB exports the connection pool statistics of conn, labelled with
+// dbName.
+func RegisterDB(conn *sql.DB, dbName string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(conn, dbName))
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
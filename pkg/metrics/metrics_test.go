@@ -0,0 +1,61 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/metrics"
+)
+
+func TestGinMiddleware_RecordsRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	metrics.Register(r)
+	r.GET("/customers/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	before := testutil.CollectAndCount(metrics.HTTPRequestDuration)
+
+	req, _ := http.NewRequest(http.MethodGet, "/customers/42", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	req, _ = http.NewRequest(http.MethodGet, "/no/such/path", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// One series per route template, not per concrete path
+	assert.Equal(t, before+2, testutil.CollectAndCount(metrics.HTTPRequestDuration))
+
+	req, _ = http.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), `route="/customers/:id"`))
+	assert.True(t, strings.Contains(w.Body.String(), `route="unmatched"`))
+}
+
+func TestUnaryServerInterceptor_RecordsCode(t *testing.T) {
+	interceptor := metrics.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/notifications.NotificationService/GetAllNotifications"}
+
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "nope")
+	})
+	assert.Error(t, err)
+
+	_, err = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("plain error")
+	})
+	assert.Error(t, err)
+
+	// Plain errors are reported as Unknown
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.GRPCRequestDuration))
+}
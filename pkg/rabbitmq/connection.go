@@ -0,0 +1,314 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+)
+
+// ErrClosed is returned once Close has been called on the Connection.
+var ErrClosed = errors.New("rabbitmq connection closed")
+
+// ErrNotConnected is returned by Channel while a reconnect is in progress.
+var ErrNotConnected = errors.New("rabbitmq not connected")
+
+var consumerSeq atomic.Uint64
+
+type State int
+
+const (
+	StateConnecting State = iota
+	StateConnected
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// Connection keeps an AMQP connection open for the lifetime of the service,
+// with one channel for consuming and one in confirm mode for publishing. When
+// the broker closes any of them it reconnects with exponential backoff and
+// redeclares the notification topology before the channels are handed out
+// again.
+type Connection struct {
+	cfg    config.RabbitMQConfig
+	logger *slog.Logger
+
+	mu    sync.RWMutex
+	conn  *amqp.Connection
+	ch    *amqp.Channel
+	pubCh *amqp.Channel
+	state State
+	// ready is closed while connected and replaced when the connection drops.
+	ready chan struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial connects to the broker. The first attempt is made synchronously so
+// that a wrong URL fails at startup; later reconnects happen in the
+// background.
+func Dial(cfg config.RabbitMQConfig, logger *slog.Logger) (*Connection, error) {
+	c := &Connection{
+		cfg:    cfg,
+		logger: logger.With("component", "rabbitmq"),
+		ready:  make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// State reports whether the connection is currently usable.
+func (c *Connection) State() State {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// Channel returns the current channel, or ErrNotConnected while
+// reconnecting.
+func (c *Connection) Channel() (*amqp.Channel, error) {
+	return c.channel(func() *amqp.Channel { return c.ch })
+}
+
+// ConfirmChannel returns the current channel in publisher confirm mode, or
+// ErrNotConnected while reconnecting.
+func (c *Connection) ConfirmChannel() (*amqp.Channel, error) {
+	return c.channel(func() *amqp.Channel { return c.pubCh })
+}
+
+func (c *Connection) channel(get func() *amqp.Channel) (*amqp.Channel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	switch c.state {
+	case StateConnected:
+		return get(), nil
+	case StateClosed:
+		return nil, ErrClosed
+	default:
+		return nil, ErrNotConnected
+	}
+}
+
+// Consume delivers messages from queue to handle, resuming on the new
+// channel after every reconnect. It blocks until ctx is cancelled or the
+// Connection is closed; on cancellation the message being handled is
+// finished and the consumer is cancelled so that prefetched messages go back
+// to the queue. handle is responsible for acknowledging.
+func (c *Connection) Consume(ctx context.Context, queue string, prefetch int, handle func(amqp.Delivery)) error {
+	for {
+		ch, err := c.waitReady(ctx)
+		if err != nil {
+			return err
+		}
+
+		tag := fmt.Sprintf("%s-%d-%d", queue, os.Getpid(), consumerSeq.Add(1))
+		msgs, err := c.subscribe(ch, queue, tag, prefetch)
+		if err != nil {
+			c.logger.Error("error starting consumer", "queue", queue, "error", err)
+			// Give the close notification a chance to flip the state
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.done:
+				return ErrClosed
+			case <-time.After(c.cfg.ReconnectBaseDelay):
+			}
+			continue
+		}
+
+		c.logger.Info("consuming", "queue", queue)
+	deliveries:
+		for {
+			select {
+			case <-ctx.Done():
+				_ = ch.Cancel(tag, false)
+				return ctx.Err()
+			case d, ok := <-msgs:
+				if !ok {
+					break deliveries
+				}
+				handle(d)
+			}
+		}
+		c.logger.Warn("consumer stopped, waiting for reconnect", "queue", queue)
+	}
+}
+
+// Close stops reconnecting and closes the underlying connection.
+func (c *Connection) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.state = StateClosed
+		if c.pubCh != nil {
+			_ = c.pubCh.Close()
+		}
+		if c.ch != nil {
+			_ = c.ch.Close()
+		}
+		if c.conn != nil {
+			_ = c.conn.Close()
+		}
+	})
+}
+
+func (c *Connection) subscribe(ch *amqp.Channel, queue, tag string, prefetch int) (<-chan amqp.Delivery, error) {
+	if prefetch > 0 {
+		if err := ch.Qos(prefetch, 0, false); err != nil {
+			return nil, err
+		}
+	}
+	return ch.Consume(queue, tag, false, false, false, false, nil)
+}
+
+func (c *Connection) waitReady(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		c.mu.RLock()
+		ready, state, ch := c.ready, c.state, c.ch
+		c.mu.RUnlock()
+
+		switch state {
+		case StateConnected:
+			return ch, nil
+		case StateClosed:
+			return nil, ErrClosed
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.done:
+			return nil, ErrClosed
+		case <-ready:
+		}
+	}
+}
+
+func (c *Connection) connect() error {
+	conn, err := amqp.Dial(c.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("error dailing RabbitMQ server: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("error opening rabbitmq channel: %w", err)
+	}
+	if _, err := DeclareTopology(ch); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	// Not fatal: the service works without it and it is tried again on the
+	// next connect
+	if n, err := DrainLegacyQueue(conn); err != nil {
+		c.logger.Warn("error draining legacy queue", "queue", LegacyNotificationsQueue, "error", err)
+	} else if n > 0 {
+		c.logger.Info("moved messages from legacy queue", "queue", LegacyNotificationsQueue, "count", n)
+	}
+	pubCh, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("error opening rabbitmq channel: %w", err)
+	}
+	if err := pubCh.Confirm(false); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("error enabling publisher confirms: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.state == StateClosed {
+		c.mu.Unlock()
+		_ = conn.Close()
+		return ErrClosed
+	}
+	c.conn, c.ch, c.pubCh = conn, ch, pubCh
+	c.state = StateConnected
+	close(c.ready)
+	c.mu.Unlock()
+
+	go c.watch(conn, ch, pubCh)
+	return nil
+}
+
+// watch waits for the connection or one of its channels to close and then
+// reconnects.
+func (c *Connection) watch(conn *amqp.Connection, ch, pubCh *amqp.Channel) {
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	pubClosed := pubCh.NotifyClose(make(chan *amqp.Error, 1))
+
+	select {
+	case <-c.done:
+		return
+	case err := <-connClosed:
+		c.logger.Warn("connection closed", "error", err)
+	case err := <-chClosed:
+		c.logger.Warn("channel closed", "error", err)
+	case err := <-pubClosed:
+		c.logger.Warn("publish channel closed", "error", err)
+	}
+
+	c.mu.Lock()
+	if c.state == StateClosed {
+		c.mu.Unlock()
+		return
+	}
+	c.state = StateConnecting
+	c.ready = make(chan struct{})
+	c.mu.Unlock()
+	_ = conn.Close()
+
+	for attempt := 1; ; attempt++ {
+		delay := Backoff(attempt, c.cfg.ReconnectBaseDelay, c.cfg.ReconnectMaxDelay)
+		select {
+		case <-c.done:
+			return
+		case <-time.After(delay):
+		}
+
+		err := c.connect()
+		if err == nil {
+			c.logger.Info("reconnected", "attempts", attempt)
+			return
+		}
+		if errors.Is(err, ErrClosed) {
+			return
+		}
+		c.logger.Warn("reconnect attempt failed", "attempt", attempt, "error", err)
+	}
+}
+
+// Check reports an error unless the connection is established; it is meant
+// for readiness probes.
+func (c *Connection) Check(ctx context.Context) error {
+	if state := c.State(); state != StateConnected {
+		return fmt.Errorf("rabbitmq %s", state)
+	}
+	return nil
+}
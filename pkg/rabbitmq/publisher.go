@@ -0,0 +1,255 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+)
+
+// ErrBufferFull is returned by Publish when the publish buffer is full.
+var ErrBufferFull = errors.New("rabbitmq publish buffer full")
+
+// publishBatchSize bounds how many buffered messages are published before
+// waiting for their confirmations.
+const publishBatchSize = 100
+
+// ConfirmChannelSource hands out the channel to publish on; *Connection
+// implements it.
+type ConfirmChannelSource interface {
+	ConfirmChannel() (*amqp.Channel, error)
+}
+
+// PublisherStats counts what happened to the messages handed to Publish.
+type PublisherStats struct {
+	Queued    uint64
+	Confirmed uint64
+	Retried   uint64
+	Failed    uint64
+	Dropped   uint64
+}
+
+type pendingPublish struct {
+	exchange string
+	key      string
+	msg      amqp.Publishing
+	done     func(error)
+	attempts int
+}
+
+// Publisher publishes messages in the background with publisher confirms.
+// Publish only appends to a bounded buffer, so callers are never held up by
+// a slow or unreachable broker. Messages the broker nacks, or does not
+// confirm in time, are published again with backoff; the outcome is reported
+// through the per-message callback and Stats.
+type Publisher struct {
+	source ConfirmChannelSource
+	cfg    config.RabbitMQConfig
+	logger *slog.Logger
+
+	buf chan *pendingPublish
+
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}
+	stopped chan struct{}
+
+	queued, confirmed, retried, failed, dropped atomic.Uint64
+}
+
+func NewPublisher(source ConfirmChannelSource, cfg config.RabbitMQConfig, logger *slog.Logger) *Publisher {
+	size := cfg.PublishBufferSize
+	if size <= 0 {
+		size = 1
+	}
+	p := &Publisher{
+		source:  source,
+		cfg:     cfg,
+		logger:  logger.With("component", "rabbitmq"),
+		buf:     make(chan *pendingPublish, size),
+		closing: make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Publish queues msg without waiting for the broker. done, if not nil, is
+// called from the publisher goroutine with nil once the broker confirmed the
+// message, or with the error that made the publisher give up on it.
+func (p *Publisher) Publish(exchange, key string, msg amqp.Publishing, done func(error)) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.buf <- &pendingPublish{exchange: exchange, key: key, msg: msg, done: done}:
+		p.queued.Add(1)
+		return nil
+	default:
+		p.dropped.Add(1)
+		return ErrBufferFull
+	}
+}
+
+func (p *Publisher) Stats() PublisherStats {
+	return PublisherStats{
+		Queued:    p.queued.Load(),
+		Confirmed: p.confirmed.Load(),
+		Retried:   p.retried.Load(),
+		Failed:    p.failed.Load(),
+		Dropped:   p.dropped.Load(),
+	}
+}
+
+// Close stops accepting messages and waits until the buffered ones have
+// been confirmed or given up on.
+func (p *Publisher) Close() {
+	_ = p.Shutdown(context.Background())
+}
+
+// Shutdown is Close bounded by ctx. It returns ctx.Err() if buffered
+// messages were still in flight when ctx ended.
+func (p *Publisher) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.closing)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Publisher) run() {
+	defer close(p.stopped)
+
+	for {
+		select {
+		case m := <-p.buf:
+			p.send(p.collect(m))
+		case <-p.closing:
+			// Publish can no longer add to buf, so draining it is final
+			for {
+				select {
+				case m := <-p.buf:
+					p.send(p.collect(m))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// collect gathers whatever else is already buffered behind first.
+func (p *Publisher) collect(first *pendingPublish) []*pendingPublish {
+	batch := []*pendingPublish{first}
+	for len(batch) < publishBatchSize {
+		select {
+		case m := <-p.buf:
+			batch = append(batch, m)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// send publishes batch and waits for the confirmations, republishing what
+// was not confirmed until it succeeds or runs out of retries.
+func (p *Publisher) send(batch []*pendingPublish) {
+	for len(batch) > 0 {
+		errs := p.publishBatch(batch)
+
+		var retry []*pendingPublish
+		for i, m := range batch {
+			if errs[i] == nil {
+				p.confirmed.Add(1)
+				p.finish(m, nil)
+				continue
+			}
+			m.attempts++
+			if m.attempts > p.cfg.PublishMaxRetries {
+				p.failed.Add(1)
+				p.finish(m, fmt.Errorf("giving up after %d attempt(s): %w", m.attempts, errs[i]))
+				continue
+			}
+			p.retried.Add(1)
+			retry = append(retry, m)
+		}
+
+		if len(retry) > 0 {
+			delay := Backoff(retry[0].attempts, p.cfg.PublishRetryBaseDelay, p.cfg.PublishRetryMaxDelay)
+			p.logger.Warn("messages not confirmed, retrying", "count", len(retry), "delay", delay)
+			time.Sleep(delay)
+		}
+		batch = retry
+	}
+}
+
+// publishBatch publishes every message before waiting for any confirmation
+// and returns one error per message.
+func (p *Publisher) publishBatch(batch []*pendingPublish) []error {
+	errs := make([]error, len(batch))
+
+	ch, err := p.source.ConfirmChannel()
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	ctx := context.Background()
+	if p.cfg.ConfirmTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.ConfirmTimeout)
+		defer cancel()
+	}
+
+	confirms := make([]*amqp.DeferredConfirmation, len(batch))
+	for i, m := range batch {
+		confirms[i], errs[i] = ch.PublishWithDeferredConfirmWithContext(ctx, m.exchange, m.key, false, false, m.msg)
+	}
+	for i, confirm := range confirms {
+		if errs[i] != nil {
+			continue
+		}
+		if confirm == nil {
+			errs[i] = errors.New("channel is not in confirm mode")
+			continue
+		}
+		acked, err := confirm.WaitContext(ctx)
+		switch {
+		case err != nil:
+			errs[i] = fmt.Errorf("waiting for confirmation: %w", err)
+		case !acked:
+			errs[i] = errors.New("broker nacked the message")
+		}
+	}
+	return errs
+}
+
+func (p *Publisher) finish(m *pendingPublish, err error) {
+	if err != nil {
+		p.logger.Error("publishing failed", "routing_key", m.key, "error", err)
+	}
+	if m.done != nil {
+		m.done(err)
+	}
+}
@@ -0,0 +1,85 @@
+package rabbitmq_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/logging"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/rabbitmq"
+)
+
+// unreachableSource never has a channel, like a Connection that is
+// reconnecting. Every call waits on gate first when it is set.
+type unreachableSource struct {
+	gate chan struct{}
+}
+
+func (s *unreachableSource) ConfirmChannel() (*amqp.Channel, error) {
+	if s.gate != nil {
+		<-s.gate
+	}
+	return nil, rabbitmq.ErrNotConnected
+}
+
+func testPublisherConfig() config.RabbitMQConfig {
+	return config.RabbitMQConfig{
+		PublishBufferSize:     1,
+		PublishMaxRetries:     2,
+		PublishRetryBaseDelay: time.Millisecond,
+		PublishRetryMaxDelay:  time.Millisecond,
+	}
+}
+
+func TestPublisher_GivesUpAfterRetries(t *testing.T) {
+	p := rabbitmq.NewPublisher(&unreachableSource{}, testPublisherConfig(), logging.Discard())
+
+	results := make(chan error, 1)
+	err := p.Publish("", rabbitmq.NotificationsQueue, amqp.Publishing{Body: []byte("{}")}, func(err error) {
+		results <- err
+	})
+	assert.NoError(t, err)
+
+	select {
+	case err := <-results:
+		assert.ErrorIs(t, err, rabbitmq.ErrNotConnected)
+	case <-time.After(time.Second):
+		t.Fatal("no delivery result")
+	}
+
+	p.Close()
+	stats := p.Stats()
+	assert.Equal(t, uint64(1), stats.Queued)
+	assert.Equal(t, uint64(2), stats.Retried)
+	assert.Equal(t, uint64(1), stats.Failed)
+	assert.Equal(t, uint64(0), stats.Confirmed)
+}
+
+func TestPublisher_BufferFull(t *testing.T) {
+	source := &unreachableSource{gate: make(chan struct{})}
+	p := rabbitmq.NewPublisher(source, testPublisherConfig(), logging.Discard())
+
+	msg := amqp.Publishing{Body: []byte("{}")}
+	// The flusher blocks on the gate with the first batch, so the buffer
+	// fills up and Publish starts failing instead of blocking.
+	assert.NoError(t, p.Publish("", rabbitmq.NotificationsQueue, msg, nil))
+	assert.Eventually(t, func() bool {
+		return errors.Is(p.Publish("", rabbitmq.NotificationsQueue, msg, nil), rabbitmq.ErrBufferFull)
+	}, time.Second, time.Millisecond)
+
+	close(source.gate)
+	p.Close()
+	assert.NotZero(t, p.Stats().Dropped)
+}
+
+func TestPublisher_ClosedRejects(t *testing.T) {
+	p := rabbitmq.NewPublisher(&unreachableSource{}, testPublisherConfig(), logging.Discard())
+	p.Close()
+
+	err := p.Publish("", rabbitmq.NotificationsQueue, amqp.Publishing{}, nil)
+	assert.ErrorIs(t, err, rabbitmq.ErrClosed)
+}
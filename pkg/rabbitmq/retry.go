@@ -0,0 +1,45 @@
+package rabbitmq
+
+import (
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RetryCount reads RetryCountHeader from headers, returning 0 when it is
+// missing or not an integer.
+func RetryCount(headers amqp.Table) int {
+	switch v := headers[RetryCountHeader].(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+// Backoff returns the delay before retry number attempt (starting at 1):
+// base doubled for every earlier attempt, capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// CopyHeaders returns a copy of headers that can be modified without
+// touching the original delivery.
+func CopyHeaders(headers amqp.Table) amqp.Table {
+	out := make(amqp.Table, len(headers)+1)
+	for k, v := range headers {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,25 @@
+package rabbitmq_test
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/rabbitmq"
+)
+
+func TestRetryCount(t *testing.T) {
+	assert.Equal(t, 0, rabbitmq.RetryCount(nil))
+	assert.Equal(t, 0, rabbitmq.RetryCount(amqp.Table{rabbitmq.RetryCountHeader: "3"}))
+	assert.Equal(t, 3, rabbitmq.RetryCount(amqp.Table{rabbitmq.RetryCountHeader: int32(3)}))
+	assert.Equal(t, 4, rabbitmq.RetryCount(amqp.Table{rabbitmq.RetryCountHeader: int64(4)}))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, rabbitmq.Backoff(1, time.Second, time.Minute))
+	assert.Equal(t, 2*time.Second, rabbitmq.Backoff(2, time.Second, time.Minute))
+	assert.Equal(t, 8*time.Second, rabbitmq.Backoff(4, time.Second, time.Minute))
+	assert.Equal(t, time.Minute, rabbitmq.Backoff(20, time.Second, time.Minute))
+}
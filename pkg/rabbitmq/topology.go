@@ -0,0 +1,122 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// NotificationsQueue is the work queue consumed by the notification
+	// service.
+	NotificationsQueue = "notifications.work"
+	// LegacyNotificationsQueue is the queue earlier releases published to.
+	// RabbitMQ does not let the arguments of an existing queue change, so it
+	// cannot gain a dead-letter exchange; DrainLegacyQueue moves whatever is
+	// left in it to NotificationsQueue.
+	LegacyNotificationsQueue = "notifications"
+	// RetryQueue holds messages waiting out their backoff; each message
+	// carries its own expiration and is dead-lettered back to
+	// NotificationsQueue when it expires.
+	RetryQueue = "notifications.retry"
+	// DeadLetterExchange and DeadLetterQueue receive messages rejected by the
+	// consumer, either because they cannot be processed at all or because
+	// they ran out of retries.
+	DeadLetterExchange = "notifications.dlx"
+	DeadLetterQueue    = "notifications.dead"
+
+	// RetryCountHeader counts how many times a message was sent back through
+	// RetryQueue.
+	RetryCountHeader = "x-retry-count"
+	// LastErrorHeader records why a message was dead-lettered by the
+	// consumer.
+	LastErrorHeader = "x-last-error"
+)
+
+// DeclareTopology declares the notification queues and the dead-letter
+// exchange. Both services must declare the queues with identical arguments,
+// so they go through this function rather than calling QueueDeclare directly.
+func DeclareTopology(ch *amqp.Channel) (amqp.Queue, error) {
+	if err := ch.ExchangeDeclare(DeadLetterExchange, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return amqp.Queue{}, fmt.Errorf("error declaring dead-letter exchange: %w", err)
+	}
+	if _, err := ch.QueueDeclare(DeadLetterQueue, true, false, false, false, nil); err != nil {
+		return amqp.Queue{}, fmt.Errorf("error declaring dead-letter queue: %w", err)
+	}
+	if err := ch.QueueBind(DeadLetterQueue, DeadLetterQueue, DeadLetterExchange, false, nil); err != nil {
+		return amqp.Queue{}, fmt.Errorf("error binding dead-letter queue: %w", err)
+	}
+
+	_, err := ch.QueueDeclare(RetryQueue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": NotificationsQueue,
+	})
+	if err != nil {
+		return amqp.Queue{}, fmt.Errorf("error declaring retry queue: %w", err)
+	}
+
+	q, err := ch.QueueDeclare(NotificationsQueue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    DeadLetterExchange,
+		"x-dead-letter-routing-key": DeadLetterQueue,
+	})
+	if err != nil {
+		return amqp.Queue{}, fmt.Errorf("error declaring queue: %w", err)
+	}
+	return q, nil
+}
+
+// DrainLegacyQueue moves the messages in LegacyNotificationsQueue to
+// NotificationsQueue and returns how many it moved. Each message is
+// republished and acknowledged in one transaction, so none is lost if the
+// broker goes away halfway. The queue itself is left in place, as publishers
+// from earlier releases may still be running during an upgrade.
+func DrainLegacyQueue(conn *amqp.Connection) (int, error) {
+	// A channel of its own, because a missing queue closes it and the
+	// transaction mode sticks to it
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("error opening rabbitmq channel: %w", err)
+	}
+	defer ch.Close()
+
+	if _, err := ch.QueueDeclarePassive(LegacyNotificationsQueue, true, false, false, false, nil); err != nil {
+		var amqpErr *amqp.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error inspecting legacy queue: %w", err)
+	}
+	if err := ch.Tx(); err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	moved := 0
+	for {
+		d, ok, err := ch.Get(LegacyNotificationsQueue, false)
+		if err != nil {
+			return moved, fmt.Errorf("error reading legacy queue: %w", err)
+		}
+		if !ok {
+			return moved, nil
+		}
+		err = ch.PublishWithContext(context.Background(), "", NotificationsQueue, false, false, amqp.Publishing{
+			Headers:      d.Headers,
+			ContentType:  d.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Body:         d.Body,
+		})
+		if err == nil {
+			err = d.Ack(false)
+		}
+		if err == nil {
+			err = ch.TxCommit()
+		}
+		if err != nil {
+			_ = ch.TxRollback()
+			return moved, fmt.Errorf("error moving message from legacy queue: %w", err)
+		}
+		moved++
+	}
+}
@@ -0,0 +1,121 @@
+package rabbitmq_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/rabbitmq"
+)
+
+// setUpBroker starts RabbitMQ in a container and returns its URL.
+func setUpBroker(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "rabbitmq:3.13-alpine",
+			ExposedPorts: []string{"5672/tcp"},
+			// guest may only connect from localhost
+			Env: map[string]string{
+				"RABBITMQ_DEFAULT_USER": "test",
+				"RABBITMQ_DEFAULT_PASS": "test",
+			},
+			WaitingFor: wait.ForLog("Server startup complete").WithStartupTimeout(time.Minute),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, container.Terminate(ctx))
+	})
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "5672/tcp")
+	require.NoError(t, err)
+	return fmt.Sprintf("amqp://test:test@%s:%s/", host, port.Port())
+}
+
+func TestDeclareTopology_IntegrationTest_FromLegacyQueue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	conn, err := amqp.Dial(setUpBroker(t))
+	require.NoError(t, err)
+	defer conn.Close()
+	ch, err := conn.Channel()
+	require.NoError(t, err)
+
+	// The queue as earlier releases declared it, with messages waiting
+	_, err = ch.QueueDeclare(rabbitmq.LegacyNotificationsQueue, true, false, false, false, nil)
+	require.NoError(t, err)
+	for _, body := range []string{`{"message":"one"}`, `{"message":"two"}`} {
+		err = ch.PublishWithContext(context.Background(), "", rabbitmq.LegacyNotificationsQueue, false, false, amqp.Publishing{
+			Headers:      amqp.Table{"x-request-id": "req-1"},
+			DeliveryMode: amqp.Persistent,
+			Body:         []byte(body),
+		})
+		require.NoError(t, err)
+	}
+
+	_, err = rabbitmq.DeclareTopology(ch)
+	require.NoError(t, err)
+	moved, err := rabbitmq.DrainLegacyQueue(conn)
+	require.NoError(t, err)
+	assert.Equal(t, 2, moved)
+
+	legacy, err := ch.QueueDeclarePassive(rabbitmq.LegacyNotificationsQueue, true, false, false, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, legacy.Messages)
+
+	var bodies []string
+	for {
+		d, ok, err := ch.Get(rabbitmq.NotificationsQueue, true)
+		require.NoError(t, err)
+		if !ok {
+			break
+		}
+		assert.Equal(t, "req-1", d.Headers["x-request-id"])
+		bodies = append(bodies, string(d.Body))
+	}
+	assert.Equal(t, []string{`{"message":"one"}`, `{"message":"two"}`}, bodies)
+
+	// Declaring again, as every restart does, still works
+	_, err = rabbitmq.DeclareTopology(ch)
+	require.NoError(t, err)
+	moved, err = rabbitmq.DrainLegacyQueue(conn)
+	require.NoError(t, err)
+	assert.Zero(t, moved)
+}
+
+func TestDrainLegacyQueue_IntegrationTest_NoLegacyQueue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	conn, err := amqp.Dial(setUpBroker(t))
+	require.NoError(t, err)
+	defer conn.Close()
+	ch, err := conn.Channel()
+	require.NoError(t, err)
+
+	_, err = rabbitmq.DeclareTopology(ch)
+	require.NoError(t, err)
+	moved, err := rabbitmq.DrainLegacyQueue(conn)
+	require.NoError(t, err)
+	assert.Zero(t, moved)
+
+	// The legacy queue is not created on a new broker
+	probe, err := conn.Channel()
+	require.NoError(t, err)
+	_, err = probe.QueueDeclarePassive(rabbitmq.LegacyNotificationsQueue, true, false, false, false, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AMQPCarrier adapts AMQP message headers to a propagation.TextMapCarrier.
+type AMQPCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = AMQPCarrier(nil)
+
+func (c AMQPCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+func (c AMQPCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c AMQPCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// InjectAMQP writes the trace context of ctx into headers, which must not be
+// nil.
+func InjectAMQP(ctx context.Context, headers amqp.Table) {
+	otel.GetTextMapPropagator().Inject(ctx, AMQPCarrier(headers))
+}
+
+// ExtractAMQP returns ctx carrying the trace context found in headers, if
+// any.
+func ExtractAMQP(ctx context.Context, headers amqp.Table) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, AMQPCarrier(headers))
+}
+
+// StartPublishSpan starts a producer span for a message sent to queue and
+// injects its context into headers, so the consumer can continue the trace.
+// The span should be ended once the broker confirmed the message.
+func StartPublishSpan(ctx context.Context, queue string, headers amqp.Table) trace.Span {
+	ctx, span := Tracer().Start(ctx, queue+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingDestinationName(queue),
+		),
+	)
+	InjectAMQP(ctx, headers)
+	return span
+}
+
+// StartConsumeSpan starts a consumer span for a message received from queue,
+// as a child of the trace context found in its headers.
+func StartConsumeSpan(ctx context.Context, queue string, headers amqp.Table) (context.Context, trace.Span) {
+	return Tracer().Start(ExtractAMQP(ctx, headers), queue+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingDestinationName(queue),
+		),
+	)
+}
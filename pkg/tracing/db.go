@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartDBSpan starts a client span for a repository call; operation names
+// the call, e.g. "customers.GetByID".
+func StartDBSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			attribute.String("db.operation", operation),
+		),
+	)
+}
+
+// EndSpan records err on span, if any, and ends it. sql.ErrNoRows is an
+// expected outcome rather than a failure and is not recorded.
+func EndSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,12 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+// ServerOption returns the gRPC server option that starts a server span for
+// every call, continuing the trace from the incoming metadata.
+func ServerOption() grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler())
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+)
+
+// Exporters accepted in config.TracingConfig.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// instrumentationName identifies the spans this module starts itself, as
+// opposed to the ones from the gin and gRPC instrumentation.
+const instrumentationName = "github.com/iBoBoTi/aqua-sec-inventory"
+
+// Init installs the global tracer provider and the W3C trace context
+// propagator for service. The returned function flushes buffered spans and
+// must be called on shutdown.
+//
+// With the "none" exporter spans are not recorded, but incoming trace
+// context is still propagated to downstream calls and messages.
+func Init(ctx context.Context, cfg config.TracingConfig, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, expected %s, %s or %s",
+			cfg.Exporter, ExporterNone, ExporterStdout, ExporterOTLP)
+	}
+}
+
+// Tracer returns the tracer for the spans started by this module.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
@@ -0,0 +1,103 @@
+package tracing_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iBoBoTi/aqua-sec-inventory/config"
+	"github.com/iBoBoTi/aqua-sec-inventory/pkg/tracing"
+)
+
+// recordSpans installs a tracer provider that keeps finished spans in memory.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+func TestInit_RejectsUnknownExporter(t *testing.T) {
+	_, err := tracing.Init(context.Background(), config.TracingConfig{Exporter: "zipkin"}, "test")
+	assert.Error(t, err)
+}
+
+func TestInit_NoneExporter(t *testing.T) {
+	shutdown, err := tracing.Init(context.Background(), config.TracingConfig{Exporter: tracing.ExporterNone}, "test")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestAMQP_PropagatesTraceAcrossMessage(t *testing.T) {
+	recorder := recordSpans(t)
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "request")
+	headers := amqp.Table{}
+	publish := tracing.StartPublishSpan(ctx, "notifications", headers)
+	publish.End()
+	parent.End()
+
+	assert.Contains(t, headers, "traceparent")
+
+	_, consume := tracing.StartConsumeSpan(context.Background(), "notifications", headers)
+	consume.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	publishSpan, consumeSpan := spans[0], spans[2]
+	assert.Equal(t, trace.SpanKindProducer, publishSpan.SpanKind())
+	assert.Equal(t, trace.SpanKindConsumer, consumeSpan.SpanKind())
+	assert.Equal(t, parent.SpanContext().TraceID(), consumeSpan.SpanContext().TraceID())
+	assert.Equal(t, publishSpan.SpanContext().SpanID(), consumeSpan.Parent().SpanID())
+}
+
+func TestExtractAMQP_WithoutHeaders(t *testing.T) {
+	recordSpans(t)
+
+	ctx := tracing.ExtractAMQP(context.Background(), nil)
+	assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+}
+
+func TestInjectExtract_RoundTrip(t *testing.T) {
+	recordSpans(t)
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "request")
+	defer span.End()
+
+	stored := tracing.Inject(ctx)
+	restored := trace.SpanContextFromContext(tracing.Extract(context.Background(), stored))
+	assert.Equal(t, span.SpanContext().TraceID(), restored.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), restored.SpanID())
+}
+
+func TestEndSpan_IgnoresNoRows(t *testing.T) {
+	recorder := recordSpans(t)
+
+	_, span := tracing.StartDBSpan(context.Background(), "customers.GetByID")
+	tracing.EndSpan(span, sql.ErrNoRows)
+	_, span = tracing.StartDBSpan(context.Background(), "customers.Create")
+	tracing.EndSpan(span, errors.New("connection refused"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, trace.SpanKindClient, spans[1].SpanKind())
+}